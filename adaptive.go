@@ -0,0 +1,74 @@
+package timedmap
+
+import "time"
+
+// StartCleanerAdaptive starts the cleanup loop with an
+// interval which is tuned after each cycle between min
+// and max based on the share of key-value pairs which
+// expired in the cycle.
+//
+// The interval starts at min. It is halved after cycles
+// in which at least a quarter of the pairs expired and
+// doubled after cycles in which no pair expired, so the
+// cleaner runs often while many pairs are dying and
+// rarely while the map is idle.
+//
+// If the cleanup loop is already running, it will be
+// stopped and restarted using the new specification.
+func (tm *TimedMap) StartCleanerAdaptive(min, max time.Duration) {
+	if tm.IsClosed() {
+		return
+	}
+	if min <= 0 {
+		min = max
+	}
+	if max < min {
+		max = min
+	}
+	if min <= 0 {
+		return
+	}
+	if tm.cleanerRunning {
+		tm.StopCleaner()
+	}
+	tm.cleanerRunning = true
+	go tm.adaptiveCleanupLoop(min, max)
+}
+
+// adaptiveCleanupLoop holds the loop executing the
+// cleanup with an interval between min and max.
+func (tm *TimedMap) adaptiveCleanupLoop(min, max time.Duration) {
+	interval := min
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			expired, size := tm.observedCleanUp()
+			interval = nextCleanupInterval(interval, min, max, expired, size)
+			timer.Reset(interval)
+		case <-tm.cleanerStopChan:
+			return
+		}
+	}
+}
+
+// nextCleanupInterval returns the interval following a
+// cycle with the given interval, in which expired of
+// size pairs expired, bounded by min and max.
+func nextCleanupInterval(interval, min, max time.Duration, expired, size int) time.Duration {
+	switch {
+	case expired == 0:
+		interval *= 2
+	case expired*4 >= size:
+		interval /= 2
+	}
+	if interval < min {
+		return min
+	}
+	if interval > max {
+		return max
+	}
+	return interval
+}
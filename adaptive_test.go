@@ -0,0 +1,39 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextCleanupInterval(t *testing.T) {
+	const min, max = 10 * time.Millisecond, time.Second
+
+	assert.Equal(t, 40*time.Millisecond, nextCleanupInterval(20*time.Millisecond, min, max, 0, 100))
+	assert.Equal(t, max, nextCleanupInterval(800*time.Millisecond, min, max, 0, 100))
+	assert.Equal(t, 20*time.Millisecond, nextCleanupInterval(40*time.Millisecond, min, max, 25, 100))
+	assert.Equal(t, min, nextCleanupInterval(15*time.Millisecond, min, max, 50, 100))
+	assert.Equal(t, 40*time.Millisecond, nextCleanupInterval(40*time.Millisecond, min, max, 10, 100))
+}
+
+func TestStartCleanerAdaptive(t *testing.T) {
+	tm := New(0)
+	tm.StartCleanerAdaptive(dCleanupTick, 100*time.Millisecond)
+
+	var expired bool
+	tm.Set(1, 1, 5*time.Millisecond, func(interface{}) {
+		expired = true
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	tm.mtx.RLock()
+	assert.True(t, expired)
+	assert.Equal(t, 0, tm.container.len())
+	tm.mtx.RUnlock()
+
+	tm.StopCleaner()
+	tm.Set(2, 2, -time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, tm.Size())
+}
@@ -0,0 +1,34 @@
+package timedmap
+
+import "time"
+
+// Add sets the value of a key like Set, which expires
+// after ttl, only if there is no live value to the key.
+// Otherwise, ErrKeyExists is returned and the map is not
+// modified, which allows to use the map for idempotency
+// keys and deduplication.
+//
+// Unlike Set, Add returns the errors of SetWithOptions
+// if the value could not be set.
+func (tm *TimedMap) Add(key, value interface{}, ttl time.Duration) error {
+	return tm.add(key, 0, value, ttl)
+}
+
+func (s *section) Add(key, value interface{}, ttl time.Duration) error {
+	return s.tm.add(key, s.sec, value, ttl)
+}
+
+// add sets the value of the given key in the
+// given section if it is not present.
+func (tm *TimedMap) add(key interface{}, sec int, value interface{}, ttl time.Duration) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if v, ok := tm.container.get(k); ok && !tm.closed && !tm.isExpired(v, time.Now()) {
+		return ErrKeyExists
+	}
+	_, _, err := tm.swapLocked(k, value, ttl, setOptions{})
+	return err
+}
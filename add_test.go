@@ -0,0 +1,41 @@
+package timedmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdd(t *testing.T) {
+	tm := New(0)
+
+	var added int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := tm.Add("req-1", i, time.Hour); err == nil {
+				atomic.AddInt32(&added, 1)
+			} else {
+				assert.ErrorIs(t, err, ErrKeyExists)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, added)
+
+	tm.Set("a", 1, -time.Millisecond)
+	assert.NoError(t, tm.Add("a", 2, time.Hour))
+	assert.Equal(t, 2, tm.GetValue("a"))
+
+	assert.NoError(t, tm.Section(1).Add("a", 3, time.Hour))
+	assert.ErrorIs(t, tm.Section(1).Add("a", 4, time.Hour), ErrKeyExists)
+	assert.Equal(t, 3, tm.Section(1).GetValue("a"))
+
+	tm.Close()
+	assert.ErrorIs(t, tm.Add("b", 1, time.Hour), ErrClosed)
+}
@@ -0,0 +1,185 @@
+package timedmap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// AuditOp is the kind of mutation
+// recorded in the audit log.
+type AuditOp string
+
+const (
+	// AuditSet records that the value
+	// of a key has been set.
+	AuditSet AuditOp = "set"
+
+	// AuditRemove records that a key-value
+	// pair has been removed.
+	AuditRemove AuditOp = "remove"
+
+	// AuditExpire records that the expire
+	// time of a key-value pair has been changed.
+	AuditExpire AuditOp = "expire"
+)
+
+// maxAuditSummary is the maximum length of
+// the summaries of values in audit records.
+const maxAuditSummary = 64
+
+// AuditRecord is a single mutation recorded
+// in the audit log.
+type AuditRecord struct {
+	// Time is the time of the mutation.
+	Time time.Time
+
+	// Op is the kind of mutation.
+	Op AuditOp
+
+	// Section is the section of the key.
+	Section int
+
+	// Key is the key which has been mutated.
+	Key interface{}
+
+	// Actor is the actor passed using WithActor
+	// or ContextWithActor, if any.
+	Actor string
+
+	// Old and New summarize the previous and the new
+	// value, or the expire times for AuditExpire. Old
+	// is empty if no live pair existed before and New
+	// is empty for AuditRemove.
+	Old, New string
+}
+
+// String returns the record formatted as
+// a single line of the audit log.
+func (r AuditRecord) String() string {
+	return fmt.Sprintf("%s %s sec=%d key=%v actor=%q old=%q new=%q",
+		r.Time.Format(time.RFC3339Nano), r.Op, r.Section, r.Key,
+		r.Actor, r.Old, r.New)
+}
+
+// auditLog is a ring buffer of audit records.
+type auditLog struct {
+	records []AuditRecord
+	next    int
+	full    bool
+	w       io.Writer
+}
+
+type actorKey struct{}
+
+// ContextWithActor returns a copy of ctx carrying
+// actor, which is recorded in the audit log for
+// mutations performed with SetCtx and RemoveCtx.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFrom returns the actor carried by ctx.
+func actorFrom(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditLog returns the records of the audit log
+// configured using WithAuditLog which are newer than
+// since, in the order they were recorded. nil is
+// returned if the map has no audit log.
+func (tm *TimedMap) AuditLog(since time.Time) []AuditRecord {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	a := tm.audit
+	if a == nil {
+		return nil
+	}
+
+	var ordered []AuditRecord
+	if a.full {
+		ordered = append(ordered, a.records[a.next:]...)
+	}
+	ordered = append(ordered, a.records[:a.next]...)
+
+	res := make([]AuditRecord, 0, len(ordered))
+	for _, r := range ordered {
+		if r.Time.After(since) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// record appends a record to the audit log, if the
+// map has one, and writes it to the sink of the log.
+// The write lock of the map must be held.
+func (tm *TimedMap) record(op AuditOp, k keyWrap, actor, before, after string) {
+	a := tm.audit
+	if a == nil {
+		return
+	}
+
+	r := AuditRecord{
+		Time:    time.Now(),
+		Op:      op,
+		Section: k.sec,
+		Key:     k.key,
+		Actor:   actor,
+		Old:     before,
+		New:     after,
+	}
+
+	if len(a.records) > 0 {
+		a.records[a.next] = r
+		a.next++
+		if a.next == len(a.records) {
+			a.next = 0
+			a.full = true
+		}
+	}
+	if a.w != nil {
+		fmt.Fprintln(a.w, r)
+	}
+}
+
+// summarize returns a summary of v for the audit log.
+func summarize(v interface{}) string {
+	s := fmt.Sprint(v)
+	if len(s) > maxAuditSummary {
+		s = s[:maxAuditSummary-3] + "..."
+	}
+	return s
+}
+
+// summarizeExpiry returns a summary of the
+// expire time of v for the audit log.
+func summarizeExpiry(v *element) string {
+	if !v.expired {
+		return "never"
+	}
+	return v.expires.Round(0).Format(time.RFC3339Nano)
+}
+
+// expirySummary returns the summary of the expire
+// time of v if the map has an audit log.
+func (tm *TimedMap) expirySummary(v *element) string {
+	if tm.audit == nil {
+		return ""
+	}
+	return summarizeExpiry(v)
+}
+
+// expiryChanged records the change of the expire time
+// of v from old in the audit log and the change feed.
+// The write lock of the map must be held.
+func (tm *TimedMap) expiryChanged(k keyWrap, v *element, old string) {
+	tm.publish(ChangeExpiry, k, v)
+	if tm.audit == nil {
+		return
+	}
+	tm.record(AuditExpire, k, "", old, summarizeExpiry(v))
+}
@@ -0,0 +1,77 @@
+package timedmap
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	tm := NewWithOptions(0, WithAuditLog(10, &buf))
+
+	start := time.Now()
+	assert.NoError(t, tm.SetWithOptions("a", 1, time.Hour, WithActor("alice")))
+	tm.Set("a", 2, time.Hour)
+	assert.NoError(t, tm.SetExpires("a", NoExpiration))
+	assert.NoError(t, tm.Section(1).SetCtx(ContextWithActor(context.Background(), "bob"), "b", 3, time.Hour))
+	assert.NoError(t, tm.RemoveCtx(ContextWithActor(context.Background(), "carol"), "a"))
+	tm.Remove("c")
+
+	recs := tm.AuditLog(start)
+	assert.Len(t, recs, 5)
+
+	assert.Equal(t, AuditSet, recs[0].Op)
+	assert.Equal(t, "a", recs[0].Key)
+	assert.Equal(t, "alice", recs[0].Actor)
+	assert.Equal(t, "", recs[0].Old)
+	assert.Equal(t, "1", recs[0].New)
+
+	assert.Equal(t, "1", recs[1].Old)
+	assert.Equal(t, "2", recs[1].New)
+
+	assert.Equal(t, AuditExpire, recs[2].Op)
+	assert.NotEmpty(t, recs[2].Old)
+	assert.Equal(t, "never", recs[2].New)
+
+	assert.Equal(t, 1, recs[3].Section)
+	assert.Equal(t, "bob", recs[3].Actor)
+
+	assert.Equal(t, AuditRemove, recs[4].Op)
+	assert.Equal(t, "carol", recs[4].Actor)
+	assert.Equal(t, "2", recs[4].Old)
+
+	assert.Empty(t, tm.AuditLog(recs[4].Time))
+	assert.Len(t, tm.AuditLog(recs[2].Time), 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Contains(t, lines[0], `set sec=0 key=a actor="alice" old="" new="1"`)
+}
+
+func TestAuditLogRing(t *testing.T) {
+	tm := NewWithOptions(0, WithAuditLog(3, nil))
+
+	for i := 0; i < 5; i++ {
+		tm.Set(i, strings.Repeat("x", 100), time.Hour)
+	}
+
+	recs := tm.AuditLog(time.Time{})
+	assert.Len(t, recs, 3)
+	for i, r := range recs {
+		assert.Equal(t, i+2, r.Key)
+		assert.Len(t, r.New, maxAuditSummary)
+	}
+}
+
+func TestAuditLogDisabled(t *testing.T) {
+	tm := New(0)
+
+	tm.Set(1, 1, time.Hour)
+	assert.Nil(t, tm.AuditLog(time.Time{}))
+	assert.ErrorIs(t, tm.Reconfigure(WithAuditLog(1, nil)), ErrNotReconfigurable)
+}
@@ -0,0 +1,131 @@
+package timedmap
+
+import "time"
+
+// Backend is the storage engine of a TimedMap, which
+// holds the elements of the map and keeps track of
+// which of them are due to expire.
+//
+// Backends are selected on construction using the
+// WithBackend option. Available backends are created
+// by NewMapBackend, NewShardedBackend, NewHeapBackend
+// and NewWheelBackend. The methods of Backend are
+// unexported, so it can only be implemented by this
+// package. A Backend instance must not be shared
+// between multiple maps.
+//
+// All methods are called while holding the lock of
+// the map, so implementations do not need to be safe
+// for concurrent use. For the same reason, there is
+// no backend built on sync.Map; use NewSharded to
+// spread a workload across several locks.
+type Backend interface {
+	// get returns the element stored for k.
+	get(k keyWrap) (*element, bool)
+
+	// put stores v for k, replacing any previously
+	// stored element.
+	put(k keyWrap, v *element)
+
+	// del removes the element stored for k.
+	del(k keyWrap)
+
+	// touch is called after the expiry of the
+	// element v stored for k has been changed.
+	touch(k keyWrap, v *element)
+
+	// each calls fn for all stored elements until
+	// fn returns false.
+	each(fn func(k keyWrap, v *element) bool)
+
+	// due calls fn for all elements which expired
+	// at now. fn may delete the passed element.
+	due(now time.Time, fn func(k keyWrap, v *element))
+
+	// len returns the number of stored elements.
+	len() int
+
+	// clear removes all stored elements.
+	clear()
+}
+
+// mapBackend stores the elements in a plain map
+// and scans all elements on each cleanup cycle.
+type mapBackend struct {
+	m map[keyWrap]*element
+}
+
+// NewMapBackend returns a Backend which stores the
+// elements in a plain map and iterates through all
+// of them on each cleanup cycle.
+//
+// This is the default backend. It has the lowest
+// memory and write overhead, but the cost of a
+// cleanup cycle grows linear with the size of the
+// map, even when nothing expires.
+func NewMapBackend() Backend {
+	return &mapBackend{
+		m: make(map[keyWrap]*element),
+	}
+}
+
+func (b *mapBackend) get(k keyWrap) (*element, bool) {
+	v, ok := b.m[k]
+	return v, ok
+}
+
+func (b *mapBackend) put(k keyWrap, v *element) {
+	b.m[k] = v
+}
+
+func (b *mapBackend) del(k keyWrap) {
+	delete(b.m, k)
+}
+
+func (b *mapBackend) touch(k keyWrap, v *element) {}
+
+func (b *mapBackend) each(fn func(k keyWrap, v *element) bool) {
+	for k, v := range b.m {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+func (b *mapBackend) due(now time.Time, fn func(k keyWrap, v *element)) {
+	for k, v := range b.m {
+		if v.isExpiredAt(now) {
+			fn(k, v)
+		}
+	}
+}
+
+func (b *mapBackend) len() int {
+	return len(b.m)
+}
+
+func (b *mapBackend) clear() {
+	for k := range b.m {
+		delete(b.m, k)
+	}
+}
+
+// indexEntry is an entry of an expiry index referencing
+// the element v stored for k, which is due at expires.
+//
+// Index entries are invalidated lazily: an entry is
+// only valid as long as v is still stored for k and
+// its expiry has not changed since the entry has been
+// created.
+type indexEntry struct {
+	k       keyWrap
+	v       *element
+	expires time.Time
+}
+
+// valid returns true if the index entry still
+// refers to the element stored in m.
+func (e *indexEntry) valid(m map[keyWrap]*element) bool {
+	cur, ok := m[e.k]
+	return ok && cur == e.v && cur.expired && cur.deadline().Equal(e.expires)
+}
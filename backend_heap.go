@@ -0,0 +1,121 @@
+package timedmap
+
+import (
+	"container/heap"
+	"time"
+)
+
+// heapBackend stores the elements in a map and keeps
+// a min-heap ordered by expiry time, so that a cleanup
+// cycle only touches elements which are due.
+type heapBackend struct {
+	m map[keyWrap]*element
+	h expiryHeap
+}
+
+// NewHeapBackend returns a Backend which stores the
+// elements in a map and indexes them in a min-heap
+// ordered by their expiry time.
+//
+// The cost of a cleanup cycle only depends on the
+// number of expired elements, which makes this
+// backend suitable for very large maps. In exchange,
+// each write costs O(log n) and changes of the expiry
+// of an element leave stale heap entries behind until
+// they are popped or the heap is compacted.
+func NewHeapBackend() Backend {
+	return &heapBackend{
+		m: make(map[keyWrap]*element),
+	}
+}
+
+func (b *heapBackend) get(k keyWrap) (*element, bool) {
+	v, ok := b.m[k]
+	return v, ok
+}
+
+func (b *heapBackend) put(k keyWrap, v *element) {
+	b.m[k] = v
+	b.touch(k, v)
+}
+
+func (b *heapBackend) del(k keyWrap) {
+	delete(b.m, k)
+}
+
+func (b *heapBackend) touch(k keyWrap, v *element) {
+	if !v.expired {
+		return
+	}
+	heap.Push(&b.h, indexEntry{k: k, v: v, expires: v.deadline()})
+	b.compact()
+}
+
+func (b *heapBackend) each(fn func(k keyWrap, v *element) bool) {
+	for k, v := range b.m {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+func (b *heapBackend) due(now time.Time, fn func(k keyWrap, v *element)) {
+	for len(b.h) > 0 && now.After(b.h[0].expires) {
+		e := heap.Pop(&b.h).(indexEntry)
+		if e.valid(b.m) {
+			fn(e.k, e.v)
+		}
+	}
+}
+
+func (b *heapBackend) len() int {
+	return len(b.m)
+}
+
+func (b *heapBackend) clear() {
+	for k := range b.m {
+		delete(b.m, k)
+	}
+	b.h = nil
+}
+
+// compact rebuilds the heap from the stored elements
+// when it holds more than twice as many entries as
+// there are elements in the map.
+func (b *heapBackend) compact() {
+	if len(b.h) < 64 || len(b.h) <= 2*len(b.m) {
+		return
+	}
+
+	h := make(expiryHeap, 0, len(b.m))
+	seen := make(map[*element]struct{}, len(b.m))
+	for _, e := range b.h {
+		if _, ok := seen[e.v]; !ok && e.valid(b.m) {
+			seen[e.v] = struct{}{}
+			h = append(h, e)
+		}
+	}
+	heap.Init(&h)
+	b.h = h
+}
+
+// expiryHeap implements heap.Interface for index
+// entries ordered by their expiry time.
+type expiryHeap []indexEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expires.Before(h[j].expires) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *expiryHeap) Push(x interface{}) {
+	*h = append(*h, x.(indexEntry))
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = indexEntry{}
+	*h = old[:n-1]
+	return e
+}
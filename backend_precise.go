@@ -0,0 +1,118 @@
+package timedmap
+
+import "time"
+
+// preciseBackend wraps the backend of a map in precise
+// expiry mode and schedules a runtime timer for the
+// deadline of each stored element, which expires the
+// element right when it is due instead of on the next
+// cleanup cycle.
+//
+// Deadlines are rounded up to multiples of window, so
+// elements with deadlines within the same window share
+// a single timer. When maxTimers timers are running,
+// no further timers are created and elements with new
+// deadlines are expired by the cleanup loop.
+//
+// Timers are invalidated lazily: when an element is
+// removed or its expiry changes, a timer firing for
+// its old deadline finds the element not due and
+// skips it.
+type preciseBackend struct {
+	Backend
+
+	tm        *TimedMap
+	window    time.Duration
+	maxTimers int
+	timers    map[int64]*deadlineTimer
+}
+
+// deadlineTimer is the timer of a deadline and
+// the keys of the elements due at it.
+type deadlineTimer struct {
+	t    *time.Timer
+	keys map[keyWrap]struct{}
+}
+
+// newPreciseBackend wraps b to schedule timers
+// expiring the elements of tm.
+func newPreciseBackend(tm *TimedMap, b Backend, window time.Duration, maxTimers int) *preciseBackend {
+	return &preciseBackend{
+		Backend:   b,
+		tm:        tm,
+		window:    window,
+		maxTimers: maxTimers,
+		timers:    make(map[int64]*deadlineTimer),
+	}
+}
+
+func (b *preciseBackend) put(k keyWrap, v *element) {
+	b.Backend.put(k, v)
+	b.schedule(k, v)
+}
+
+func (b *preciseBackend) touch(k keyWrap, v *element) {
+	b.Backend.touch(k, v)
+	b.schedule(k, v)
+}
+
+func (b *preciseBackend) clear() {
+	b.Backend.clear()
+	for deadline, dt := range b.timers {
+		dt.t.Stop()
+		delete(b.timers, deadline)
+	}
+}
+
+// schedule adds k to the timer of the deadline
+// of v, creating the timer if necessary.
+func (b *preciseBackend) schedule(k keyWrap, v *element) {
+	if !v.expired {
+		return
+	}
+
+	deadline := v.deadline().UnixNano()
+	if w := int64(b.window); w > 1 {
+		deadline = (deadline + w - 1) / w * w
+	}
+	if dt, ok := b.timers[deadline]; ok {
+		dt.keys[k] = struct{}{}
+		return
+	}
+	if b.maxTimers > 0 && len(b.timers) >= b.maxTimers {
+		return
+	}
+
+	dt := &deadlineTimer{
+		keys: map[keyWrap]struct{}{k: {}},
+	}
+	// fire right after the deadline, because
+	// elements are due after their expiry
+	delay := time.Until(v.deadline()) + time.Duration(deadline-v.deadline().UnixNano())
+	dt.t = time.AfterFunc(delay+time.Nanosecond, func() {
+		b.fire(deadline, dt)
+	})
+	b.timers[deadline] = dt
+}
+
+// fire expires the due elements of the timer dt
+// of the given deadline.
+func (b *preciseBackend) fire(deadline int64, dt *deadlineTimer) {
+	tm := b.tm
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if b.timers[deadline] != dt {
+		// the timers have been cleared
+		return
+	}
+	delete(b.timers, deadline)
+
+	now := time.Now()
+	for k := range dt.keys {
+		if v, ok := b.Backend.get(k); ok && tm.isExpired(v, now) {
+			tm.expireElement(k, v)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package timedmap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreciseExpiry(t *testing.T) {
+	for name, nb := range testBackends {
+		tm := NewWithOptions(0, WithBackend(nb()), WithPreciseExpiry())
+
+		var fired int32
+		start := time.Now()
+		var firedAfter time.Duration
+		tm.Set(1, 1, 20*time.Millisecond, func(interface{}) {
+			firedAfter = time.Since(start)
+			atomic.AddInt32(&fired, 1)
+		})
+		tm.Set(2, 2, 10*time.Millisecond)
+		tm.Refresh(2, time.Hour)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&fired), name)
+		assert.GreaterOrEqual(t, int64(firedAfter), int64(20*time.Millisecond), name)
+		assert.Nil(t, tm.getRaw(1, 0), name)
+		assert.True(t, tm.Contains(2), name)
+	}
+}
+
+func TestPreciseExpiryShared(t *testing.T) {
+	tm := NewWithOptions(0, WithPreciseExpiry())
+	b := tm.container.(*preciseBackend)
+
+	expires := time.Now().Add(time.Hour)
+	tm.mtx.Lock()
+	for i := 0; i < 3; i++ {
+		b.put(keyWrap{key: i}, &element{value: i, expired: true, expires: expires})
+	}
+	assert.Len(t, b.timers, 1)
+	tm.mtx.Unlock()
+
+	tm.Flush()
+	assert.Empty(t, b.timers)
+}
+
+func TestPreciseExpiryNotReconfigurable(t *testing.T) {
+	tm := New(0)
+	assert.ErrorIs(t, tm.Reconfigure(WithPreciseExpiry()), ErrNotReconfigurable)
+}
+
+func TestTimerCoalescing(t *testing.T) {
+	tm := NewWithOptions(0, WithPreciseExpiry(), WithTimerCoalescing(10*time.Millisecond, 2))
+	b := tm.container.(*preciseBackend)
+
+	for i := 0; i < 5; i++ {
+		tm.Set(i, i, time.Hour+time.Duration(i)*time.Microsecond)
+	}
+	tm.mtx.RLock()
+	assert.LessOrEqual(t, len(b.timers), 2)
+	tm.mtx.RUnlock()
+
+	base := time.Now().Add(time.Hour).Truncate(time.Hour)
+	tm.mtx.Lock()
+	b.clear()
+	for i := 0; i < 10; i++ {
+		b.put(keyWrap{key: i}, &element{expired: true, expires: base.Add(time.Duration(i) * time.Hour)})
+	}
+	assert.Len(t, b.timers, 2)
+	tm.mtx.Unlock()
+
+	// the second deadline falls into another window or
+	// exceeds the timer cap and is left to the cleaner
+	tm = NewWithOptions(dCleanupTick, WithPreciseExpiry(), WithTimerCoalescing(5*time.Millisecond, 1))
+	var fired int32
+	tm.Set(1, 1, 10*time.Millisecond, func(interface{}) { atomic.AddInt32(&fired, 1) })
+	tm.Set(2, 2, 11*time.Millisecond, func(interface{}) { atomic.AddInt32(&fired, 1) })
+	time.Sleep(60 * time.Millisecond)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fired))
+}
@@ -0,0 +1,98 @@
+package timedmap
+
+import "time"
+
+// shardedBackend stores the elements in several plain
+// maps and scans one of them on each cleanup cycle.
+type shardedBackend struct {
+	shards []map[keyWrap]*element
+	next   int
+	n      int
+}
+
+// NewShardedBackend returns a Backend which partitions
+// the elements across the given number of plain maps by
+// the hash of their keys, and scans only one of them on
+// each cleanup cycle, in turn.
+//
+// This spreads the cost of the map backend across
+// shards cleanup cycles, so a single cycle holds the
+// lock of the map for a fraction of the time, at the
+// cost of hashing the key on each access. Expired pairs
+// are removed up to shards-1 cycles late, but are never
+// returned by reads in the meantime. If shards is <= 0,
+// 16 shards are used.
+//
+// The shards share the lock of the map. To spread lock
+// contention across several locks, use NewSharded.
+func NewShardedBackend(shards int) Backend {
+	if shards <= 0 {
+		shards = 16
+	}
+	b := &shardedBackend{shards: make([]map[keyWrap]*element, shards)}
+	for i := range b.shards {
+		b.shards[i] = make(map[keyWrap]*element)
+	}
+	return b
+}
+
+// shard returns the map storing the element of k.
+func (b *shardedBackend) shard(k keyWrap) map[keyWrap]*element {
+	return b.shards[shardOf(k, len(b.shards))]
+}
+
+func (b *shardedBackend) get(k keyWrap) (*element, bool) {
+	v, ok := b.shard(k)[k]
+	return v, ok
+}
+
+func (b *shardedBackend) put(k keyWrap, v *element) {
+	m := b.shard(k)
+	if _, ok := m[k]; !ok {
+		b.n++
+	}
+	m[k] = v
+}
+
+func (b *shardedBackend) del(k keyWrap) {
+	m := b.shard(k)
+	if _, ok := m[k]; ok {
+		delete(m, k)
+		b.n--
+	}
+}
+
+func (b *shardedBackend) touch(k keyWrap, v *element) {}
+
+func (b *shardedBackend) each(fn func(k keyWrap, v *element) bool) {
+	for _, m := range b.shards {
+		for k, v := range m {
+			if !fn(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func (b *shardedBackend) due(now time.Time, fn func(k keyWrap, v *element)) {
+	m := b.shards[b.next]
+	b.next = (b.next + 1) % len(b.shards)
+	for k, v := range m {
+		if v.isExpiredAt(now) {
+			fn(k, v)
+		}
+	}
+}
+
+func (b *shardedBackend) len() int {
+	return b.n
+}
+
+func (b *shardedBackend) clear() {
+	for _, m := range b.shards {
+		for k := range m {
+			delete(m, k)
+		}
+	}
+	b.n = 0
+}
@@ -0,0 +1,126 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testBackends = map[string]func() Backend{
+	"map":     NewMapBackend,
+	"sharded": func() Backend { return NewShardedBackend(2) },
+	"heap":    NewHeapBackend,
+	"wheel":   func() Backend { return NewWheelBackend(dCleanupTick, 8) },
+}
+
+func TestBackendCleanup(t *testing.T) {
+	for name, nb := range testBackends {
+		tm := NewWithOptions(dCleanupTick, WithBackend(nb()))
+
+		tm.Set(1, 1, 20*time.Millisecond)
+		tm.Set(2, 2, time.Hour)
+		tm.Set(3, 3, NoExpiration)
+		tm.Section(1).Set(1, 1, 20*time.Millisecond)
+
+		time.Sleep(50 * time.Millisecond)
+		tm.mtx.RLock()
+		assert.EqualValues(t, 2, tm.container.len(), name)
+		_, ok := tm.container.get(keyWrap{key: 2})
+		tm.mtx.RUnlock()
+		assert.True(t, ok, name)
+	}
+}
+
+func TestBackendReindex(t *testing.T) {
+	for name, nb := range testBackends {
+		tm := NewWithOptions(dCleanupTick, WithBackend(nb()))
+
+		tm.Set(1, 1, 20*time.Millisecond)
+		tm.Set(2, 2, 20*time.Millisecond)
+		assert.Nil(t, tm.Refresh(1, time.Hour), name)
+		assert.Nil(t, tm.SetExpires(2, NoExpiration), name)
+
+		tm.Set(3, 3, time.Hour)
+		tm.Set(3, 3, 20*time.Millisecond)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.NotNil(t, tm.getRaw(1, 0), name)
+		assert.NotNil(t, tm.getRaw(2, 0), name)
+		assert.Nil(t, tm.getRaw(3, 0), name)
+	}
+}
+
+func TestBackendFarFuture(t *testing.T) {
+	b := NewWheelBackend(time.Millisecond, 4)
+	tm := NewWithOptions(time.Millisecond, WithBackend(b))
+
+	tm.Set(1, 1, 30*time.Millisecond)
+
+	time.Sleep(15 * time.Millisecond)
+	assert.NotNil(t, tm.getRaw(1, 0))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, tm.getRaw(1, 0))
+}
+
+func TestHeapBackendCompact(t *testing.T) {
+	b := NewHeapBackend().(*heapBackend)
+	tm := NewWithOptions(0, WithBackend(b))
+
+	tm.Set(1, 1, time.Hour)
+	for i := 0; i < 200; i++ {
+		tm.Refresh(1, time.Second)
+	}
+
+	assert.Less(t, len(b.h), 64)
+}
+
+func TestShardedBackendIncremental(t *testing.T) {
+	b := NewShardedBackend(4).(*shardedBackend)
+	tm := NewWithOptions(0, WithBackend(b))
+
+	for i := 0; i < 100; i++ {
+		tm.Set(i, i, time.Millisecond)
+	}
+	assert.EqualValues(t, 100, tm.Size())
+	time.Sleep(5 * time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		size, due := tm.Size(), len(b.shards[i])
+		tm.cleanUp()
+		assert.Equal(t, size-due, tm.Size())
+		assert.Empty(t, b.shards[i])
+	}
+	assert.EqualValues(t, 0, tm.Size())
+}
+
+func TestBackendClear(t *testing.T) {
+	for name, nb := range testBackends {
+		tm := NewWithOptions(0, WithBackend(nb()))
+
+		for i := 0; i < 10; i++ {
+			tm.Set(i, i, time.Hour)
+		}
+		tm.Flush()
+		assert.EqualValues(t, 0, tm.Size(), name)
+
+		tm.cleanUp()
+		assert.EqualValues(t, 0, tm.Size(), name)
+	}
+}
+
+func BenchmarkCleanupNothingDue(b *testing.B) {
+	for name, nb := range testBackends {
+		b.Run(name, func(b *testing.B) {
+			tm := NewWithOptions(0, WithBackend(nb()))
+			for i := 0; i < 100000; i++ {
+				tm.Set(i, i, time.Hour)
+			}
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				tm.cleanUp()
+			}
+		})
+	}
+}
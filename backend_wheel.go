@@ -0,0 +1,146 @@
+package timedmap
+
+import "time"
+
+// wheelBackend stores the elements in a map and keeps
+// a hashed timing wheel of expiry times, so that a
+// cleanup cycle only touches the elements in the slots
+// passed since the previous cycle.
+type wheelBackend struct {
+	m          map[keyWrap]*element
+	slots      [][]indexEntry
+	resolution time.Duration
+	epoch      time.Time
+	last       int64
+}
+
+// NewWheelBackend returns a Backend which stores the
+// elements in a map and indexes them in a hashed timing
+// wheel with the given number of slots, each covering
+// the duration of resolution.
+//
+// Writes cost O(1) and a cleanup cycle only touches the
+// elements in the slots which passed since the previous
+// cycle. Elements expiring further in the future than
+// one rotation of the wheel are visited once per
+// rotation. The resolution should be in the range of
+// the cleanup interval.
+func NewWheelBackend(resolution time.Duration, slots int) Backend {
+	if resolution <= 0 {
+		resolution = time.Second
+	}
+	if slots <= 0 {
+		slots = 1
+	}
+	b := &wheelBackend{
+		m:          make(map[keyWrap]*element),
+		slots:      make([][]indexEntry, slots),
+		resolution: resolution,
+		epoch:      time.Now(),
+	}
+	b.last = b.tick(b.epoch)
+	return b
+}
+
+func (b *wheelBackend) get(k keyWrap) (*element, bool) {
+	v, ok := b.m[k]
+	return v, ok
+}
+
+func (b *wheelBackend) put(k keyWrap, v *element) {
+	b.m[k] = v
+	b.touch(k, v)
+}
+
+func (b *wheelBackend) del(k keyWrap) {
+	delete(b.m, k)
+}
+
+func (b *wheelBackend) touch(k keyWrap, v *element) {
+	if !v.expired {
+		return
+	}
+	t := b.tick(v.deadline())
+	if t <= b.last {
+		t = b.last + 1
+	}
+	i := b.slot(t)
+	b.slots[i] = append(b.slots[i], indexEntry{k: k, v: v, expires: v.deadline()})
+}
+
+func (b *wheelBackend) each(fn func(k keyWrap, v *element) bool) {
+	for k, v := range b.m {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+func (b *wheelBackend) due(now time.Time, fn func(k keyWrap, v *element)) {
+	cur := b.tick(now)
+	from := b.last
+	if cur-from > int64(len(b.slots)) {
+		from = cur - int64(len(b.slots))
+	}
+
+	// The slot of the current tick is visited as well
+	// because it may contain elements which are already
+	// expired, but it is not considered passed, so that
+	// it is visited again on the next cycle.
+	for t := from + 1; t <= cur; t++ {
+		i := b.slot(t)
+		kept := b.slots[i][:0]
+		for _, e := range b.slots[i] {
+			if !e.valid(b.m) {
+				continue
+			}
+			if e.v.isExpiredAt(now) {
+				fn(e.k, e.v)
+				continue
+			}
+			kept = append(kept, e)
+		}
+		for j := len(kept); j < len(b.slots[i]); j++ {
+			b.slots[i][j] = indexEntry{}
+		}
+		b.slots[i] = kept
+	}
+
+	if cur-1 > b.last {
+		b.last = cur - 1
+	}
+}
+
+func (b *wheelBackend) len() int {
+	return len(b.m)
+}
+
+func (b *wheelBackend) clear() {
+	for k := range b.m {
+		delete(b.m, k)
+	}
+	for i := range b.slots {
+		b.slots[i] = nil
+	}
+}
+
+// tick returns the absolute tick of t, which is
+// measured on the monotonic clock relative to the
+// creation of the backend when t has a monotonic
+// clock reading.
+func (b *wheelBackend) tick(t time.Time) int64 {
+	d := t.Sub(b.epoch)
+	if d < 0 {
+		return int64(d/b.resolution) - 1
+	}
+	return int64(d / b.resolution)
+}
+
+// slot returns the slot index of the tick t.
+func (b *wheelBackend) slot(t int64) int {
+	i := int(t % int64(len(b.slots)))
+	if i < 0 {
+		i += len(b.slots)
+	}
+	return i
+}
@@ -0,0 +1,134 @@
+package timedmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// barrier is a channel which is closed once all
+// key-value pairs expiring before a time have been
+// processed.
+type barrier struct {
+	before time.Time
+	ch     chan struct{}
+	once   sync.Once
+}
+
+// release closes the channel of the barrier.
+func (b *barrier) release() {
+	b.once.Do(func() {
+		close(b.ch)
+	})
+}
+
+// Barrier returns a channel which is closed once the
+// time before has passed and all key-value pairs whose
+// expire time is before it have been removed from the
+// map, including the execution of their expiration
+// callbacks, even if they run in the workers set using
+// WithAsyncCallbacks. This allows shutdown and test code
+// to wait for the side effects of expirations.
+//
+// Barriers are checked after each cleanup cycle, so the
+// channel is only closed while the cleanup loop runs or
+// Cleanup is called. Pairs which are retained or in their
+// grace period hold the barrier until they are removed.
+// All barriers are closed when the map is closed.
+func (tm *TimedMap) Barrier(before time.Time) <-chan struct{} {
+	b := &barrier{
+		before: before,
+		ch:     make(chan struct{}),
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		b.release()
+		return b.ch
+	}
+	tm.barriers = append(tm.barriers, b)
+	tm.checkBarriers(time.Now())
+	return b.ch
+}
+
+// checkBarriers releases all barriers whose time has
+// passed at now and which are not held by key-value
+// pairs expiring before it anymore. The write lock of
+// the map must be held.
+func (tm *TimedMap) checkBarriers(now time.Time) {
+	due := false
+	for _, b := range tm.barriers {
+		if !now.Before(b.before) {
+			due = true
+			break
+		}
+	}
+	if !due {
+		return
+	}
+
+	// earliest expire time of the pairs in the map
+	var earliest time.Time
+	tm.container.each(func(_ keyWrap, v *element) bool {
+		if v.expired && (earliest.IsZero() || v.expires.Before(earliest)) {
+			earliest = v.expires
+		}
+		return true
+	})
+
+	pending := tm.barriers[:0]
+	for _, b := range tm.barriers {
+		if now.Before(b.before) || (!earliest.IsZero() && earliest.Before(b.before)) {
+			pending = append(pending, b)
+			continue
+		}
+		if tm.dispatcher != nil {
+			tm.dispatcher.barrier(b.release)
+		} else {
+			b.release()
+		}
+	}
+	for i := len(pending); i < len(tm.barriers); i++ {
+		tm.barriers[i] = nil
+	}
+	tm.barriers = pending
+}
+
+// releaseBarriers releases all barriers
+// of the map, which has been closed.
+func (tm *TimedMap) releaseBarriers() {
+	tm.mtx.Lock()
+	barriers := tm.barriers
+	tm.barriers = nil
+	tm.mtx.Unlock()
+
+	for _, b := range barriers {
+		b.release()
+	}
+}
+
+// barrier calls fn once all callbacks which have
+// been queued before have been executed.
+func (d *callbackDispatcher) barrier(fn func()) {
+	remaining := int32(len(d.workers))
+	done := func() {
+		if atomic.AddInt32(&remaining, -1) == 0 {
+			fn()
+		}
+	}
+	for _, w := range d.workers {
+		w.mtx.Lock()
+		closed := w.closed
+		if !closed {
+			w.queue = append(w.queue, done)
+			w.cond.Signal()
+		}
+		w.mtx.Unlock()
+
+		if closed {
+			done()
+		}
+	}
+}
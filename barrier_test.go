@@ -0,0 +1,91 @@
+package timedmap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func released(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestBarrier(t *testing.T) {
+	tm := New(0)
+
+	var expired int
+	cb := func(interface{}) { expired++ }
+	tm.Set(1, 1, -time.Second, cb)
+	tm.Set(2, 2, 20*time.Millisecond, cb)
+	tm.Set(3, 3, time.Hour, cb)
+	tm.Set(4, 4, NoExpiration, cb)
+
+	past := tm.Barrier(time.Now())
+	future := tm.Barrier(time.Now().Add(10 * time.Millisecond))
+	assert.False(t, released(past))
+
+	tm.Cleanup()
+	assert.True(t, released(past))
+	assert.Equal(t, 1, expired)
+	assert.False(t, released(future))
+
+	time.Sleep(15 * time.Millisecond)
+	// pair 2 expires after the barrier
+	tm.Cleanup()
+	assert.True(t, released(future))
+
+	// a barrier without pending pairs
+	// is released immediately
+	assert.True(t, released(tm.Barrier(time.Now())))
+
+	end := tm.Barrier(time.Now().Add(time.Minute))
+	tm.Close()
+	assert.True(t, released(end))
+	assert.True(t, released(tm.Barrier(time.Now())))
+}
+
+func TestBarrierRetained(t *testing.T) {
+	tm := New(0)
+	defer tm.Close()
+
+	tm.Set(1, 1, time.Millisecond)
+	assert.NoError(t, tm.Retain(1))
+	time.Sleep(5 * time.Millisecond)
+
+	b := tm.Barrier(time.Now())
+	tm.Cleanup()
+	assert.False(t, released(b))
+
+	assert.NoError(t, tm.ReleaseRef(1))
+	tm.Cleanup()
+	assert.True(t, released(b))
+}
+
+func TestBarrierAsyncCallbacks(t *testing.T) {
+	tm := NewWithOptions(0, WithAsyncCallbacks(2))
+	defer tm.Close()
+
+	var expired int32
+	for i := 0; i < 10; i++ {
+		tm.Set(i, i, -time.Second, func(interface{}) {
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&expired, 1)
+		})
+	}
+
+	b := tm.Barrier(time.Now())
+	tm.Cleanup()
+	select {
+	case <-b:
+	case <-time.After(time.Second):
+		t.Fatal("barrier not released")
+	}
+	assert.Equal(t, int32(10), atomic.LoadInt32(&expired))
+}
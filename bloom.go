@@ -0,0 +1,201 @@
+package timedmap
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+)
+
+// defaultBloomFalsePositiveRate is used when an
+// invalid false positive rate is passed.
+const defaultBloomFalsePositiveRate = 0.01
+
+// bloomFilter is a counting bloom filter which
+// supports the removal of keys.
+//
+// add, remove and reset must be called while
+// holding the write lock of the map.
+// mayContain can be called without any lock.
+type bloomFilter struct {
+	counters []uint32
+	hashes   uint64
+}
+
+// newBloomFilter creates a new bloomFilter sized for
+// n keys with the false positive rate p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if p <= 0 || p >= 1 {
+		p = defaultBloomFalsePositiveRate
+	}
+
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{
+		counters: make([]uint32, uint64(m)),
+		hashes:   uint64(k),
+	}
+}
+
+// add adds k to the filter.
+func (b *bloomFilter) add(k keyWrap) {
+	h1, h2, ok := keyHash(k)
+	if !ok {
+		return
+	}
+	for i := uint64(0); i < b.hashes; i++ {
+		atomic.AddUint32(&b.counters[b.index(h1, h2, i)], 1)
+	}
+}
+
+// remove removes k, which must have been
+// added before, from the filter.
+func (b *bloomFilter) remove(k keyWrap) {
+	h1, h2, ok := keyHash(k)
+	if !ok {
+		return
+	}
+	for i := uint64(0); i < b.hashes; i++ {
+		atomic.AddUint32(&b.counters[b.index(h1, h2, i)], ^uint32(0))
+	}
+}
+
+// mayContain returns false if k is definitely
+// not contained in the filter. Keys which can not
+// be hashed are never excluded.
+func (b *bloomFilter) mayContain(k keyWrap) bool {
+	h1, h2, ok := keyHash(k)
+	if !ok {
+		return true
+	}
+	for i := uint64(0); i < b.hashes; i++ {
+		if atomic.LoadUint32(&b.counters[b.index(h1, h2, i)]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reset removes all keys from the filter.
+func (b *bloomFilter) reset() {
+	for i := range b.counters {
+		atomic.StoreUint32(&b.counters[i], 0)
+	}
+}
+
+// index returns the counter index for the i-th
+// hash function using double hashing.
+func (b *bloomFilter) index(h1, h2, i uint64) uint64 {
+	return (h1 + i*h2) % uint64(len(b.counters))
+}
+
+// hashKey returns two independent hashes of k, which
+// are equal for keys comparing equal. Keys which can not
+// be hashed all have the same hashes.
+func hashKey(k keyWrap) (h1, h2 uint64) {
+	h1, h2, _ = keyHash(k)
+	return
+}
+
+// keyHash returns two independent hashes of k like
+// hashKey. ok is false if k can not be hashed.
+func keyHash(k keyWrap) (h1, h2 uint64, ok bool) {
+	h := fnv.New64a()
+	h.Write(strconv.AppendInt(nil, int64(k.sec), 10))
+	h.Write([]byte{0})
+
+	ok = true
+	switch key := k.key.(type) {
+	case nil:
+	case string:
+		h.Write([]byte(key))
+	case int:
+		h.Write(strconv.AppendInt(nil, int64(key), 10))
+	case int64:
+		h.Write(strconv.AppendInt(nil, key, 10))
+	case uint64:
+		h.Write(strconv.AppendUint(nil, key, 10))
+	default:
+		ok = writeKey(h, reflect.ValueOf(key))
+	}
+	if !ok {
+		return 0, 1, false
+	}
+
+	sum := h.Sum64()
+	return sum, sum>>32 | 1, true
+}
+
+// writeKey writes a representation of v to h, which is
+// equal for values comparing equal using ==, unlike
+// formatting them, which distinguishes 0.0 from -0.0 and
+// follows pointers. It returns false if v has no such
+// representation.
+func writeKey(h hash.Hash64, v reflect.Value) bool {
+	var buf [8]byte
+	put := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf[:], x)
+		h.Write(buf[:])
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			put(1)
+		} else {
+			put(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		put(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		put(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		put(floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		put(floatBits(real(c)))
+		put(floatBits(imag(c)))
+	case reflect.String:
+		h.Write([]byte(v.String()))
+		h.Write([]byte{0})
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		put(uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			put(0)
+			return true
+		}
+		return writeKey(h, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !writeKey(h, v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !writeKey(h, v.Field(i)) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// floatBits returns the bits of f, with -0 replaced
+// by 0, as both compare equal.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		f = 0
+	}
+	return math.Float64bits(f)
+}
@@ -0,0 +1,100 @@
+package timedmap
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilter(t *testing.T) {
+	b := newBloomFilter(100, 0.01)
+
+	k1 := keyWrap{sec: 0, key: "a"}
+	k2 := keyWrap{sec: 1, key: "a"}
+
+	assert.False(t, b.mayContain(k1))
+
+	b.add(k1)
+	assert.True(t, b.mayContain(k1))
+	assert.False(t, b.mayContain(k2))
+
+	b.add(k1)
+	b.remove(k1)
+	assert.True(t, b.mayContain(k1))
+	b.remove(k1)
+	assert.False(t, b.mayContain(k1))
+
+	b.add(k2)
+	b.reset()
+	assert.False(t, b.mayContain(k2))
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	b := newBloomFilter(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		b.add(keyWrap{key: i})
+	}
+
+	var fp int
+	for i := 1000; i < 11000; i++ {
+		if b.mayContain(keyWrap{key: i}) {
+			fp++
+		}
+	}
+	assert.Less(t, fp, 300)
+}
+
+func TestWithBloomFilter(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithBloomFilter(100, 0.01))
+	assert.NotNil(t, tm.bloom)
+
+	tm.Set("a", 1, 20*time.Millisecond)
+	tm.Set("b", 2, time.Hour)
+	tm.Section(1).Set("c", 3, time.Hour)
+
+	assert.True(t, tm.Contains("a"))
+	assert.False(t, tm.Contains("c"))
+	assert.True(t, tm.Section(1).Contains("c"))
+
+	tm.Remove("b")
+	assert.False(t, tm.bloom.mayContain(keyWrap{key: "b"}))
+
+	time.Sleep(40 * time.Millisecond)
+	assert.False(t, tm.bloom.mayContain(keyWrap{key: "a"}))
+
+	tm.Flush()
+	assert.False(t, tm.bloom.mayContain(keyWrap{sec: 1, key: "c"}))
+}
+
+func TestBloomFilterEqualKeys(t *testing.T) {
+	type point struct {
+		X, Y float64
+	}
+	negZero := math.Copysign(0, -1)
+
+	tm := NewWithOptions(0, WithBloomFilter(100, 0.01))
+	tm.Set(0.0, 1, time.Hour)
+	tm.Set(point{X: 0, Y: 1}, 2, time.Hour)
+	p := &point{X: 1}
+	tm.Set(p, 3, time.Hour)
+	tm.Set(nil, 4, time.Hour)
+
+	// -0.0 == 0.0, so both are the same key
+	assert.Equal(t, 1, tm.GetValue(negZero))
+	assert.Equal(t, 2, tm.GetValue(point{X: negZero, Y: 1}))
+
+	// pointers are compared by address, not content
+	p.X = 2
+	assert.Equal(t, 3, tm.GetValue(p))
+	assert.False(t, tm.Contains(&point{X: 2}))
+
+	assert.Equal(t, 4, tm.GetValue(nil))
+
+	h1, h2 := hashKey(keyWrap{key: [2]float32{0, 1}})
+	g1, g2 := hashKey(keyWrap{key: [2]float32{float32(negZero), 1}})
+	assert.Equal(t, h1, g1)
+	assert.Equal(t, h2, g2)
+}
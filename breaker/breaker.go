@@ -0,0 +1,165 @@
+// Package breaker provides circuit breakers for a
+// dynamic set of endpoints, which keep their state
+// in a timedmap so that the state of endpoints which
+// are not used anymore is dropped automatically.
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// State is the state of the circuit
+// breaker of an endpoint.
+type State int
+
+const (
+	// Closed lets all requests pass.
+	Closed State = iota
+	// Open rejects all requests.
+	Open
+	// HalfOpen lets a single trial request pass
+	// which decides if the breaker is closed or
+	// opened again.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker manages circuit breakers keyed
+// by endpoint.
+//
+// An endpoint's breaker opens after threshold
+// failures, each within window of the previous
+// one. After openFor, the breaker becomes half-open
+// and lets a single trial request pass. A success
+// closes the breaker again, a failure re-opens it.
+// When a half-open breaker is not used for window,
+// its state is dropped and it is closed again.
+type Breaker struct {
+	mtx   sync.Mutex
+	cache timedmap.Section
+
+	threshold int
+	window    time.Duration
+	openFor   time.Duration
+}
+
+// entry is the breaker state of an endpoint.
+type entry struct {
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// New creates a new Breaker storing its state
+// in the given cache section.
+func New(cache timedmap.Section, threshold int, window, openFor time.Duration) *Breaker {
+	return &Breaker{
+		cache:     cache,
+		threshold: threshold,
+		window:    window,
+		openFor:   openFor,
+	}
+}
+
+// Allow returns true if a request to
+// endpoint may be performed.
+func (b *Breaker) Allow(endpoint interface{}) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	e := b.get(endpoint)
+	switch e.state(time.Now()) {
+	case Open:
+		return false
+	case HalfOpen:
+		if e.trial {
+			return false
+		}
+		e.trial = true
+	}
+	return true
+}
+
+// Success reports a successful request
+// to endpoint, which closes its breaker.
+func (b *Breaker) Success(endpoint interface{}) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.cache.Remove(endpoint)
+}
+
+// Failure reports a failed request
+// to endpoint.
+func (b *Breaker) Failure(endpoint interface{}) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := time.Now()
+	e := b.get(endpoint)
+
+	switch e.state(now) {
+	case Open:
+		return
+	case HalfOpen:
+		b.open(endpoint, e, now)
+		return
+	}
+
+	e.failures++
+	if e.failures >= b.threshold {
+		b.open(endpoint, e, now)
+		return
+	}
+	b.cache.Set(endpoint, e, b.window)
+}
+
+// State returns the current state of
+// the breaker of endpoint.
+func (b *Breaker) State(endpoint interface{}) State {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.get(endpoint).state(time.Now())
+}
+
+// get returns the state entry of endpoint or a
+// new, closed entry if there is none.
+func (b *Breaker) get(endpoint interface{}) *entry {
+	if e, ok := b.cache.GetValue(endpoint).(*entry); ok {
+		return e
+	}
+	return new(entry)
+}
+
+// open opens the breaker of endpoint.
+func (b *Breaker) open(endpoint interface{}, e *entry, now time.Time) {
+	e.openUntil = now.Add(b.openFor)
+	e.trial = false
+	b.cache.Set(endpoint, e, b.openFor+b.window)
+}
+
+// state returns the state of the entry at now.
+func (e *entry) state(now time.Time) State {
+	if e.openUntil.IsZero() {
+		return Closed
+	}
+	if now.Before(e.openUntil) {
+		return Open
+	}
+	return HalfOpen
+}
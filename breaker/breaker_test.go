@@ -0,0 +1,63 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerOpens(t *testing.T) {
+	b := New(timedmap.New(time.Minute), 3, time.Second, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		b.Failure("a")
+		assert.True(t, b.Allow("a"))
+	}
+	b.Failure("a")
+	assert.False(t, b.Allow("a"))
+	assert.Equal(t, Open, b.State("a"))
+	assert.Equal(t, Closed, b.State("b"))
+}
+
+func TestBreakerFailuresExpire(t *testing.T) {
+	b := New(timedmap.New(time.Minute), 2, 20*time.Millisecond, time.Hour)
+
+	b.Failure("a")
+	time.Sleep(30 * time.Millisecond)
+	b.Failure("a")
+	assert.Equal(t, Closed, b.State("a"))
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+	b := New(tm, 1, time.Second, 20*time.Millisecond)
+
+	b.Failure("a")
+	assert.Equal(t, Open, b.State("a"))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, HalfOpen, b.State("a"))
+	assert.True(t, b.Allow("a"))
+	assert.False(t, b.Allow("a"))
+
+	b.Failure("a")
+	assert.Equal(t, Open, b.State("a"))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, b.Allow("a"))
+	b.Success("a")
+	assert.Equal(t, Closed, b.State("a"))
+	assert.EqualValues(t, 0, tm.Size())
+}
+
+func TestBreakerStateExpires(t *testing.T) {
+	tm := timedmap.New(5 * time.Millisecond)
+	b := New(tm, 1, 10*time.Millisecond, 10*time.Millisecond)
+
+	b.Failure("a")
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, Closed, b.State("a"))
+	assert.EqualValues(t, 0, tm.Size())
+}
@@ -0,0 +1,129 @@
+package timedmap
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// SlowCallbackHandler is called with the key of an
+// expired key-value pair and the execution time d of
+// an expiration callback which exceeded the configured
+// threshold.
+//
+// The handler is executed while the map is locked
+// and must not access the map.
+type SlowCallbackHandler func(key interface{}, d time.Duration)
+
+// RearmFunc is an expiration callback registered
+// using WithRearm, which returns the duration after
+// which the expired key-value pair should expire again,
+// or 0 to let it be removed.
+//
+// Like all expiration callbacks, it is executed while
+// the map is locked and must not access the map.
+type RearmFunc func(value interface{}) time.Duration
+
+// rearmElement executes the rearm callback of the
+// expired element v of k and sets its new expiry if
+// the callback returned one. It returns true if the
+// element has been re-armed. The write lock of the map
+// must be held.
+func (tm *TimedMap) rearmElement(k keyWrap, v *element) bool {
+	var d time.Duration
+	tm.runCallback(k, func(value interface{}) {
+		d = v.rearm(value)
+	}, tm.transformRead(k, v.value))
+	if d <= 0 {
+		return false
+	}
+
+	v.setExpiry(time.Now(), d)
+	atomic.StoreUint32(&v.done, 0)
+	tm.container.touch(k, v)
+	tm.publish(ChangeExpiry, k, v)
+	return true
+}
+
+// runCallback executes the expiration callback cb of
+// k with value and measures its execution time if
+// a slow callback threshold is configured.
+// The write lock of the map must be held.
+func (tm *TimedMap) runCallback(k keyWrap, cb callback, value interface{}) {
+	tm.callbackRun(k).run(cb, value)
+}
+
+// runCallbacks executes the expiration callbacks cbs
+// of k with value, or dispatches them to the workers
+// set using WithAsyncCallbacks. The write lock of the
+// map must be held.
+func (tm *TimedMap) runCallbacks(k keyWrap, cbs []callback, value interface{}) {
+	if len(cbs) == 0 {
+		return
+	}
+	r := tm.callbackRun(k)
+	if tm.dispatcher == nil {
+		for _, cb := range cbs {
+			r.run(cb, value)
+		}
+		return
+	}
+	tm.dispatcher.dispatch(k, func() {
+		for _, cb := range cbs {
+			r.run(cb, value)
+		}
+	})
+}
+
+// callbackRun holds the state needed to execute
+// expiration callbacks of a key, so that they can be
+// executed without holding the lock of the map.
+type callbackRun struct {
+	tm        *TimedMap
+	k         keyWrap
+	sc        *statsCounters
+	threshold time.Duration
+	handler   SlowCallbackHandler
+}
+
+// callbackRun returns the state needed to execute
+// expiration callbacks of k. The write lock of the
+// map must be held.
+func (tm *TimedMap) callbackRun(k keyWrap) *callbackRun {
+	return &callbackRun{
+		tm:        tm,
+		k:         k,
+		sc:        tm.sectionCounters(k.sec),
+		threshold: tm.opts.slowCallbackThreshold,
+		handler:   tm.opts.slowCallbackHandler,
+	}
+}
+
+// run executes the expiration callback cb with value
+// and measures its execution time if a slow callback
+// threshold is configured.
+func (r *callbackRun) run(cb callback, value interface{}) {
+	atomic.AddUint64(&r.tm.stats.callbacks, 1)
+	atomic.AddUint64(&r.sc.callbacks, 1)
+
+	if r.threshold <= 0 {
+		cb(value)
+		return
+	}
+
+	start := time.Now()
+	cb(value)
+	d := time.Since(start)
+
+	if d <= r.threshold {
+		return
+	}
+
+	atomic.AddUint64(&r.tm.stats.slowCallbacks, 1)
+	atomic.AddUint64(&r.sc.slowCallbacks, 1)
+	if r.handler != nil {
+		r.handler(r.k.key, d)
+	} else {
+		log.Printf("timedmap: expiration callback of key %v took %s", r.k.key, d)
+	}
+}
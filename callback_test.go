@@ -0,0 +1,111 @@
+package timedmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowCallbackHandler(t *testing.T) {
+	var slowKeys []interface{}
+	tm := NewWithOptions(dCleanupTick, WithSlowCallbackHandler(5*time.Millisecond,
+		func(key interface{}, d time.Duration) {
+			assert.Greater(t, int64(d), int64(5*time.Millisecond))
+			slowKeys = append(slowKeys, key)
+		}))
+
+	tm.Set("fast", 1, 5*time.Millisecond, func(interface{}) {})
+	tm.Set("slow", 1, 5*time.Millisecond, func(interface{}) {
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	tm.mtx.RLock()
+	assert.Equal(t, []interface{}{"slow"}, slowKeys)
+	tm.mtx.RUnlock()
+
+	st := tm.Stats()
+	assert.EqualValues(t, 2, st.Callbacks)
+	assert.EqualValues(t, 1, st.SlowCallbacks)
+	assert.EqualValues(t, 0, st.Size)
+}
+
+func TestCallbackExactlyOnce(t *testing.T) {
+	for name, nb := range testBackends {
+		tm := NewWithOptions(time.Millisecond, WithBackend(nb()), WithPreciseExpiry())
+
+		const n = 200
+		var counts [n]int32
+		for i := 0; i < n; i++ {
+			i := i
+			tm.Set(i, i, time.Millisecond, func(interface{}) {
+				atomic.AddInt32(&counts[i], 1)
+			})
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				deadline := time.Now().Add(20 * time.Millisecond)
+				for time.Now().Before(deadline) {
+					for i := 0; i < n; i++ {
+						if g == 0 && i%10 == 0 {
+							tm.Remove(i)
+							continue
+						}
+						tm.GetValue(i)
+						tm.GetEntry(i)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		time.Sleep(10 * time.Millisecond)
+
+		for i := 0; i < n; i++ {
+			c := atomic.LoadInt32(&counts[i])
+			if i%10 == 0 {
+				assert.LessOrEqual(t, c, int32(1), name)
+			} else {
+				assert.EqualValues(t, 1, c, name)
+			}
+		}
+		tm.Close()
+	}
+}
+
+func TestCallbackRearm(t *testing.T) {
+	for name, b := range map[string]func() Backend{
+		"map":   NewMapBackend,
+		"heap":  NewHeapBackend,
+		"wheel": func() Backend { return NewWheelBackend(dCleanupTick, 16) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			tm := NewWithOptions(dCleanupTick, WithBackend(b()))
+
+			var tries, expired int32
+			assert.NoError(t, tm.SetWithOptions(1, 1, 5*time.Millisecond,
+				WithRearm(func(interface{}) time.Duration {
+					if atomic.AddInt32(&tries, 1) < 3 {
+						return time.Millisecond
+					}
+					return 0
+				}),
+				WithCallback(func(interface{}) {
+					atomic.AddInt32(&expired, 1)
+				})))
+
+			time.Sleep(100 * time.Millisecond)
+			assert.EqualValues(t, 3, atomic.LoadInt32(&tries))
+			assert.EqualValues(t, 1, atomic.LoadInt32(&expired))
+			assert.False(t, tm.Contains(1))
+			assert.Equal(t, 0, tm.Size())
+		})
+	}
+}
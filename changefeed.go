@@ -0,0 +1,227 @@
+package timedmap
+
+import (
+	"context"
+	"time"
+)
+
+// ChangeOp is the kind of change
+// recorded in the change feed.
+type ChangeOp string
+
+const (
+	// ChangeSet records that the value of a key has
+	// been set. Value and Expires hold the new state.
+	ChangeSet ChangeOp = "set"
+
+	// ChangeRemove records that a key-value
+	// pair has been removed.
+	ChangeRemove ChangeOp = "remove"
+
+	// ChangeExpire records that a key-value
+	// pair has expired.
+	ChangeExpire ChangeOp = "expire"
+
+	// ChangeExpiry records that the expire time of a
+	// key-value pair has been changed to Expires.
+	ChangeExpiry ChangeOp = "expiry"
+
+	// ChangeFlush records that all key-value pairs
+	// of Section have been removed, or of all sections
+	// if All is true.
+	ChangeFlush ChangeOp = "flush"
+)
+
+// Change is a single change recorded
+// in the change feed.
+type Change struct {
+	// Seq is the sequence number of the change, which
+	// increases by one with each change starting at 1.
+	Seq uint64
+
+	// Time is the time of the change.
+	Time time.Time
+
+	// Op is the kind of change.
+	Op ChangeOp
+
+	// Section and Key identify the changed
+	// key-value pair.
+	Section int
+	Key     interface{}
+
+	// Value is the new value for ChangeSet.
+	Value interface{}
+
+	// Expires is the new expire time for ChangeSet and
+	// ChangeExpiry. It is the zero time if the pair
+	// never expires.
+	Expires time.Time
+
+	// All is true if a ChangeFlush
+	// affected all sections.
+	All bool
+}
+
+// changeFeed is a ring buffer of changes.
+type changeFeed struct {
+	changes []Change
+	next    int
+	full    bool
+	seq     uint64
+	notify  chan struct{}
+}
+
+// newChangeFeed creates a new change feed
+// retaining the last size changes.
+func newChangeFeed(size int) *changeFeed {
+	return &changeFeed{
+		changes: make([]Change, size),
+		notify:  make(chan struct{}),
+	}
+}
+
+// add appends c to the feed with the next sequence
+// number and wakes up all waiting consumers.
+func (f *changeFeed) add(c Change) {
+	f.seq++
+	c.Seq = f.seq
+
+	f.changes[f.next] = c
+	f.next++
+	if f.next == len(f.changes) {
+		f.next = 0
+		f.full = true
+	}
+
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+// since returns the retained changes
+// with a sequence number after cursor.
+func (f *changeFeed) since(cursor uint64) ([]Change, error) {
+	retained := uint64(f.next)
+	if f.full {
+		retained = uint64(len(f.changes))
+	}
+	if cursor+retained < f.seq {
+		return nil, ErrCursorExpired
+	}
+	if cursor >= f.seq {
+		return nil, nil
+	}
+
+	n := int(f.seq - cursor)
+	res := make([]Change, 0, n)
+	for i := len(f.changes) + f.next - n; len(res) < n; i++ {
+		res = append(res, f.changes[i%len(f.changes)])
+	}
+	return res, nil
+}
+
+// ChangeCursor returns the sequence number of the
+// last change recorded in the change feed configured
+// using WithChangeFeed.
+//
+// To mirror the map, take the cursor, then a Snapshot,
+// and apply all changes after the cursor to the
+// snapshot. Changes which happened between taking the
+// cursor and the snapshot are applied twice, which
+// leads to the same state.
+func (tm *TimedMap) ChangeCursor() (uint64, error) {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.feed == nil {
+		return 0, ErrNoChangeFeed
+	}
+	return tm.feed.seq, nil
+}
+
+// Changes returns the changes recorded after the
+// sequence number since in the order they happened.
+//
+// If changes after since have already been dropped
+// from the feed, ErrCursorExpired is returned and the
+// consumer has to start over from a new cursor. If the
+// map has no change feed, ErrNoChangeFeed is returned.
+func (tm *TimedMap) Changes(since uint64) ([]Change, error) {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.feed == nil {
+		return nil, ErrNoChangeFeed
+	}
+	return tm.feed.since(since)
+}
+
+// WaitChanges returns the changes recorded after the
+// sequence number since like Changes. If there are
+// none, it blocks until the next change is recorded
+// or ctx is done, in which case the error of ctx
+// is returned.
+func (tm *TimedMap) WaitChanges(ctx context.Context, since uint64) ([]Change, error) {
+	for {
+		tm.mtx.RLock()
+		if tm.feed == nil {
+			tm.mtx.RUnlock()
+			return nil, ErrNoChangeFeed
+		}
+		changes, err := tm.feed.since(since)
+		notify := tm.feed.notify
+		tm.mtx.RUnlock()
+
+		if err != nil || len(changes) > 0 {
+			return changes, err
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// publish records a change of op for the element v
+// of k in the change feed and the write-ahead log, if
+// the map has them. The write lock of the map must be
+// held.
+func (tm *TimedMap) publish(op ChangeOp, k keyWrap, v *element) {
+	tm.logChange(op, k, v)
+	if tm.feed == nil {
+		return
+	}
+
+	c := Change{
+		Time:    time.Now(),
+		Op:      op,
+		Section: k.sec,
+		Key:     k.key,
+	}
+	if op == ChangeSet {
+		c.Value = v.value
+	}
+	if (op == ChangeSet || op == ChangeExpiry) && v.expired {
+		c.Expires = v.expires
+	}
+	tm.feed.add(c)
+}
+
+// publishFlush records a flush of the given section,
+// or of all sections if all is true, in the change
+// feed and the write-ahead log, if the map has them.
+// The write lock of the map must be held.
+func (tm *TimedMap) publishFlush(sec int, all bool) {
+	tm.logFlush(sec, all)
+	if tm.feed == nil {
+		return
+	}
+	tm.feed.add(Change{
+		Time:    time.Now(),
+		Op:      ChangeFlush,
+		Section: sec,
+		All:     all,
+	})
+}
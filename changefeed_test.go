@@ -0,0 +1,103 @@
+package timedmap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChanges(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithChangeFeed(100))
+
+	cursor, err := tm.ChangeCursor()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cursor)
+
+	tm.Set(1, "a", NoExpiration)
+	tm.Section(1).Set(2, "b", 5*time.Millisecond)
+	assert.NoError(t, tm.SetExpires(1, time.Hour))
+	tm.Remove(1)
+	time.Sleep(30 * time.Millisecond)
+	tm.Section(1).Flush()
+	tm.BumpGeneration()
+
+	changes, err := tm.Changes(0)
+	assert.NoError(t, err)
+
+	ops := make([]ChangeOp, len(changes))
+	for i, c := range changes {
+		ops[i] = c.Op
+		assert.EqualValues(t, i+1, c.Seq)
+	}
+	assert.Equal(t, []ChangeOp{ChangeSet, ChangeSet, ChangeExpiry, ChangeRemove,
+		ChangeExpire, ChangeFlush, ChangeFlush}, ops)
+
+	assert.Equal(t, "a", changes[0].Value)
+	assert.True(t, changes[0].Expires.IsZero())
+	assert.Equal(t, 1, changes[1].Section)
+	assert.Equal(t, 2, changes[1].Key)
+	assert.False(t, changes[1].Expires.IsZero())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), changes[2].Expires, time.Second)
+	assert.Equal(t, 2, changes[4].Key)
+	assert.Equal(t, 1, changes[5].Section)
+	assert.False(t, changes[5].All)
+	assert.True(t, changes[6].All)
+
+	changes, err = tm.Changes(5)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.EqualValues(t, 6, changes[0].Seq)
+
+	changes, err = tm.Changes(7)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestChangesCursorExpired(t *testing.T) {
+	tm := NewWithOptions(0, WithChangeFeed(3))
+
+	for i := 0; i < 5; i++ {
+		tm.Set(i, i, time.Hour)
+	}
+
+	_, err := tm.Changes(1)
+	assert.ErrorIs(t, err, ErrCursorExpired)
+
+	changes, err := tm.Changes(2)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	for i, c := range changes {
+		assert.Equal(t, i+2, c.Key)
+	}
+}
+
+func TestWaitChanges(t *testing.T) {
+	tm := NewWithOptions(0, WithChangeFeed(10))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tm.Set(1, 1, time.Hour)
+	}()
+
+	changes, err := tm.WaitChanges(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = tm.WaitChanges(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestChangesDisabled(t *testing.T) {
+	tm := New(0)
+
+	_, err := tm.Changes(0)
+	assert.ErrorIs(t, err, ErrNoChangeFeed)
+	_, err = tm.ChangeCursor()
+	assert.ErrorIs(t, err, ErrNoChangeFeed)
+	_, err = tm.WaitChanges(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrNoChangeFeed)
+}
@@ -0,0 +1,170 @@
+package timedmap
+
+import "time"
+
+// CleanupStrategy decides which key-value pairs are
+// examined and expired in a cleanup cycle.
+//
+// Sweep is called for each cycle while the map is
+// locked, so it must not access the map other than
+// through the passed Sweeper, which is only valid
+// during the call.
+type CleanupStrategy interface {
+	Sweep(now time.Time, s Sweeper)
+}
+
+// CleanupStrategyFunc is a function
+// implementing CleanupStrategy.
+type CleanupStrategyFunc func(now time.Time, s Sweeper)
+
+// Sweep calls f.
+func (f CleanupStrategyFunc) Sweep(now time.Time, s Sweeper) {
+	f(now, s)
+}
+
+// Sweeper gives a CleanupStrategy access to the
+// key-value pairs of the map during a cleanup cycle.
+type Sweeper interface {
+	// Len returns the number of key-value
+	// pairs stored in the map.
+	Len() int
+
+	// Range calls fn for the stored key-value pairs
+	// in no particular order until fn returns false.
+	// fn may call Expire with the passed entry.
+	Range(fn func(e SweepEntry) bool)
+
+	// Expire expires the key-value pair of e and executes
+	// its callbacks, if it has expired and is not retained,
+	// and returns true. Otherwise, it returns false.
+	Expire(e SweepEntry) bool
+
+	// ExpireDue expires all key-value pairs which the
+	// backend of the map reports as due and returns
+	// their number.
+	ExpireDue() int
+}
+
+// SweepEntry describes a key-value pair
+// passed to a CleanupStrategy.
+type SweepEntry struct {
+	Key     interface{}
+	Section int
+
+	// Expires is the time when the pair is removed,
+	// including its grace period. It is the zero time
+	// if the pair never expires.
+	Expires time.Time
+
+	k keyWrap
+	v *element
+}
+
+// sweeper implements Sweeper for
+// a single cleanup cycle.
+type sweeper struct {
+	tm  *TimedMap
+	now time.Time
+
+	// expired is the number of pairs
+	// expired during the cycle.
+	expired int
+}
+
+func (s *sweeper) Len() int {
+	return s.tm.container.len()
+}
+
+func (s *sweeper) Range(fn func(e SweepEntry) bool) {
+	// all backends iterate a map, from which elements
+	// can be deleted while iterating
+	s.tm.container.each(func(k keyWrap, v *element) bool {
+		e := SweepEntry{Key: k.key, Section: k.sec, k: k, v: v}
+		if v.expired {
+			e.Expires = v.deadline()
+		}
+		return fn(e)
+	})
+}
+
+func (s *sweeper) Expire(e SweepEntry) bool {
+	v, ok := s.tm.container.get(e.k)
+	if !ok || v != e.v || !s.tm.isExpired(v, s.now) {
+		return false
+	}
+	s.tm.expireElement(e.k, v)
+	s.expired++
+	return true
+}
+
+func (s *sweeper) ExpireDue() int {
+	// elements are expired after collecting them, because
+	// re-armed elements are indexed again by the backend
+	var due []indexEntry
+	s.tm.container.due(s.now, func(k keyWrap, v *element) {
+		if v.refs > 0 {
+			// expired by ReleaseRef
+			return
+		}
+		due = append(due, indexEntry{k: k, v: v})
+	})
+	for _, e := range due {
+		s.tm.expireElement(e.k, e.v)
+	}
+	s.expired += len(due)
+	return len(due)
+}
+
+// Cleanup runs a cleanup cycle immediately using the
+// cleanup strategy of the map, which allows to trigger
+// cycles externally without a cleanup loop.
+func (tm *TimedMap) Cleanup() {
+	tm.observedCleanUp()
+}
+
+// IndexedCleanup returns the default cleanup strategy,
+// which expires the pairs reported as due by the backend
+// of the map. With the heap and timing wheel backends,
+// a cycle only touches pairs which are due, while the
+// map backend examines all pairs.
+func IndexedCleanup() CleanupStrategy {
+	return CleanupStrategyFunc(func(_ time.Time, s Sweeper) {
+		s.ExpireDue()
+	})
+}
+
+// FullSweepCleanup returns a cleanup strategy which
+// examines all pairs in each cycle, independent of
+// the backend.
+func FullSweepCleanup() CleanupStrategy {
+	return CleanupStrategyFunc(func(_ time.Time, s Sweeper) {
+		s.Range(func(e SweepEntry) bool {
+			s.Expire(e)
+			return true
+		})
+	})
+}
+
+// SampledCleanup returns a cleanup strategy which
+// examines up to n random pairs per round, and starts
+// another round as long as more than a quarter of the
+// examined pairs have expired. This bounds the work per
+// cycle for very large maps, at the cost of expired
+// pairs being removed later.
+func SampledCleanup(n int) CleanupStrategy {
+	return CleanupStrategyFunc(func(_ time.Time, s Sweeper) {
+		for {
+			var examined, expired int
+			s.Range(func(e SweepEntry) bool {
+				examined++
+				if s.Expire(e) {
+					expired++
+				}
+				return examined < n
+			})
+			if examined == 0 || expired*4 <= examined {
+				return
+			}
+		}
+	})
+}
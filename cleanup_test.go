@@ -0,0 +1,76 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupStrategies(t *testing.T) {
+	for name, s := range map[string]CleanupStrategy{
+		"indexed": IndexedCleanup(),
+		"full":    FullSweepCleanup(),
+		"sampled": SampledCleanup(4),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tm := NewWithOptions(0, WithBackend(NewHeapBackend()), WithCleanupStrategy(s))
+
+			var expired int
+			for i := 0; i < 20; i++ {
+				tm.Set(i, i, -time.Second, func(interface{}) {
+					expired++
+				})
+			}
+			tm.Set(20, 20, time.Hour)
+			tm.Set(21, 21, NoExpiration)
+
+			tm.Cleanup()
+			assert.Equal(t, 20, expired)
+			assert.Equal(t, 2, tm.Size())
+		})
+	}
+}
+
+func TestFullSweepCleanupGeneration(t *testing.T) {
+	tm := NewWithOptions(0, WithCleanupStrategy(FullSweepCleanup()))
+
+	tm.Set(1, 1, time.Hour)
+	tm.BumpGeneration()
+	assert.Equal(t, 1, tm.Size())
+
+	tm.Cleanup()
+	assert.Equal(t, 0, tm.Size())
+}
+
+func TestCustomCleanupStrategy(t *testing.T) {
+	var entries []SweepEntry
+	strategy := CleanupStrategyFunc(func(now time.Time, s Sweeper) {
+		assert.Equal(t, 2, s.Len())
+		s.Range(func(e SweepEntry) bool {
+			entries = append(entries, e)
+			return true
+		})
+		assert.Equal(t, 1, s.ExpireDue())
+	})
+
+	tm := NewWithOptions(0)
+	assert.NoError(t, tm.Reconfigure(WithCleanupStrategy(strategy)))
+
+	tm.Set(1, 1, 5*time.Millisecond)
+	tm.Section(1).Set(2, 2, NoExpiration)
+	time.Sleep(8 * time.Millisecond)
+
+	tm.Cleanup()
+	assert.Len(t, entries, 2)
+	for _, e := range entries {
+		if e.Key == 1 {
+			assert.Equal(t, 0, e.Section)
+			assert.False(t, e.Expires.IsZero())
+		} else {
+			assert.Equal(t, 1, e.Section)
+			assert.True(t, e.Expires.IsZero())
+		}
+	}
+	assert.Equal(t, 1, tm.Size())
+}
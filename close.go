@@ -0,0 +1,100 @@
+package timedmap
+
+import "context"
+
+// CloseHook is a function which is executed when
+// the map is closed. The passed context is the one
+// passed to Shutdown and is done when the hook
+// should give up.
+type CloseHook func(ctx context.Context) error
+
+// OnClose registers hook to be executed when the map
+// is closed. Hooks are executed in registration order
+// before the key-value pairs are removed from the map,
+// so they can still read the map, for example to take
+// a final snapshot.
+//
+// Hooks must not call Close or Shutdown. Hooks which
+// are registered after the map has been closed are
+// never executed.
+func (tm *TimedMap) OnClose(hook CloseHook) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed || tm.closing {
+		return
+	}
+	tm.closeHooks = append(tm.closeHooks, hook)
+}
+
+// Close stops the cleanup loop and the stats
+// reporter and removes all key-value pairs from
+// the map without executing their callbacks. It is
+// equal to calling Shutdown with context.Background().
+//
+// After Close, operations which return an error
+// return ErrClosed, all other operations are no-ops
+// or behave like on an empty map. Calling Close on
+// an already closed map returns ErrClosed.
+func (tm *TimedMap) Close() error {
+	return tm.Shutdown(context.Background())
+}
+
+// Shutdown closes the map like Close after executing
+// the hooks registered with OnClose with ctx.
+//
+// When ctx is done, the remaining hooks are skipped.
+// The map is closed in any case and the first error
+// returned by a hook or the error of ctx is returned.
+func (tm *TimedMap) Shutdown(ctx context.Context) error {
+	tm.mtx.Lock()
+	if tm.closed || tm.closing {
+		tm.mtx.Unlock()
+		return ErrClosed
+	}
+	tm.closing = true
+	hooks := tm.closeHooks
+	tm.closeHooks = nil
+	tm.mtx.Unlock()
+
+	var err error
+	for _, hook := range hooks {
+		if cErr := ctx.Err(); cErr != nil {
+			if err == nil {
+				err = cErr
+			}
+			break
+		}
+		if hErr := hook(ctx); hErr != nil && err == nil {
+			err = hErr
+		}
+	}
+
+	tm.mtx.Lock()
+	tm.closed = true
+	if tm.wal != nil {
+		if wErr := tm.wal.close(); wErr != nil && err == nil {
+			err = wErr
+		}
+	}
+	tm.flush()
+	tm.stopStatsReporter()
+	tm.mtx.Unlock()
+
+	tm.StopCleaner()
+	unregister(tm)
+	if tm.dispatcher != nil {
+		tm.dispatcher.close()
+	}
+	tm.releaseBarriers()
+
+	return err
+}
+
+// IsClosed returns true if the map has been closed.
+func (tm *TimedMap) IsClosed() bool {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	return tm.closed
+}
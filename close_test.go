@@ -0,0 +1,113 @@
+package timedmap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClose(t *testing.T) {
+	tm := New(dCleanupTick)
+	tm.Set("a", 1, time.Hour)
+
+	assert.False(t, tm.IsClosed())
+	assert.Nil(t, tm.Close())
+	assert.True(t, tm.IsClosed())
+	assert.False(t, tm.cleanerRunning)
+	assert.ErrorIs(t, tm.Close(), ErrClosed)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.Set("b", 1, time.Hour)
+		assert.Nil(t, s.GetValue("a"))
+		assert.Nil(t, s.GetValue("b"))
+		assert.False(t, s.Contains("a"))
+		assert.EqualValues(t, 0, s.Size())
+		assert.Empty(t, s.Snapshot())
+
+		_, err := s.GetExpires("a")
+		assert.ErrorIs(t, err, ErrClosed)
+		assert.ErrorIs(t, s.SetExpires("a", time.Hour), ErrClosed)
+		assert.ErrorIs(t, s.Refresh("a", time.Hour), ErrClosed)
+
+		_, err = s.GetCtx(context.Background(), "a")
+		assert.ErrorIs(t, err, ErrClosed)
+		assert.ErrorIs(t, s.SetCtx(context.Background(), "a", 1, time.Hour), ErrClosed)
+		assert.ErrorIs(t, s.RemoveCtx(context.Background(), "a"), ErrClosed)
+
+		assert.NotPanics(t, func() {
+			s.Remove("a")
+			s.Flush()
+		})
+	}
+
+	tm.StartCleanerInternal(dCleanupTick)
+	assert.False(t, tm.cleanerRunning)
+}
+
+func TestCloseRightAfterStart(t *testing.T) {
+	tm := New(dCleanupTick)
+	done := make(chan struct{})
+	go func() {
+		tm.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked")
+	}
+	assert.False(t, tm.cleanerRunning)
+}
+
+func TestOnClose(t *testing.T) {
+	tm := New(dCleanupTick)
+	tm.Set("a", 1, time.Hour)
+
+	var order []int
+	tm.OnClose(func(ctx context.Context) error {
+		order = append(order, 1)
+		assert.EqualValues(t, 1, tm.GetValue("a"))
+		return nil
+	})
+	tm.OnClose(func(ctx context.Context) error {
+		order = append(order, 2)
+		return errors.New("flush failed")
+	})
+	tm.OnClose(func(ctx context.Context) error {
+		order = append(order, 3)
+		return nil
+	})
+
+	assert.EqualError(t, tm.Close(), "flush failed")
+	assert.Equal(t, []int{1, 2, 3}, order)
+	assert.True(t, tm.IsClosed())
+	assert.Nil(t, tm.GetValue("a"))
+
+	tm.OnClose(func(ctx context.Context) error {
+		t.Error("hook registered after close executed")
+		return nil
+	})
+	assert.ErrorIs(t, tm.Shutdown(context.Background()), ErrClosed)
+}
+
+func TestShutdownContext(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.OnClose(func(ctx context.Context) error {
+		cancel()
+		return nil
+	})
+	tm.OnClose(func(ctx context.Context) error {
+		t.Error("hook executed after context was cancelled")
+		return nil
+	})
+
+	assert.ErrorIs(t, tm.Shutdown(ctx), context.Canceled)
+	assert.True(t, tm.IsClosed())
+	assert.False(t, tm.cleanerRunning)
+}
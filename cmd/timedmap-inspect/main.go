@@ -0,0 +1,263 @@
+// Command timedmap-inspect inspects snapshots written by
+// TimedMap.SaveTo and write-ahead logs written using
+// timedmap.WithWAL.
+//
+// Usage:
+//
+//	timedmap-inspect list FILE
+//	timedmap-inspect stats FILE
+//	timedmap-inspect convert -to snapshot|wal FILE OUT
+//
+// list prints the live key-value pairs with their section,
+// expire time and size. stats prints the number and size
+// of the pairs per section and statistics of their
+// remaining TTLs. convert writes the live pairs to OUT,
+// which must not exist yet, as snapshot or as compacted
+// write-ahead log.
+//
+// The format of FILE is detected automatically. Keys and
+// values are decoded using encoding/gob, so only types
+// built into encoding/gob can be inspected. Their size is
+// the size of their gob encoding. Pairs which have
+// expired are not listed.
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// maxValueLen is the number of characters
+// after which listed values are truncated.
+const maxValueLen = 48
+
+var errUsage = errors.New("usage: timedmap-inspect list|stats FILE | convert -to snapshot|wal FILE OUT")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "timedmap-inspect:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command given by args
+// and writes its output to w.
+func run(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list", "stats":
+		if len(args) != 2 {
+			return errUsage
+		}
+		tm, err := load(args[1])
+		if err != nil {
+			return err
+		}
+		defer tm.Close()
+		if args[0] == "list" {
+			return list(w, tm)
+		}
+		return stats(w, tm, time.Now())
+
+	case "convert":
+		fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		to := fs.String("to", "snapshot", "")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 2 {
+			return errUsage
+		}
+		return convert(fs.Arg(0), fs.Arg(1), *to)
+	}
+	return errUsage
+}
+
+// load recovers the snapshot or write-ahead log
+// at path into a new map configured with opts.
+func load(path string, opts ...timedmap.Option) (*timedmap.TimedMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := timedmap.NewWithOptions(0, append(opts, timedmap.WithCostFunc(encodedSize))...)
+	err = tm.Recover(bytes.NewReader(data), nil)
+	if errors.Is(err, timedmap.ErrSnapshotVersion) {
+		// not a snapshot, so it may be a log
+		err = tm.Recover(nil, bytes.NewReader(data))
+	}
+	if err != nil {
+		tm.Close()
+		return nil, err
+	}
+	return tm, nil
+}
+
+// convert writes the pairs of the snapshot or write-ahead
+// log at in to out in the format to.
+func convert(in, out, to string) error {
+	if to != "snapshot" && to != "wal" {
+		return errUsage
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		return fmt.Errorf("%s already exists", out)
+	}
+
+	if to == "wal" {
+		// restored pairs are logged like set ones
+		tm, err := load(in, timedmap.WithWAL(out))
+		if err != nil {
+			return err
+		}
+		return tm.Close()
+	}
+
+	tm, err := load(in)
+	if err != nil {
+		return err
+	}
+	defer tm.Close()
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err = tm.SaveTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// pair is a live key-value pair of a map.
+type pair struct {
+	section int
+	key     interface{}
+	value   interface{}
+	expires time.Time
+	size    int64
+}
+
+// pairs returns all live pairs of tm,
+// ordered by section and key.
+func pairs(tm *timedmap.TimedMap) []pair {
+	entries := tm.LargestEntries(tm.Size())
+	res := make([]pair, 0, len(entries))
+	for _, e := range entries {
+		s := tm.Section(e.Section)
+		value, ok := s.Get(e.Key)
+		if !ok {
+			continue
+		}
+		expires, _ := s.GetExpires(e.Key)
+		res = append(res, pair{e.Section, e.Key, value, expires, e.Cost})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].section != res[j].section {
+			return res[i].section < res[j].section
+		}
+		return fmt.Sprint(res[i].key) < fmt.Sprint(res[j].key)
+	})
+	return res
+}
+
+// list writes all live pairs of tm to w.
+func list(w io.Writer, tm *timedmap.TimedMap) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECTION\tKEY\tVALUE\tEXPIRES\tSIZE")
+	for _, p := range pairs(tm) {
+		expires := "never"
+		if !p.expires.IsZero() {
+			expires = p.expires.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%v\t%s\t%s\t%d\n", p.section, p.key, truncate(fmt.Sprint(p.value)), expires, p.size)
+	}
+	return tw.Flush()
+}
+
+// stats writes the number and size of the pairs of tm
+// per section and the statistics of their TTLs at now
+// to w.
+func stats(w io.Writer, tm *timedmap.TimedMap, now time.Time) error {
+	type sectionStats struct {
+		pairs int
+		size  int64
+	}
+	sections := make(map[int]*sectionStats)
+	var ids []int
+	var ttls []time.Duration
+	var total int64
+	var never int
+
+	ps := pairs(tm)
+	for _, p := range ps {
+		st, ok := sections[p.section]
+		if !ok {
+			st = new(sectionStats)
+			sections[p.section] = st
+			ids = append(ids, p.section)
+		}
+		st.pairs++
+		st.size += p.size
+		total += p.size
+
+		if p.expires.IsZero() {
+			never++
+		} else {
+			ttls = append(ttls, p.expires.Sub(now))
+		}
+	}
+
+	fmt.Fprintf(w, "pairs: %d, size: %d bytes\n", len(ps), total)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECTION\tPAIRS\tSIZE")
+	for _, id := range ids {
+		fmt.Fprintf(tw, "%d\t%d\t%d\n", id, sections[id].pairs, sections[id].size)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "ttl: %d without expiry", never)
+	if len(ttls) > 0 {
+		sort.Slice(ttls, func(i, j int) bool { return ttls[i] < ttls[j] })
+		fmt.Fprintf(w, ", min %s, median %s, max %s",
+			ttls[0].Round(time.Second), ttls[len(ttls)/2].Round(time.Second), ttls[len(ttls)-1].Round(time.Second))
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// encodedSize is the CostFunc of the loaded maps, which
+// returns the size of the gob encoding of a pair.
+func encodedSize(key, value interface{}) int64 {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if enc.Encode(&key) != nil || enc.Encode(&value) != nil {
+		return 0
+	}
+	return int64(buf.Len())
+}
+
+// truncate shortens s to maxValueLen characters.
+func truncate(s string) string {
+	r := []rune(s)
+	if len(r) <= maxValueLen {
+		return s
+	}
+	return string(r[:maxValueLen-3]) + "..."
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSnapshot(t *testing.T, dir string) string {
+	tm := timedmap.New(0)
+	defer tm.Close()
+	tm.Set("a", "hello", time.Hour)
+	tm.Set("b", 42, timedmap.NoExpiration)
+	tm.Section(2).Set("c", strings.Repeat("x", 100), 2*time.Hour)
+
+	path := filepath.Join(dir, "snapshot")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, tm.SaveTo(f))
+	assert.NoError(t, f.Close())
+	return path
+}
+
+func TestInspect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timedmap-inspect")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	snapshot := testSnapshot(t, dir)
+
+	var out bytes.Buffer
+	assert.NoError(t, run([]string{"list", snapshot}, &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "hello")
+	assert.Contains(t, lines[2], "never")
+	assert.True(t, strings.HasPrefix(lines[3], "2 "))
+	assert.Contains(t, lines[3], "...")
+
+	out.Reset()
+	assert.NoError(t, run([]string{"stats", snapshot}, &out))
+	assert.Contains(t, out.String(), "pairs: 3")
+	assert.Contains(t, out.String(), "ttl: 1 without expiry, min 1h0m0s")
+	assert.Contains(t, out.String(), "max 2h0m0s")
+
+	// a converted log lists the same pairs
+	wal := filepath.Join(dir, "wal")
+	assert.NoError(t, run([]string{"convert", "-to", "wal", snapshot, wal}, &out))
+	var walOut bytes.Buffer
+	assert.NoError(t, run([]string{"list", wal}, &walOut))
+	out.Reset()
+	assert.NoError(t, run([]string{"list", snapshot}, &out))
+	assert.Equal(t, out.String(), walOut.String())
+
+	back := filepath.Join(dir, "back")
+	assert.NoError(t, run([]string{"convert", wal, back}, &out))
+	walOut.Reset()
+	assert.NoError(t, run([]string{"list", back}, &walOut))
+	assert.Equal(t, out.String(), walOut.String())
+
+	assert.Error(t, run([]string{"convert", wal, back}, &out))
+}
+
+func TestInspectInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timedmap-inspect")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "invalid")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("not a snapshot"), 0644))
+
+	var out bytes.Buffer
+	assert.ErrorIs(t, run([]string{"list", path}, &out), timedmap.ErrSnapshotVersion)
+	assert.Equal(t, errUsage, run(nil, &out))
+	assert.Equal(t, errUsage, run([]string{"stats"}, &out))
+	assert.Equal(t, errUsage, run([]string{"convert", "-to", "csv", path, path + ".csv"}, &out))
+}
@@ -0,0 +1,34 @@
+// Package compat exposes the API surface of version 1
+// of the upstream package github.com/zekroTJA/timedmap
+// backed by this implementation, so that downstream
+// code can switch its import path without changes.
+//
+// All types are aliases of the types of package
+// timedmap, so values can be passed freely between
+// code using either package.
+package compat
+
+import (
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// TimedMap contains a map with all key-value pairs,
+// and a timer, which cleans the map in the set
+// tick durations from expired keys.
+type TimedMap = timedmap.TimedMap
+
+// Section defines a sectioned access
+// wrapper of TimedMap.
+type Section = timedmap.Section
+
+// ErrKeyNotFound is returned when a key was
+// requested which is not present in the map.
+var ErrKeyNotFound = timedmap.ErrKeyNotFound
+
+// New creates and returns a new instance of TimedMap
+// like timedmap.New.
+func New(cleanupTickTime time.Duration, tickerChan ...<-chan time.Time) *TimedMap {
+	return timedmap.New(cleanupTickTime, tickerChan...)
+}
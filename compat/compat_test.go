@@ -0,0 +1,67 @@
+package compat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// v1Section is the method set of the Section
+// interface of the upstream package, which is
+// shared by TimedMap.
+type v1Section interface {
+	Ident() int
+	GetValue(key interface{}) interface{}
+	GetExpires(key interface{}) (time.Time, error)
+	SetExpires(key interface{}, d time.Duration) error
+	Contains(key interface{}) bool
+	Remove(key interface{})
+	Refresh(key interface{}, d time.Duration) error
+	Flush()
+	Size() int
+	Snapshot() map[interface{}]interface{}
+}
+
+// v1TimedMap is the method set of the upstream
+// TimedMap, except Set, whose callback type can
+// not be named outside the package.
+type v1TimedMap interface {
+	v1Section
+	Section(i int) Section
+	SetExpire(key interface{}, d time.Duration) error
+	StartCleanerInternal(interval time.Duration)
+	StartCleanerExternal(initiator <-chan time.Time)
+	StopCleaner()
+}
+
+var (
+	_ v1TimedMap = (*TimedMap)(nil)
+	_ v1Section  = Section(nil)
+)
+
+func TestUpstreamUsage(t *testing.T) {
+	tm := New(10 * time.Millisecond)
+	defer tm.StopCleaner()
+
+	var expired interface{}
+	tm.Set("hey", 213, 5*time.Millisecond, func(value interface{}) {
+		expired = value
+	})
+	d, ok := tm.GetValue("hey").(int)
+	assert.True(t, ok)
+	assert.Equal(t, 213, d)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, tm.GetValue("hey"))
+	assert.Equal(t, 213, expired)
+
+	_, err := tm.GetExpires("hey")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	s := tm.Section(1)
+	s.Set("a", 1, time.Hour)
+	assert.Equal(t, map[interface{}]interface{}{"a": 1}, s.Snapshot())
+	assert.Equal(t, 1, s.Size())
+	assert.Equal(t, 1, tm.Size())
+}
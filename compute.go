@@ -0,0 +1,164 @@
+package timedmap
+
+import (
+	"errors"
+	"time"
+)
+
+// errComputePanicked is the error passed to concurrent
+// callers of GetOrCompute when fn panicked.
+var errComputePanicked = errors.New("compute function panicked")
+
+// ComputeFunc computes the value of a key which was not
+// present in the map and returns the duration after
+// which the computed value expires.
+type ComputeFunc func() (value interface{}, ttl time.Duration, err error)
+
+// computeCall is a running call of a ComputeFunc, which
+// concurrent callers for the same key wait for.
+type computeCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// GetOrCompute returns the value of a key or, if there
+// is no value to the key, stores and returns the value
+// computed by fn, which expires after the duration
+// returned by fn along with it.
+//
+// Concurrent calls for the same key share a single call
+// of fn. If fn fails, nothing is stored and a *LoaderError
+// wrapping the error of fn is returned.
+func (tm *TimedMap) GetOrCompute(key interface{}, fn ComputeFunc) (interface{}, error) {
+	return tm.getOrCompute(key, 0, fn)
+}
+
+func (s *section) GetOrCompute(key interface{}, fn ComputeFunc) (interface{}, error) {
+	return s.tm.getOrCompute(key, s.sec, fn)
+}
+
+// GetElseSet returns the value of a key or, if there
+// is no value to the key, stores and returns the value
+// returned by fn, which expires after ttl.
+//
+// Like GetOrCompute, concurrent calls for the same key
+// share a single call of fn, so callers do not need to
+// guard fn with their own locks. If the value returned
+// by fn can not be stored, for example because the map
+// is closed, it is returned to the caller which called
+// fn and nil is returned to the other callers.
+func (tm *TimedMap) GetElseSet(key interface{}, ttl time.Duration, fn func() interface{}) interface{} {
+	return tm.getElseSet(key, 0, ttl, fn)
+}
+
+func (s *section) GetElseSet(key interface{}, ttl time.Duration, fn func() interface{}) interface{} {
+	return s.tm.getElseSet(key, s.sec, ttl, fn)
+}
+
+// getElseSet returns the value of the given key in the
+// given section, setting it to the result of fn if not
+// present.
+func (tm *TimedMap) getElseSet(key interface{}, sec int, ttl time.Duration, fn func() interface{}) interface{} {
+	var computed interface{}
+	v, err := tm.getOrCompute(key, sec, func() (interface{}, time.Duration, error) {
+		computed = fn()
+		return computed, ttl, nil
+	})
+	if err != nil {
+		return computed
+	}
+	return v
+}
+
+// getOrCompute returns the value of the given key in the
+// given section, computing it with fn if not present.
+func (tm *TimedMap) getOrCompute(key interface{}, sec int, fn ComputeFunc) (interface{}, error) {
+	if tm.IsClosed() {
+		return nil, ErrClosed
+	}
+	k := tm.wrapKey(key, sec)
+	if v := tm.lookup(k); v != nil {
+		return tm.readValue(k, v.value), nil
+	}
+
+	tm.computeMtx.Lock()
+	if c, ok := tm.computing[k]; ok {
+		tm.computeMtx.Unlock()
+		<-c.done
+		return tm.copyValue(c.value), c.err
+	}
+	c := &computeCall{
+		done: make(chan struct{}),
+		err:  &LoaderError{Key: k.key, Err: errComputePanicked},
+	}
+	if tm.computing == nil {
+		tm.computing = make(map[keyWrap]*computeCall)
+	}
+	tm.computing[k] = c
+	tm.computeMtx.Unlock()
+
+	defer func() {
+		tm.computeMtx.Lock()
+		delete(tm.computing, k)
+		tm.computeMtx.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = tm.compute(k, fn)
+	return tm.copyValue(c.value), c.err
+}
+
+// compute calls fn and stores the computed value for
+// k, unless it has been set since the last lookup.
+func (tm *TimedMap) compute(k keyWrap, fn ComputeFunc) (interface{}, error) {
+	if v := tm.lookup(k); v != nil {
+		return tm.transformRead(k, v.value), nil
+	}
+
+	value, ttl, err := fn()
+	if err != nil {
+		return nil, &LoaderError{Key: k.key, Err: err}
+	}
+	if _, _, err = tm.swap(k, value, ttl, setOptions{}); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// GetOrSet returns the value of a key and true or, if
+// there is no value to the key, stores value, which
+// expires after ttl, and returns it and false. The
+// lookup and the write happen atomically.
+//
+// If value can not be stored, for example because the
+// map is closed, it is returned along with false as
+// well.
+func (tm *TimedMap) GetOrSet(key, value interface{}, ttl time.Duration) (actual interface{}, loaded bool) {
+	return tm.getOrSet(key, 0, value, ttl)
+}
+
+func (s *section) GetOrSet(key, value interface{}, ttl time.Duration) (actual interface{}, loaded bool) {
+	return s.tm.getOrSet(key, s.sec, value, ttl)
+}
+
+// getOrSet returns the value of the given key in the
+// given section, setting it to value if not present.
+func (tm *TimedMap) getOrSet(key interface{}, sec int, value interface{}, ttl time.Duration) (interface{}, bool) {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	if v, ok := tm.container.get(k); ok && !tm.closed && !tm.isExpired(v, time.Now()) {
+		actual := tm.readValue(k, v.value)
+		tm.markAccess(v)
+		tm.mtx.Unlock()
+		if v.sliding > 0 {
+			tm.slide(k, v)
+		}
+		return actual, true
+	}
+	tm.swapLocked(k, value, ttl, setOptions{})
+	tm.mtx.Unlock()
+
+	return value, false
+}
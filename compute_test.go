@@ -0,0 +1,165 @@
+package timedmap
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrCompute(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		var calls int32
+		fn := func() (interface{}, time.Duration, error) {
+			atomic.AddInt32(&calls, 1)
+			return "v", 20 * time.Millisecond, nil
+		}
+
+		v, err := s.GetOrCompute("a", fn)
+		assert.Nil(t, err)
+		assert.Equal(t, "v", v)
+
+		v, err = s.GetOrCompute("a", fn)
+		assert.Nil(t, err)
+		assert.Equal(t, "v", v)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+		exp, err := s.GetExpires("a")
+		assert.Nil(t, err)
+		assert.InDelta(t, 20*time.Millisecond, time.Until(exp), float64(10*time.Millisecond))
+
+		time.Sleep(50 * time.Millisecond)
+		_, err = s.GetOrCompute("a", fn)
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	}
+}
+
+func TestGetOrComputeSingleflight(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func() (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 1, time.Hour, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := tm.GetOrCompute("a", fn)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, v)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestGetOrComputeError(t *testing.T) {
+	tm := New(dCleanupTick)
+	errCompute := errors.New("backend down")
+
+	_, err := tm.GetOrCompute("a", func() (interface{}, time.Duration, error) {
+		return nil, 0, errCompute
+	})
+	assert.ErrorIs(t, err, ErrLoaderFailed)
+	assert.ErrorIs(t, err, errCompute)
+	assert.False(t, tm.Contains("a"))
+
+	tm.Close()
+	_, err = tm.GetOrCompute("a", func() (interface{}, time.Duration, error) {
+		return 1, time.Hour, nil
+	})
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestGetElseSet(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	var calls int32
+	fn := func() interface{} {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, 1, tm.GetElseSet("a", time.Hour, fn))
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, calls)
+
+	exp, err := tm.GetExpires("a")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Second)
+
+	assert.Equal(t, 2, tm.Section(1).GetElseSet("a", time.Hour, func() interface{} {
+		return 2
+	}))
+	assert.Equal(t, 1, tm.GetValue("a"))
+}
+
+func TestGetElseSetNotStored(t *testing.T) {
+	tm := NewWithOptions(0, WithValidator(func(key, value interface{}) error {
+		return errors.New("rejected")
+	}))
+
+	assert.Equal(t, 1, tm.GetElseSet("a", time.Hour, func() interface{} {
+		return 1
+	}))
+	assert.False(t, tm.Contains("a"))
+}
+
+func TestGetOrSet(t *testing.T) {
+	tm := New(0)
+
+	var loaded int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			actual, ok := tm.GetOrSet("a", i, time.Hour)
+			if ok {
+				atomic.AddInt32(&loaded, 1)
+			}
+			assert.Equal(t, tm.GetValue("a"), actual)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 19, loaded)
+
+	tm.Set("b", 1, -time.Millisecond)
+	actual, ok := tm.GetOrSet("b", 2, time.Hour)
+	assert.False(t, ok)
+	assert.Equal(t, 2, actual)
+	assert.Equal(t, 2, tm.GetValue("b"))
+
+	actual, ok = tm.Section(1).GetOrSet("b", 3, time.Hour)
+	assert.False(t, ok)
+	assert.Equal(t, 3, actual)
+
+	tm.Close()
+	actual, ok = tm.GetOrSet("c", 4, time.Hour)
+	assert.False(t, ok)
+	assert.Equal(t, 4, actual)
+	assert.False(t, tm.Contains("c"))
+}
@@ -0,0 +1,44 @@
+package timedmap
+
+import "time"
+
+// RemoveAfterConfirm calls confirm with the value of a
+// key while holding the lock of the map and deletes the
+// key-value pair only if confirm returns true, for
+// example to remove a value only if it still has the
+// expected version. It returns true if the pair has
+// been removed.
+//
+// If there is no value to the passed key or if the
+// value was expired, confirm is not called. confirm
+// must not access the map.
+func (tm *TimedMap) RemoveAfterConfirm(key interface{}, confirm func(value interface{}) bool) bool {
+	return tm.removeAfterConfirm(key, 0, confirm)
+}
+
+func (s *section) RemoveAfterConfirm(key interface{}, confirm func(value interface{}) bool) bool {
+	return s.tm.removeAfterConfirm(key, s.sec, confirm)
+}
+
+// removeAfterConfirm removes the given key in the
+// given section if confirm returns true for its value.
+func (tm *TimedMap) removeAfterConfirm(key interface{}, sec int, confirm func(value interface{}) bool) bool {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return false
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, time.Now()) {
+		return false
+	}
+	if !confirm(tm.readValue(k, v.value)) {
+		return false
+	}
+	tm.removeLocked(k, "")
+	return true
+}
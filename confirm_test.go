@@ -0,0 +1,39 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type confirmTestValue struct {
+	version int
+}
+
+func TestRemoveAfterConfirm(t *testing.T) {
+	tm := New(0)
+
+	isVersion := func(n int) func(interface{}) bool {
+		return func(v interface{}) bool {
+			return v.(confirmTestValue).version == n
+		}
+	}
+
+	tm.Set(1, confirmTestValue{version: 2}, time.Hour)
+	assert.False(t, tm.RemoveAfterConfirm(1, isVersion(1)))
+	assert.True(t, tm.Contains(1))
+	assert.True(t, tm.RemoveAfterConfirm(1, isVersion(2)))
+	assert.False(t, tm.Contains(1))
+
+	var called bool
+	assert.False(t, tm.RemoveAfterConfirm(1, func(interface{}) bool {
+		called = true
+		return true
+	}))
+	assert.False(t, called)
+
+	tm.Section(1).Set(1, confirmTestValue{version: 1}, time.Hour)
+	assert.True(t, tm.Section(1).RemoveAfterConfirm(1, isVersion(1)))
+	assert.Equal(t, 0, tm.Size())
+}
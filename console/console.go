@@ -0,0 +1,177 @@
+// Package console provides a small line based debug
+// console, which can be served on a unix socket (or
+// any other net.Listener) to inspect and modify the
+// state of a live TimedMap.
+//
+// Supported commands:
+//
+//	get <key>               print the value of key
+//	set <key> <value> <ttl> set key to value, expiring after ttl (e.g. 5m or never)
+//	del <key>               remove key
+//	ttl <key>               print the remaining lifetime of key
+//	keys                    list all keys of the current section
+//	section <n>             switch to section n
+//	help                    print the list of commands
+//	quit                    close the connection
+//
+// Keys and values are handled as strings.
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+const prompt = "> "
+
+const help = `get <key>               print the value of key
+set <key> <value> <ttl> set key to value, expiring after ttl (e.g. 5m or never)
+del <key>               remove key
+ttl <key>               print the remaining lifetime of key
+keys                    list all keys of the current section
+section <n>             switch to section n
+help                    print this list of commands
+quit                    close the connection
+`
+
+// Serve accepts connections on l and serves the
+// console on each of them against tm until l is
+// closed.
+func Serve(l net.Listener, tm *timedmap.TimedMap) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			ServeConn(conn, tm)
+		}()
+	}
+}
+
+// ServeConn serves the console on rw against
+// tm until rw is closed or "quit" is entered.
+func ServeConn(rw io.ReadWriter, tm *timedmap.TimedMap) {
+	var s timedmap.Section = tm
+	sc := bufio.NewScanner(rw)
+
+	fmt.Fprint(rw, prompt)
+	for sc.Scan() {
+		args := strings.Fields(sc.Text())
+		if len(args) == 0 {
+			fmt.Fprint(rw, prompt)
+			continue
+		}
+
+		switch strings.ToLower(args[0]) {
+		case "quit", "exit":
+			return
+		case "section":
+			if len(args) != 2 {
+				fmt.Fprintln(rw, "ERR usage: section <n>")
+				break
+			}
+			i, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Fprintln(rw, "ERR invalid section:", args[1])
+				break
+			}
+			s = tm.Section(i)
+			fmt.Fprintln(rw, "OK")
+		default:
+			exec(rw, s, args)
+		}
+
+		fmt.Fprint(rw, prompt)
+	}
+}
+
+// exec executes the section command args
+// and writes the result to w.
+func exec(w io.Writer, s timedmap.Section, args []string) {
+	switch strings.ToLower(args[0]) {
+	case "get":
+		if len(args) != 2 {
+			fmt.Fprintln(w, "ERR usage: get <key>")
+			return
+		}
+		v := s.GetValue(args[1])
+		if v == nil {
+			fmt.Fprintln(w, "(nil)")
+			return
+		}
+		fmt.Fprintln(w, v)
+
+	case "set":
+		if len(args) != 4 {
+			fmt.Fprintln(w, "ERR usage: set <key> <value> <ttl>")
+			return
+		}
+		ttl, err := parseTTL(args[3])
+		if err != nil {
+			fmt.Fprintln(w, "ERR invalid ttl:", args[3])
+			return
+		}
+		s.Set(args[1], args[2], ttl)
+		fmt.Fprintln(w, "OK")
+
+	case "del":
+		if len(args) != 2 {
+			fmt.Fprintln(w, "ERR usage: del <key>")
+			return
+		}
+		s.Remove(args[1])
+		fmt.Fprintln(w, "OK")
+
+	case "ttl":
+		if len(args) != 2 {
+			fmt.Fprintln(w, "ERR usage: ttl <key>")
+			return
+		}
+		exp, err := s.GetExpires(args[1])
+		if err != nil {
+			fmt.Fprintln(w, "ERR", err)
+			return
+		}
+		if exp.IsZero() {
+			fmt.Fprintln(w, "(no expiry)")
+			return
+		}
+		fmt.Fprintln(w, time.Until(exp).Round(time.Millisecond))
+
+	case "keys":
+		keys := make([]string, 0, s.Size())
+		for k := range s.Snapshot() {
+			keys = append(keys, fmt.Sprint(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintln(w, k)
+		}
+		fmt.Fprintf(w, "(%d keys)\n", len(keys))
+
+	case "help":
+		fmt.Fprint(w, help)
+
+	default:
+		fmt.Fprintf(w, "ERR unknown command %q, enter \"help\" for a list of commands\n", args[0])
+	}
+}
+
+// parseTTL parses the ttl argument of the set command,
+// which is either a duration or "never".
+func parseTTL(s string) (time.Duration, error) {
+	if s == "never" {
+		return timedmap.NoExpiration, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,64 @@
+package console
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+type rw struct {
+	io.Reader
+	io.Writer
+}
+
+func run(tm *timedmap.TimedMap, in string) []string {
+	var out bytes.Buffer
+	ServeConn(rw{strings.NewReader(in), &out}, tm)
+	lines := strings.Split(strings.ReplaceAll(out.String(), prompt, ""), "\n")
+	return lines[:len(lines)-1]
+}
+
+func TestServeConn(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+
+	out := run(tm, "set a hello 1h\nget a\nget b\nttl a\nkeys\ndel a\nget a\nquit\nget a\n")
+	assert.Equal(t, "OK", out[0])
+	assert.Equal(t, "hello", out[1])
+	assert.Equal(t, "(nil)", out[2])
+	assert.Equal(t, "1h0m0s", out[3])
+	assert.Equal(t, "a", out[4])
+	assert.Equal(t, "(1 keys)", out[5])
+	assert.Equal(t, "OK", out[6])
+	assert.Equal(t, "(nil)", out[7])
+	assert.Len(t, out, 8)
+}
+
+func TestServeConnSection(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+
+	out := run(tm, "section 2\nset a 1 1h\nsection 0\nget a\n")
+	assert.Equal(t, []string{"OK", "OK", "OK", "(nil)"}, out)
+	assert.Equal(t, "1", tm.Section(2).GetValue("a"))
+}
+
+func TestServeConnErrors(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+
+	out := run(tm, "foo\nset a\nset a 1 x\nsection x\nttl a\n")
+	assert.Len(t, out, 5)
+	for _, l := range out {
+		assert.True(t, strings.HasPrefix(l, "ERR"), l)
+	}
+}
+
+func TestServeConnNoExpiry(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+
+	out := run(tm, "set a 1 never\nttl a\n")
+	assert.Equal(t, []string{"OK", "(no expiry)"}, out)
+}
@@ -0,0 +1,61 @@
+package timedmap
+
+import "time"
+
+// ContainsAll returns true, if all of the passed
+// keys exist in the map and have not expired.
+// All keys are checked while holding the lock of
+// the map once. If no keys are passed, true is
+// returned.
+func (tm *TimedMap) ContainsAll(keys ...interface{}) bool {
+	return tm.containsKeys(keys, 0, true)
+}
+
+// ContainsAny returns true, if at least one of the
+// passed keys exists in the map and has not expired.
+// All keys are checked while holding the lock of
+// the map once. If no keys are passed, false is
+// returned.
+func (tm *TimedMap) ContainsAny(keys ...interface{}) bool {
+	return tm.containsKeys(keys, 0, false)
+}
+
+func (s *section) ContainsAll(keys ...interface{}) bool {
+	return s.tm.containsKeys(keys, s.sec, true)
+}
+
+func (s *section) ContainsAny(keys ...interface{}) bool {
+	return s.tm.containsKeys(keys, s.sec, false)
+}
+
+// containsKeys checks the presence of keys in the
+// given section. If all is true, it returns whether
+// all keys are present, otherwise whether any key
+// is present.
+func (tm *TimedMap) containsKeys(keys []interface{}, sec int, all bool) bool {
+	now := time.Now()
+
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.closed {
+		return false
+	}
+
+	for _, key := range keys {
+		if tm.containsLocked(tm.wrapKey(key, sec), now) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+// containsLocked returns true if there is a live element
+// for k at now. The read lock of the map must be held.
+func (tm *TimedMap) containsLocked(k keyWrap, now time.Time) bool {
+	if tm.bloom != nil && !tm.bloom.mayContain(k) {
+		return false
+	}
+	v, ok := tm.container.get(k)
+	return ok && !tm.isExpired(v, now)
+}
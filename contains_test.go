@@ -0,0 +1,33 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsAllAny(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithBloomFilter(100, 0.01))
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.Set("a", 1, time.Hour)
+		s.Set("b", 2, time.Hour)
+		s.Set("c", 3, 10*time.Millisecond)
+
+		assert.True(t, s.ContainsAll("a", "b", "c"))
+		assert.True(t, s.ContainsAll())
+		assert.False(t, s.ContainsAll("a", "x"))
+
+		assert.True(t, s.ContainsAny("x", "b"))
+		assert.False(t, s.ContainsAny("x", "y"))
+		assert.False(t, s.ContainsAny())
+
+		time.Sleep(20 * time.Millisecond)
+		assert.False(t, s.ContainsAll("a", "c"))
+		assert.False(t, s.ContainsAny("c"))
+	}
+
+	tm.Close()
+	assert.False(t, tm.ContainsAll())
+}
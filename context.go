@@ -0,0 +1,76 @@
+package timedmap
+
+import (
+	"context"
+	"time"
+)
+
+// GetCtx returns the value of a key in the map like
+// GetValue. If there is no value to the passed key or
+// if the value was expired, ErrKeyNotFound is returned.
+// If ctx is done before the value could be obtained,
+// the error of ctx is returned.
+func (tm *TimedMap) GetCtx(ctx context.Context, key interface{}) (interface{}, error) {
+	return tm.getCtx(ctx, key, 0)
+}
+
+// SetCtx sets the value of a key like Set. If ctx is
+// done before the value could be set, the error of ctx
+// is returned and the map is not modified.
+func (tm *TimedMap) SetCtx(ctx context.Context, key, value interface{}, expiresAfter time.Duration, cb ...callback) error {
+	return tm.setCtx(ctx, key, 0, value, expiresAfter, cb...)
+}
+
+// RemoveCtx deletes a key-value pair like Remove. If
+// ctx is done before the pair could be removed, the
+// error of ctx is returned and the map is not modified.
+func (tm *TimedMap) RemoveCtx(ctx context.Context, key interface{}) error {
+	return tm.removeCtx(ctx, key, 0)
+}
+
+func (s *section) GetCtx(ctx context.Context, key interface{}) (interface{}, error) {
+	return s.tm.getCtx(ctx, key, s.sec)
+}
+
+func (s *section) SetCtx(ctx context.Context, key, value interface{}, expiresAfter time.Duration, cb ...callback) error {
+	return s.tm.setCtx(ctx, key, s.sec, value, expiresAfter, cb...)
+}
+
+func (s *section) RemoveCtx(ctx context.Context, key interface{}) error {
+	return s.tm.removeCtx(ctx, key, s.sec)
+}
+
+// getCtx returns the value of the given key in the
+// given section unless ctx is done.
+func (tm *TimedMap) getCtx(ctx context.Context, key interface{}, sec int) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if tm.IsClosed() {
+		return nil, ErrClosed
+	}
+	k := tm.wrapKey(key, sec)
+	v := tm.lookup(k)
+	if v == nil {
+		return nil, ErrKeyNotFound
+	}
+	return tm.readValue(k, v.value), nil
+}
+
+// setCtx sets the value of the given key in the
+// given section unless ctx is done.
+func (tm *TimedMap) setCtx(ctx context.Context, key interface{}, sec int, val interface{}, expiresAfter time.Duration, cb ...callback) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tm.setWith(key, sec, val, expiresAfter, setOptions{cbs: cb, actor: actorFrom(ctx)})
+}
+
+// removeCtx removes the given key in the given
+// section unless ctx is done.
+func (tm *TimedMap) removeCtx(ctx context.Context, key interface{}, sec int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tm.removeAs(key, sec, actorFrom(ctx))
+}
@@ -0,0 +1,44 @@
+package timedmap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextOperations(t *testing.T) {
+	tm := New(dCleanupTick)
+	ctx := context.Background()
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		_, err := s.GetCtx(ctx, "a")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		assert.Nil(t, s.SetCtx(ctx, "a", 1, time.Hour))
+		v, err := s.GetCtx(ctx, "a")
+		assert.Nil(t, err)
+		assert.EqualValues(t, 1, v)
+
+		assert.Nil(t, s.RemoveCtx(ctx, "a"))
+		assert.False(t, s.Contains("a"))
+	}
+}
+
+func TestContextOperationsCanceled(t *testing.T) {
+	tm := New(dCleanupTick)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tm.Set("a", 1, time.Hour)
+
+	_, err := tm.GetCtx(ctx, "a")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.ErrorIs(t, tm.SetCtx(ctx, "b", 1, time.Hour), context.Canceled)
+	assert.False(t, tm.Contains("b"))
+
+	assert.ErrorIs(t, tm.RemoveCtx(ctx, "a"), context.Canceled)
+	assert.True(t, tm.Contains("a"))
+}
@@ -0,0 +1,14 @@
+package timedmap
+
+// CopyFunc returns a copy of the stored value v,
+// which is never nil.
+type CopyFunc func(v interface{}) interface{}
+
+// copyValue returns v copied using the function passed
+// to WithCopyOnRead, or v itself if none was passed.
+func (tm *TimedMap) copyValue(v interface{}) interface{} {
+	if tm.opts.copyOnRead == nil || v == nil {
+		return v
+	}
+	return tm.opts.copyOnRead(v)
+}
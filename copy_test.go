@@ -0,0 +1,42 @@
+package timedmap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func copyInts(v interface{}) interface{} {
+	s, ok := v.([]int)
+	if !ok {
+		return v
+	}
+	return append([]int(nil), s...)
+}
+
+func TestWithCopyOnRead(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithCopyOnRead(copyInts))
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.Set("a", []int{1, 2}, time.Hour)
+		s.Set("nil", nil, time.Hour)
+
+		s.GetValue("a").([]int)[0] = 3
+		v, _ := s.Get("a")
+		v.([]int)[0] = 3
+		v, _ = s.GetCtx(context.Background(), "a")
+		v.([]int)[0] = 3
+		e, _ := s.GetEntry("a")
+		e.Value.([]int)[0] = 3
+		s.Snapshot()["a"].([]int)[0] = 3
+		v, _ = s.GetOrCompute("a", nil)
+		v.([]int)[0] = 3
+
+		assert.Equal(t, []int{1, 2}, s.GetValue("a"))
+		assert.Nil(t, s.GetValue("nil"))
+	}
+
+	assert.ErrorIs(t, tm.Reconfigure(WithCopyOnRead(copyInts)), ErrNotReconfigurable)
+}
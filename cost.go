@@ -0,0 +1,121 @@
+package timedmap
+
+import (
+	"container/heap"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// CostFunc returns the approximate cost of a key-value
+// pair, usually its memory footprint in bytes.
+type CostFunc func(key, value interface{}) int64
+
+// CostEntry is a key-value pair together with
+// its cost as returned by the CostFunc of the map.
+type CostEntry struct {
+	Key     interface{}
+	Section int
+	Cost    int64
+}
+
+// LargestEntries returns up to n live key-value pairs
+// of all sections with the highest cost, ordered by
+// descending cost. If no CostFunc is set using
+// WithCostFunc, nil is returned.
+func (tm *TimedMap) LargestEntries(n int) []CostEntry {
+	if tm.opts.costFunc == nil || n <= 0 {
+		return nil
+	}
+
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	now := time.Now()
+	var h costHeap
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if tm.isExpired(v, now) {
+			return true
+		}
+		if len(h) < n {
+			heap.Push(&h, CostEntry{Key: k.key, Section: k.sec, Cost: v.cost})
+		} else if v.cost > h[0].Cost {
+			h[0] = CostEntry{Key: k.key, Section: k.sec, Cost: v.cost}
+			heap.Fix(&h, 0)
+		}
+		return true
+	})
+
+	entries := []CostEntry(h)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Cost > entries[j].Cost
+	})
+	return entries
+}
+
+// unitCost is the CostFunc used by WithMaxCost if no
+// other CostFunc is set, which counts each pair as 1.
+func unitCost(_, _ interface{}) int64 {
+	return 1
+}
+
+// resolveCost sets the CostFunc of o which is used to
+// enforce the maximum cost.
+func (o *options) resolveCost() {
+	if o.maxCostFunc != nil {
+		o.costFunc = o.maxCostFunc
+	} else if o.maxCost > 0 && o.costFunc == nil {
+		o.costFunc = unitCost
+	}
+}
+
+// charge updates the cost of v stored for k after
+// its value has been set. The write lock of the map
+// must be held.
+func (tm *TimedMap) charge(k keyWrap, v *element) {
+	if tm.opts.costFunc == nil {
+		return
+	}
+	c := tm.opts.costFunc(k.key, v.value)
+	delta := c - v.cost
+	v.cost = c
+	atomic.AddInt64(&tm.stats.cost, delta)
+	atomic.AddInt64(&tm.sectionCounters(k.sec).cost, delta)
+}
+
+// discharge subtracts the cost of v stored for k
+// before it is removed from the map. If evicted is
+// true, the cost is added to the evicted cost. The
+// write lock of the map must be held.
+func (tm *TimedMap) discharge(k keyWrap, v *element, evicted bool) {
+	if v.cost == 0 {
+		return
+	}
+	sc := tm.sectionCounters(k.sec)
+	atomic.AddInt64(&tm.stats.cost, -v.cost)
+	atomic.AddInt64(&sc.cost, -v.cost)
+	if evicted {
+		atomic.AddInt64(&tm.stats.evictedCost, v.cost)
+		atomic.AddInt64(&sc.evictedCost, v.cost)
+	}
+	v.cost = 0
+}
+
+// costHeap is a min-heap of cost entries
+// ordered by their cost.
+type costHeap []CostEntry
+
+func (h costHeap) Len() int           { return len(h) }
+func (h costHeap) Less(i, j int) bool { return h[i].Cost < h[j].Cost }
+func (h costHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *costHeap) Push(x interface{}) {
+	*h = append(*h, x.(CostEntry))
+}
+
+func (h *costHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
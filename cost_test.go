@@ -0,0 +1,62 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func byteCost(_, value interface{}) int64 {
+	return int64(DefaultSize(value))
+}
+
+func TestCostStats(t *testing.T) {
+	tm := NewWithOptions(0, WithCostFunc(byteCost))
+
+	tm.Set(1, "aaaa", time.Hour)
+	tm.Set(2, "bb", time.Hour)
+	tm.Section(1).Set(1, "cccccc", time.Hour)
+
+	assert.EqualValues(t, 12, tm.Stats().Cost)
+	assert.EqualValues(t, 6, tm.StatsOf(0).Cost)
+	assert.EqualValues(t, 6, tm.StatsOf(1).Cost)
+
+	tm.Set(1, "a", time.Hour)
+	assert.EqualValues(t, 9, tm.Stats().Cost)
+
+	tm.Remove(2)
+	assert.EqualValues(t, 7, tm.Stats().Cost)
+	assert.EqualValues(t, 0, tm.Stats().EvictedCost)
+
+	tm.Section(1).SetExpires(1, -time.Millisecond)
+	tm.cleanUp()
+	st := tm.StatsOf(1)
+	assert.EqualValues(t, 0, st.Cost)
+	assert.EqualValues(t, 6, st.EvictedCost)
+	assert.EqualValues(t, 1, tm.Stats().Cost)
+	assert.EqualValues(t, 6, tm.Stats().EvictedCost)
+
+	tm.Flush()
+	assert.EqualValues(t, 0, tm.Stats().Cost)
+	assert.EqualValues(t, 0, tm.StatsOf(0).Cost)
+}
+
+func TestLargestEntries(t *testing.T) {
+	tm := NewWithOptions(0, WithCostFunc(byteCost))
+	assert.Nil(t, tm.LargestEntries(0))
+
+	tm.Set("a", "a", time.Hour)
+	tm.Set("b", "bbb", time.Hour)
+	tm.Set("c", "cc", time.Hour)
+	tm.Set("d", "dddd", -time.Millisecond)
+	tm.Section(1).Set("e", "eeeee", time.Hour)
+
+	assert.Equal(t, []CostEntry{
+		{Key: "e", Section: 1, Cost: 5},
+		{Key: "b", Section: 0, Cost: 3},
+	}, tm.LargestEntries(2))
+	assert.Len(t, tm.LargestEntries(10), 4)
+
+	assert.Nil(t, New(0).LargestEntries(10))
+}
@@ -0,0 +1,257 @@
+package timedmap
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader is the header row of the CSV
+// files written by ExportCSV.
+var csvHeader = []string{"section", "key", "value", "expires"}
+
+// errCSVColumns is returned for rows of a CSV
+// file which do not have four columns.
+var errCSVColumns = errors.New("expected columns section, key, value and expires")
+
+// Codec converts keys or values to and from the
+// strings stored in the CSV files of ExportCSV and
+// ImportCSV.
+type Codec struct {
+	// Encode returns the string representation of v.
+	Encode func(v interface{}) (string, error)
+
+	// Decode returns the key or value represented by s.
+	Decode func(s string) (interface{}, error)
+}
+
+var (
+	// StringCodec encodes keys or values using
+	// fmt.Sprint and decodes them as strings.
+	StringCodec = Codec{
+		Encode: func(v interface{}) (string, error) {
+			return fmt.Sprint(v), nil
+		},
+		Decode: func(s string) (interface{}, error) {
+			return s, nil
+		},
+	}
+
+	// IntCodec encodes and decodes keys or
+	// values of type int.
+	IntCodec = Codec{
+		Encode: func(v interface{}) (string, error) {
+			i, ok := v.(int)
+			if !ok {
+				return "", fmt.Errorf("%T is not an int", v)
+			}
+			return strconv.Itoa(i), nil
+		},
+		Decode: func(s string) (interface{}, error) {
+			return strconv.Atoi(s)
+		},
+	}
+)
+
+// CSVError is returned by ExportCSV and ImportCSV when
+// a key-value pair could not be converted or a row of
+// a CSV file could not be imported. Line is the line
+// of the row in the CSV file, starting at 1 for the
+// header row.
+type CSVError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("csv line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns the error of the row.
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// ExportCSV writes all key-value pairs of all sections
+// which have not expired to w as CSV with the columns
+// section, key, value and expires, starting with a
+// header row. Keys and values are converted to strings
+// using the passed codecs and expire times are written
+// in RFC 3339 format, or empty for pairs which never
+// expire.
+//
+// ExportCSV is meant for operators editing or seeding
+// maps with standard tooling. Use SaveTo to preserve
+// values of any type.
+func (tm *TimedMap) ExportCSV(w io.Writer, key, value Codec) error {
+	rows, err := tm.csvRows(key, value)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err = cw.Write(csvHeader); err != nil {
+		return err
+	}
+	if err = cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// ImportCSV reads a CSV file in the format written by
+// ExportCSV from r and sets all key-value pairs which
+// have not expired yet. Keys and values are converted
+// using the passed codecs. The header row is optional.
+// It returns the number of pairs which have been set.
+//
+// The whole file is read and converted before the map is
+// changed, so rows which can not be read or converted
+// leave the map unmodified. The pairs are set like using
+// SetWithOptions, so validators and limits apply. Rows
+// rejected by the map are skipped and the error of the
+// first rejected row is returned after the other rows
+// have been set. All errors of rows are returned as
+// *CSVError.
+func (tm *TimedMap) ImportCSV(r io.Reader, key, value Codec) (int, error) {
+	records, err := readCSV(r, key, value)
+	if err != nil {
+		return 0, err
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return 0, ErrClosed
+	}
+
+	now := time.Now()
+	var n int
+	var rejected error
+	for _, rec := range records {
+		if !rec.expires.IsZero() && !rec.expires.After(now) {
+			continue
+		}
+		k := tm.wrapKey(rec.key, rec.sec)
+		if err = tm.restoreLocked(k, now, rec.value, rec.expires, setOptions{}); err != nil {
+			if rejected == nil {
+				rejected = &CSVError{Line: rec.line, Err: err}
+			}
+			continue
+		}
+		n++
+	}
+	return n, rejected
+}
+
+// csvRecord is a converted row of a CSV file.
+type csvRecord struct {
+	line       int
+	sec        int
+	key, value interface{}
+	expires    time.Time
+}
+
+// csvRows returns the rows of all live
+// key-value pairs of the map.
+func (tm *TimedMap) csvRows(key, value Codec) ([][]string, error) {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.closed {
+		return nil, ErrClosed
+	}
+
+	now := time.Now()
+	var rows [][]string
+	var err error
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if tm.isExpired(v, now) {
+			return true
+		}
+
+		row := make([]string, 4)
+		row[0] = strconv.Itoa(k.sec)
+		if row[1], err = key.Encode(k.key); err == nil {
+			row[2], err = value.Encode(tm.readValue(k, v.value))
+		}
+		if err != nil {
+			err = &CSVError{Line: len(rows) + 2, Err: err}
+			return false
+		}
+		if v.expired {
+			row[3] = v.expires.Round(0).Format(time.RFC3339Nano)
+		}
+		rows = append(rows, row)
+		return true
+	})
+	return rows, err
+}
+
+// readCSV reads and converts all rows of the CSV file
+// in r using the given codecs.
+func readCSV(r io.Reader, key, value Codec) ([]csvRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	var records []csvRecord
+	for line := 1; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && isCSVHeader(row) {
+			continue
+		}
+
+		rec, err := parseCSVRow(row, key, value)
+		if err != nil {
+			return nil, &CSVError{Line: line, Err: err}
+		}
+		rec.line = line
+		records = append(records, rec)
+	}
+}
+
+// isCSVHeader returns true if row
+// is the header row of ExportCSV.
+func isCSVHeader(row []string) bool {
+	if len(row) != len(csvHeader) {
+		return false
+	}
+	for i := range row {
+		if row[i] != csvHeader[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSVRow converts a row of a CSV
+// file using the given codecs.
+func parseCSVRow(row []string, key, value Codec) (rec csvRecord, err error) {
+	if len(row) != len(csvHeader) {
+		return rec, errCSVColumns
+	}
+	if rec.sec, err = strconv.Atoi(row[0]); err != nil {
+		return
+	}
+	if rec.key, err = key.Decode(row[1]); err != nil {
+		return
+	}
+	if rec.value, err = value.Decode(row[2]); err != nil {
+		return
+	}
+	if row[3] != "" {
+		rec.expires, err = time.Parse(time.RFC3339Nano, row[3])
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package timedmap
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportCSV(t *testing.T) {
+	tm := New(0)
+	defer tm.Close()
+
+	tm.Set(1, "a", time.Hour)
+	tm.Set(2, "b,c", NoExpiration)
+	tm.Section(3).Set(1, "d", time.Hour)
+	tm.Set(4, "expired", -time.Second)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.ExportCSV(&buf, IntCodec, StringCodec))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "section,key,value,expires", lines[0])
+	assert.Contains(t, lines, `0,2,"b,c",`)
+
+	restored := New(0)
+	defer restored.Close()
+	n, err := restored.ImportCSV(&buf, IntCodec, StringCodec)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "a", restored.GetValue(1))
+	assert.Equal(t, "b,c", restored.GetValue(2))
+	assert.Equal(t, "d", restored.Section(3).GetValue(1))
+	assert.False(t, restored.Contains(4))
+
+	exp, _ := tm.GetExpires(1)
+	rexp, err := restored.GetExpires(1)
+	assert.NoError(t, err)
+	assert.Equal(t, exp.Round(0), rexp.Round(0))
+
+	// the header row is optional and
+	// expired rows are skipped
+	n, err = restored.ImportCSV(strings.NewReader(
+		"0,5,e,\n0,6,f,2000-01-01T00:00:00Z\n"), IntCodec, StringCodec)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "e", restored.GetValue(5))
+	assert.False(t, restored.Contains(6))
+
+	tm.Set("x", 1, time.Hour)
+	var cerr *CSVError
+	assert.ErrorAs(t, tm.ExportCSV(&buf, IntCodec, StringCodec), &cerr)
+}
+
+func TestImportCSVInvalid(t *testing.T) {
+	tm := NewWithOptions(0, WithValidator(func(key, value interface{}) error {
+		if value == "invalid" {
+			return errors.New("invalid")
+		}
+		return nil
+	}))
+	defer tm.Close()
+
+	for input, line := range map[string]int{
+		"section,key,value,expires\n0,1,a\n": 2,
+		"0,1,a,\nx,2,b,\n":                   2,
+		"0,a,a,\n":                           1,
+		"0,1,a,yesterday\n":                  1,
+	} {
+		_, err := tm.ImportCSV(strings.NewReader(input), IntCodec, StringCodec)
+		var cerr *CSVError
+		if assert.ErrorAs(t, err, &cerr, input) {
+			assert.Equal(t, line, cerr.Line, input)
+		}
+	}
+	assert.Equal(t, 0, tm.Size())
+
+	// rows rejected by the map are skipped
+	n, err := tm.ImportCSV(strings.NewReader(
+		"0,1,invalid,\n0,2,valid,\n"), IntCodec, StringCodec)
+	assert.Equal(t, 1, n)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	var cerr *CSVError
+	assert.ErrorAs(t, err, &cerr)
+	assert.Equal(t, 1, cerr.Line)
+	assert.True(t, tm.Contains(2))
+
+	tm.Close()
+	_, err = tm.ImportCSV(strings.NewReader(""), IntCodec, StringCodec)
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,118 @@
+package timedmap
+
+import "sync"
+
+// callbackDispatcher executes expiration callbacks in a
+// fixed number of workers. All callbacks of a key are
+// executed by the same worker, so they run serially in
+// the order the key expired, while callbacks of
+// different keys run in parallel.
+type callbackDispatcher struct {
+	workers []*callbackWorker
+	wg      sync.WaitGroup
+}
+
+// callbackWorker executes the queued callbacks of
+// the keys assigned to it in order.
+//
+// The queue is unbounded, because callbacks are
+// dispatched while the map is locked and a blocking
+// dispatch could deadlock with callbacks accessing
+// the map.
+type callbackWorker struct {
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	queue  []func()
+	closed bool
+}
+
+// newCallbackDispatcher creates a dispatcher and starts
+// n workers. If prev is not nil, the workers only start
+// executing callbacks after the workers of prev stopped,
+// so that callbacks queued at prev are executed first.
+func newCallbackDispatcher(n int, prev *callbackDispatcher) *callbackDispatcher {
+	d := &callbackDispatcher{
+		workers: make([]*callbackWorker, n),
+	}
+	d.wg.Add(n)
+	for i := range d.workers {
+		w := new(callbackWorker)
+		w.cond = sync.NewCond(&w.mtx)
+		d.workers[i] = w
+		go w.run(&d.wg, prev)
+	}
+	return d
+}
+
+// dispatch queues fn at the worker of k.
+func (d *callbackDispatcher) dispatch(k keyWrap, fn func()) {
+	h, _ := hashKey(k)
+	w := d.workers[h%uint64(len(d.workers))]
+
+	w.mtx.Lock()
+	if !w.closed {
+		w.queue = append(w.queue, fn)
+		w.cond.Signal()
+	}
+	w.mtx.Unlock()
+}
+
+// close stops all workers after they executed
+// their queued callbacks and waits for them.
+func (d *callbackDispatcher) close() {
+	d.stop()
+	d.wg.Wait()
+}
+
+// stop stops all workers after they executed their
+// queued callbacks without waiting for them.
+func (d *callbackDispatcher) stop() {
+	for _, w := range d.workers {
+		w.mtx.Lock()
+		w.closed = true
+		w.cond.Signal()
+		w.mtx.Unlock()
+	}
+}
+
+// run executes queued callbacks until the worker
+// is closed and its queue is empty, after the
+// workers of prev stopped.
+func (w *callbackWorker) run(wg *sync.WaitGroup, prev *callbackDispatcher) {
+	defer wg.Done()
+	if prev != nil {
+		prev.wg.Wait()
+	}
+	for {
+		w.mtx.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mtx.Unlock()
+			return
+		}
+		fn := w.queue[0]
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+		w.mtx.Unlock()
+
+		fn()
+	}
+}
+
+// resizeDispatcher replaces the dispatcher of the map
+// with one running n workers. Callbacks queued at the
+// previous dispatcher are executed before the ones
+// dispatched afterwards. The write lock of the map
+// must be held.
+func (tm *TimedMap) resizeDispatcher(n int) {
+	prev := tm.dispatcher
+	if prev != nil {
+		if len(prev.workers) == n {
+			return
+		}
+		prev.stop()
+	}
+	tm.dispatcher = newCallbackDispatcher(n, prev)
+}
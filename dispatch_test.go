@@ -0,0 +1,107 @@
+package timedmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncCallbacksOrdered(t *testing.T) {
+	tm := NewWithOptions(0, WithAsyncCallbacks(4))
+
+	var mtx sync.Mutex
+	var order []int
+	for i := 0; i < 100; i++ {
+		i := i
+		tm.Set("a", i, -time.Millisecond, func(v interface{}) {
+			// callbacks may access the map
+			tm.Contains("a")
+			mtx.Lock()
+			order = append(order, v.(int))
+			mtx.Unlock()
+		})
+		tm.cleanUp()
+	}
+	assert.NoError(t, tm.Close())
+
+	assert.Len(t, order, 100)
+	for i, v := range order {
+		assert.Equal(t, i, v)
+	}
+}
+
+func TestAsyncCallbacksParallel(t *testing.T) {
+	tm := NewWithOptions(0, WithAsyncCallbacks(2))
+
+	worker := func(key interface{}) uint64 {
+		h, _ := hashKey(keyWrap{key: key})
+		return h % 2
+	}
+	other := 0
+	for worker(other) == worker("a") {
+		other++
+	}
+
+	unblock := make(chan struct{})
+	done := make(chan struct{})
+	tm.Set("a", 1, -time.Millisecond, func(interface{}) {
+		<-unblock
+		close(done)
+	})
+	tm.Set(other, 2, -time.Millisecond, func(interface{}) {
+		close(unblock)
+	})
+	tm.cleanUp()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("callbacks of different keys did not run in parallel")
+	}
+	assert.NoError(t, tm.Close())
+}
+
+func TestReconfigureAsyncCallbacks(t *testing.T) {
+	tm := NewWithOptions(0, WithAsyncCallbacks(1))
+
+	var mtx sync.Mutex
+	var order []int
+	record := func(v interface{}) {
+		mtx.Lock()
+		order = append(order, v.(int))
+		mtx.Unlock()
+	}
+
+	// callbacks queued before the resize run first
+	unblock := make(chan struct{})
+	tm.Set("a", 1, -time.Millisecond, func(v interface{}) {
+		<-unblock
+		record(v)
+	})
+	tm.cleanUp()
+
+	assert.NoError(t, tm.Reconfigure(WithAsyncCallbacks(4)))
+	assert.Len(t, tm.dispatcher.workers, 4)
+	tm.Set("a", 2, -time.Millisecond, record)
+	tm.cleanUp()
+	close(unblock)
+
+	assert.ErrorIs(t, tm.Reconfigure(WithAsyncCallbacks(0)), ErrNotReconfigurable)
+	assert.NoError(t, tm.Close())
+	assert.Equal(t, []int{1, 2}, order)
+
+	// async callbacks can be enabled at runtime
+	tm = New(0)
+	assert.NoError(t, tm.Reconfigure(WithAsyncCallbacks(2)))
+	done := make(chan struct{})
+	tm.Set("a", 1, -time.Millisecond, func(interface{}) {
+		// callbacks may access the map
+		tm.Contains("a")
+		close(done)
+	})
+	tm.cleanUp()
+	<-done
+	assert.NoError(t, tm.Close())
+}
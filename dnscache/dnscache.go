@@ -0,0 +1,158 @@
+// Package dnscache provides a caching resolver which
+// stores host lookups in a timedmap, serves stale
+// results while a refresh is running in the background
+// and keeps serving them during resolver outages.
+package dnscache
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// DefaultRefreshTimeout is the time after which a
+// background refresh of a stale entry is cancelled.
+const DefaultRefreshTimeout = 10 * time.Second
+
+// LookupFunc resolves the given host to a list of
+// addresses and returns the duration the result may
+// be cached (usually the records TTL).
+type LookupFunc func(ctx context.Context, host string) (addrs []string, ttl time.Duration, err error)
+
+// Resolver caches the results of a LookupFunc.
+type Resolver struct {
+	// RefreshTimeout is the time after which a background
+	// refresh is cancelled. If 0, DefaultRefreshTimeout
+	// is used.
+	RefreshTimeout time.Duration
+
+	// LookupTimeout limits the duration of lookups which
+	// are performed because LookupHost missed the cache.
+	// The deadline of the context passed to LookupHost
+	// applies as well. If 0, only the context passed to
+	// LookupHost limits the lookup.
+	LookupTimeout time.Duration
+
+	cache    timedmap.Section
+	lookup   LookupFunc
+	staleTTL time.Duration
+
+	mtx        sync.Mutex
+	refreshing map[string]struct{}
+}
+
+// entry is a cached lookup result.
+type entry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// NewResolver creates a new Resolver wrapping the
+// LookupHost method of r. When r is nil,
+// net.DefaultResolver is used.
+//
+// Because net.Resolver does not expose the TTL of the
+// resolved records, all results are cached for ttl.
+// After that, results are served stale for up to
+// staleTTL while being refreshed in the background.
+func NewResolver(cache timedmap.Section, r *net.Resolver, ttl, staleTTL time.Duration) *Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	return NewResolverFunc(cache, func(ctx context.Context, host string) ([]string, time.Duration, error) {
+		addrs, err := r.LookupHost(ctx, host)
+		return addrs, ttl, err
+	}, staleTTL)
+}
+
+// NewResolverFunc creates a new Resolver caching the
+// results of lookup for the TTL returned by it. After
+// that, results are served stale for up to staleTTL
+// while being refreshed in the background.
+func NewResolverFunc(cache timedmap.Section, lookup LookupFunc, staleTTL time.Duration) *Resolver {
+	return &Resolver{
+		cache:      cache,
+		lookup:     lookup,
+		staleTTL:   staleTTL,
+		refreshing: make(map[string]struct{}),
+	}
+}
+
+// LookupHost returns the addresses of the given host.
+//
+// Fresh results are served from the cache. Stale results
+// are served from the cache as well, but trigger a
+// refresh in the background. When the background refresh
+// fails, the stale result keeps being served until it
+// reaches the end of its stale period.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if e, ok := r.cache.GetValue(host).(*entry); ok {
+		if time.Now().After(e.expires) {
+			r.refreshAsync(host)
+		}
+		return e.addrs, nil
+	}
+
+	if r.LookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.LookupTimeout)
+		defer cancel()
+	}
+
+	return r.refresh(ctx, host)
+}
+
+// Forget removes the cached result of host.
+func (r *Resolver) Forget(host string) {
+	r.cache.Remove(host)
+}
+
+// refresh performs the lookup of host and stores
+// the result in the cache.
+func (r *Resolver) refresh(ctx context.Context, host string) ([]string, error) {
+	addrs, ttl, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		r.cache.Set(host, &entry{
+			addrs:   addrs,
+			expires: time.Now().Add(ttl),
+		}, ttl+r.staleTTL)
+	}
+
+	return addrs, nil
+}
+
+// refreshAsync refreshes host in the background if
+// there is no refresh already running for it.
+func (r *Resolver) refreshAsync(host string) {
+	r.mtx.Lock()
+	if _, ok := r.refreshing[host]; ok {
+		r.mtx.Unlock()
+		return
+	}
+	r.refreshing[host] = struct{}{}
+	r.mtx.Unlock()
+
+	go func() {
+		defer func() {
+			r.mtx.Lock()
+			delete(r.refreshing, host)
+			r.mtx.Unlock()
+		}()
+
+		timeout := r.RefreshTimeout
+		if timeout == 0 {
+			timeout = DefaultRefreshTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		r.refresh(ctx, host)
+	}()
+}
@@ -0,0 +1,92 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLookup struct {
+	calls int32
+	fail  int32
+}
+
+func (l *testLookup) lookup(ctx context.Context, host string) ([]string, time.Duration, error) {
+	n := atomic.AddInt32(&l.calls, 1)
+	if atomic.LoadInt32(&l.fail) == 1 {
+		return nil, 0, errors.New("resolver down")
+	}
+	if n == 1 {
+		return []string{"10.0.0.1"}, 20 * time.Millisecond, nil
+	}
+	return []string{"10.0.0.2"}, 20 * time.Millisecond, nil
+}
+
+func TestLookupHostCached(t *testing.T) {
+	l := new(testLookup)
+	r := NewResolverFunc(timedmap.New(time.Minute), l.lookup, time.Second)
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupHost(context.Background(), "example.com")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&l.calls))
+}
+
+func TestLookupHostStaleRefresh(t *testing.T) {
+	l := new(testLookup)
+	r := NewResolverFunc(timedmap.New(time.Minute), l.lookup, time.Second)
+
+	r.LookupHost(context.Background(), "example.com")
+	time.Sleep(30 * time.Millisecond)
+
+	addrs, err := r.LookupHost(context.Background(), "example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	time.Sleep(10 * time.Millisecond)
+	addrs, err = r.LookupHost(context.Background(), "example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.2"}, addrs)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&l.calls))
+}
+
+func TestLookupHostOutage(t *testing.T) {
+	l := new(testLookup)
+	r := NewResolverFunc(timedmap.New(time.Minute), l.lookup, 50*time.Millisecond)
+
+	r.LookupHost(context.Background(), "example.com")
+	atomic.StoreInt32(&l.fail, 1)
+	time.Sleep(30 * time.Millisecond)
+
+	addrs, err := r.LookupHost(context.Background(), "example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = r.LookupHost(context.Background(), "example.com")
+	assert.NotNil(t, err)
+}
+
+func TestLookupHostTimeout(t *testing.T) {
+	r := NewResolverFunc(timedmap.New(time.Minute), func(ctx context.Context, host string) ([]string, time.Duration, error) {
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(time.Second):
+			return []string{"10.0.0.1"}, time.Minute, nil
+		}
+	}, 0)
+	r.LookupTimeout = 10 * time.Millisecond
+
+	start := time.Now()
+	_, err := r.LookupHost(context.Background(), "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
@@ -0,0 +1,78 @@
+package timedmap
+
+import "time"
+
+// Entry is a copy of a key-value pair of
+// the map together with its metadata.
+type Entry struct {
+	// Key is the key of the pair.
+	Key interface{}
+	// Value is the value of the pair.
+	Value interface{}
+	// Expires is the time when the pair expires.
+	// It is zero if the pair does not expire.
+	Expires time.Time
+	// Created is the time when the pair has been
+	// added to the map.
+	Created time.Time
+	// Updated is the time when the value of the
+	// pair has been set the last time.
+	Updated time.Time
+	// Meta is the metadata attached to the pair
+	// using WithMeta.
+	Meta interface{}
+	// Stale is true if the pair has expired, but
+	// is still served during its grace period, or
+	// has been flagged using MarkStale.
+	Stale bool
+}
+
+// GetEntry returns the key-value pair of key together
+// with its metadata. If there is no value to the passed
+// key or if the value was expired, ErrKeyNotFound is
+// returned.
+func (tm *TimedMap) GetEntry(key interface{}) (Entry, error) {
+	return tm.getEntry(key, 0)
+}
+
+func (s *section) GetEntry(key interface{}) (Entry, error) {
+	return s.tm.getEntry(key, s.sec)
+}
+
+// getEntry returns the entry of the given
+// key in the given section.
+func (tm *TimedMap) getEntry(key interface{}, sec int) (Entry, error) {
+	if tm.IsClosed() {
+		return Entry{}, ErrClosed
+	}
+
+	k := tm.wrapKey(key, sec)
+
+	var e Entry
+	if !tm.view(k, func(v *element) {
+		e = v.entry(k.key)
+	}) {
+		return Entry{}, ErrKeyNotFound
+	}
+
+	e.Value = tm.readValue(k, e.Value)
+	return e, nil
+}
+
+// entry returns the Entry of the element
+// stored for key.
+func (v *element) entry(key interface{}) Entry {
+	e := Entry{
+		Key:     key,
+		Value:   v.value,
+		Created: v.created,
+		Updated: v.updated,
+		Meta:    v.meta,
+		Stale:   v.marked,
+	}
+	if v.expired {
+		e.Expires = v.expires
+		e.Stale = e.Stale || v.isStaleAt(time.Now())
+	}
+	return e
+}
@@ -0,0 +1,52 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEntry(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		_, err := s.GetEntry("a")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		before := time.Now()
+		s.Set("a", 1, time.Hour)
+
+		e, err := s.GetEntry("a")
+		assert.Nil(t, err)
+		assert.Equal(t, "a", e.Key)
+		assert.EqualValues(t, 1, e.Value)
+		assert.False(t, e.Created.Before(before))
+		assert.Equal(t, e.Created, e.Updated)
+		assert.InDelta(t, time.Hour, time.Until(e.Expires), float64(time.Second))
+
+		time.Sleep(2 * time.Millisecond)
+		s.Set("a", 2, time.Hour)
+
+		e2, err := s.GetEntry("a")
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, e2.Value)
+		assert.Equal(t, e.Created, e2.Created)
+		assert.True(t, e2.Updated.After(e.Updated))
+	}
+
+	tm.Close()
+	_, err := tm.GetEntry("a")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestGetEntryNoExpiry(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	tm.Set("a", 1, time.Hour)
+	tm.SetExpires("a", NoExpiration)
+
+	e, err := tm.GetEntry("a")
+	assert.Nil(t, err)
+	assert.True(t, e.Expires.IsZero())
+}
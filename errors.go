@@ -1,9 +1,154 @@
 package timedmap
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrKeyNotFound is returned when a key was
 	// requested which is not present in the map.
 	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrKeyExists is returned when a key should be
+	// added or restored which is already present in
+	// the map.
+	ErrKeyExists = errors.New("key already exists")
+
+	// ErrFull is returned when a value can not be
+	// stored because the capacity of the map has
+	// been reached.
+	ErrFull = errors.New("map is full")
+
+	// ErrClosed is returned when an operation is
+	// performed on a map which has been closed.
+	ErrClosed = errors.New("map is closed")
+
+	// ErrLoaderFailed is returned when a loader function
+	// failed to provide the value of a key. The returned
+	// error is a *LoaderError containing the error of
+	// the loader.
+	ErrLoaderFailed = errors.New("loader failed")
+
+	// ErrKeyTooLarge is returned when a key exceeds
+	// the maximum key size of the map.
+	ErrKeyTooLarge = errors.New("key too large")
+
+	// ErrValueTooLarge is returned when a value exceeds
+	// the maximum value size of the map.
+	ErrValueTooLarge = errors.New("value too large")
+
+	// ErrInvalidValue is returned when a value has
+	// been rejected by the validator of the map.
+	ErrInvalidValue = errors.New("invalid value")
+
+	// ErrNotReconfigurable is returned by Reconfigure
+	// when an option was passed which can only be set
+	// on construction.
+	ErrNotReconfigurable = errors.New("option can not be changed at runtime")
+
+	// ErrNotRetained is returned by ReleaseRef when
+	// the key-value pair holds no references.
+	ErrNotRetained = errors.New("key not retained")
+
+	// ErrRecentlyDeleted is returned when a key should be
+	// set which has a tombstone left by Remove.
+	ErrRecentlyDeleted = errors.New("key recently deleted")
+
+	// ErrNoChangeFeed is returned when the change feed
+	// is read of a map which has none.
+	ErrNoChangeFeed = errors.New("change feed not enabled")
+
+	// ErrNoWAL is returned when the write-ahead log
+	// is compacted of a map which has none.
+	ErrNoWAL = errors.New("write-ahead log not enabled")
+
+	// ErrCursorExpired is returned when changes are read
+	// from the change feed which have already been dropped.
+	ErrCursorExpired = errors.New("change feed cursor expired")
+
+	// ErrRateLimited is returned when a key should be
+	// set more often than the write rate limit allows.
+	ErrRateLimited = errors.New("write rate limit exceeded")
+
+	// ErrInvalidTTL is returned when a key should be set
+	// with a duration of 0 or below while the TTL policy
+	// of the map is TTLReject.
+	ErrInvalidTTL = errors.New("invalid ttl")
+
+	// ErrConflict is returned when a working set is
+	// committed while one of its keys has been changed
+	// in the map since it has been checked out.
+	ErrConflict = errors.New("conflicting change")
+
+	// ErrNotCheckedOut is returned when a key of a
+	// working set should be changed which has not
+	// been checked out.
+	ErrNotCheckedOut = errors.New("key not checked out")
+
+	// ErrSnapshotTruncated is returned when a snapshot
+	// ends before all of its records have been read.
+	ErrSnapshotTruncated = errors.New("snapshot truncated")
+
+	// ErrSnapshotCorrupt is returned when a checksum or
+	// record of a snapshot does not match its content.
+	ErrSnapshotCorrupt = errors.New("snapshot corrupt")
+
+	// ErrSnapshotVersion is returned when data is read
+	// as snapshot or write-ahead log which has not been
+	// written by SaveTo or WithWAL or uses an unsupported
+	// version of the format.
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
 )
+
+// LoaderError is returned when a loader function
+// failed to provide the value of Key.
+//
+// errors.Is(err, ErrLoaderFailed) reports true for
+// LoaderErrors and the error returned by the loader
+// can be accessed using errors.Unwrap.
+type LoaderError struct {
+	Key interface{}
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LoaderError) Error() string {
+	return fmt.Sprintf("%s for key %v: %s", ErrLoaderFailed, e.Key, e.Err)
+}
+
+// Unwrap returns the error returned by the loader.
+func (e *LoaderError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrLoaderFailed.
+func (e *LoaderError) Is(target error) bool {
+	return target == ErrLoaderFailed
+}
+
+// ValidationError is returned when the validator of
+// the map rejected the value of Key.
+//
+// errors.Is(err, ErrInvalidValue) reports true for
+// ValidationErrors and the error returned by the
+// validator can be accessed using errors.Unwrap.
+type ValidationError struct {
+	Key interface{}
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s for key %v: %s", ErrInvalidValue, e.Key, e.Err)
+}
+
+// Unwrap returns the error returned by the validator.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidValue.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
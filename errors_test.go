@@ -0,0 +1,24 @@
+package timedmap
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderError(t *testing.T) {
+	errLoad := errors.New("connection refused")
+
+	var err error = &LoaderError{Key: "a", Err: errLoad}
+	assert.Equal(t, "loader failed for key a: connection refused", err.Error())
+	assert.ErrorIs(t, err, ErrLoaderFailed)
+	assert.ErrorIs(t, err, errLoad)
+
+	err = fmt.Errorf("get: %w", err)
+	var lErr *LoaderError
+	assert.True(t, errors.As(err, &lErr))
+	assert.Equal(t, "a", lErr.Key)
+	assert.ErrorIs(t, err, ErrLoaderFailed)
+}
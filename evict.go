@@ -0,0 +1,220 @@
+package timedmap
+
+import (
+	"container/list"
+	"sync/atomic"
+	"time"
+)
+
+// EvictionPolicy decides which key-value pairs are
+// evicted when the map exceeds its maximum size.
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used pairs.
+	// This is the default policy.
+	LRU EvictionPolicy = iota
+
+	// LFU evicts the least frequently used pairs,
+	// so frequently used pairs survive floods of
+	// new pairs which are not used again. The use
+	// counts decay over time, so pairs which are
+	// not used anymore are evicted eventually.
+	LFU
+)
+
+const (
+	// maxUses is the maximum number of
+	// uses counted for a pair by LFU.
+	maxUses = 255
+
+	// maxUsesPasses is the number of times maxUses
+	// can be halved before it drops to zero.
+	maxUsesPasses = 8
+)
+
+// EvictReason is the reason a key-value pair
+// has been evicted from the map.
+type EvictReason string
+
+const (
+	// EvictSize evicts pairs because the map exceeded
+	// the maximum size set using WithMaxSize.
+	EvictSize EvictReason = "size"
+
+	// EvictCost evicts pairs because the total cost of
+	// the map exceeded the budget set using WithMaxCost.
+	EvictCost EvictReason = "cost"
+)
+
+// EvictionHandler is called with the key and value of
+// each key-value pair evicted from the map before it
+// expired, and the reason of the eviction. Expiration
+// callbacks are not executed for evicted pairs.
+//
+// The handler is executed while the map is locked
+// and must not access the map.
+type EvictionHandler func(key, value interface{}, reason EvictReason)
+
+// clockBackend wraps a Backend and keeps the order in
+// which elements have been inserted or given a second
+// chance by the CLOCK algorithm, which approximates the
+// eviction policy of the map. Reads only count the uses
+// of elements, so they do not need to acquire the write
+// lock of the map.
+type clockBackend struct {
+	Backend
+
+	// order holds the keys of all elements, starting
+	// with the most recently inserted one.
+	order *list.List
+	items map[keyWrap]*list.Element
+}
+
+// newClockBackend wraps b with a clockBackend.
+func newClockBackend(b Backend) *clockBackend {
+	return &clockBackend{
+		Backend: b,
+		order:   list.New(),
+		items:   make(map[keyWrap]*list.Element),
+	}
+}
+
+func (b *clockBackend) put(k keyWrap, v *element) {
+	b.Backend.put(k, v)
+	if _, ok := b.items[k]; !ok {
+		b.items[k] = b.order.PushFront(k)
+	}
+}
+
+func (b *clockBackend) del(k keyWrap) {
+	b.Backend.del(k)
+	if e, ok := b.items[k]; ok {
+		b.order.Remove(e)
+		delete(b.items, k)
+	}
+}
+
+func (b *clockBackend) clear() {
+	b.Backend.clear()
+	b.order.Init()
+	b.items = make(map[keyWrap]*list.Element)
+}
+
+// markAccess counts a use of v for the eviction
+// policy, if the map has a maximum size.
+func (tm *TimedMap) markAccess(v *element) {
+	if tm.clock == nil {
+		return
+	}
+	n := atomic.LoadUint32(&v.uses)
+	if tm.opts.evictionPolicy == LFU {
+		// lost updates under contention are fine
+		// for an approximate frequency
+		if n < maxUses {
+			atomic.CompareAndSwapUint32(&v.uses, n, n+1)
+		}
+	} else if n == 0 {
+		atomic.StoreUint32(&v.uses, 1)
+	}
+}
+
+// secondChance returns true if v has been used since it
+// was passed by the CLOCK hand the last time, and ages
+// its uses. With LRU, all uses are reset, while with LFU,
+// the uses are halved, so frequently used pairs survive
+// several passes.
+func (tm *TimedMap) secondChance(v *element) bool {
+	if tm.opts.evictionPolicy == LFU {
+		n := atomic.LoadUint32(&v.uses)
+		atomic.StoreUint32(&v.uses, n/2)
+		return n > 0
+	}
+	return atomic.SwapUint32(&v.uses, 0) > 0
+}
+
+// enableEviction wraps the backend of the map with a
+// clockBackend holding all stored keys, if the map has
+// none yet, so that a maximum size or cost set using
+// Reconfigure can be enforced. The write lock of the map must be held.
+func (tm *TimedMap) enableEviction() {
+	if tm.clock != nil {
+		return
+	}
+	clock := newClockBackend(tm.container)
+	tm.container.each(func(k keyWrap, v *element) bool {
+		clock.items[k] = clock.order.PushFront(k)
+		return true
+	})
+	tm.clock = clock
+	tm.container = clock
+}
+
+// evictOverflow evicts pairs according to the eviction
+// policy until the map does not exceed its maximum size
+// and cost anymore. Pairs which have expired are expired instead
+// of evicted and pairs which are retained are skipped,
+// as is the pair of the key inserted, which caused the
+// overflow, unless inserted is nil.
+// The write lock of the map must be held.
+func (tm *TimedMap) evictOverflow(inserted *keyWrap) {
+	now := time.Now()
+
+	// the uses of each pair drop to zero after at
+	// most maxUsesPasses second chances
+	for budget := (maxUsesPasses + 1) * tm.clock.order.Len(); budget > 0; budget-- {
+		reason, over := tm.overflow()
+		if !over {
+			return
+		}
+
+		e := tm.clock.order.Back()
+		k := e.Value.(keyWrap)
+		v, _ := tm.container.get(k)
+
+		switch {
+		case inserted != nil && k == *inserted || v.refs > 0 || tm.secondChance(v):
+			tm.clock.order.MoveToFront(e)
+		case tm.isExpired(v, now):
+			tm.expireElement(k, v)
+		default:
+			tm.evict(k, v, reason)
+		}
+	}
+}
+
+// overflow returns true and the reason if the map
+// exceeds its maximum size or cost. The write lock
+// of the map must be held.
+func (tm *TimedMap) overflow() (EvictReason, bool) {
+	if tm.opts.maxSize > 0 && tm.container.len() > tm.opts.maxSize {
+		return EvictSize, true
+	}
+	if tm.opts.maxCost > 0 && atomic.LoadInt64(&tm.stats.cost) > tm.opts.maxCost {
+		return EvictCost, true
+	}
+	return "", false
+}
+
+// evict removes the element v of k from the map
+// and calls the EvictionHandler of the map with
+// reason. The write lock of the map must be held.
+func (tm *TimedMap) evict(k keyWrap, v *element, reason EvictReason) {
+	value := v.value
+
+	sc := tm.sectionCounters(k.sec)
+	atomic.AddUint64(&tm.stats.evictions, 1)
+	atomic.AddUint64(&sc.evictions, 1)
+
+	tm.publish(ChangeRemove, k, v)
+	tm.discharge(k, v, true)
+	tm.elementPool.Put(v)
+	tm.container.del(k)
+	if tm.bloom != nil {
+		tm.bloom.remove(k)
+	}
+
+	if h := tm.opts.evictionHandler; h != nil {
+		h(k.key, tm.transformRead(k, value), reason)
+	}
+}
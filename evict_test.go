@@ -0,0 +1,248 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type eviction struct {
+	key    interface{}
+	value  interface{}
+	reason EvictReason
+}
+
+func TestMaxSize(t *testing.T) {
+	var evicted []eviction
+	tm := NewWithOptions(0, WithMaxSize(3), WithEvictionHandler(func(key, value interface{}, reason EvictReason) {
+		evicted = append(evicted, eviction{key, value, reason})
+	}))
+	defer tm.Close()
+
+	var expired int
+	tm.Set(1, "a", time.Hour, func(interface{}) { expired++ })
+	tm.Set(2, "b", time.Hour)
+	tm.Set(3, "c", time.Hour)
+	assert.Equal(t, "a", tm.GetValue(1))
+
+	// 2 is the least recently used pair
+	tm.Set(4, "d", time.Hour)
+	assert.Equal(t, []eviction{{2, "b", EvictSize}}, evicted)
+	assert.Equal(t, 3, tm.Size())
+	assert.False(t, tm.Contains(2))
+	assert.Equal(t, 0, expired)
+
+	// updating a key does not evict
+	tm.Set(4, "e", time.Hour)
+	assert.Len(t, evicted, 1)
+
+	tm.Set(5, "f", time.Hour)
+	assert.Equal(t, eviction{3, "c", EvictSize}, evicted[1])
+
+	st := tm.Stats()
+	assert.Equal(t, uint64(2), st.Evictions)
+	assert.Equal(t, 3, st.Size)
+}
+
+func TestMaxSizeExpired(t *testing.T) {
+	var evicted int
+	tm := NewWithOptions(0, WithMaxSize(2), WithEvictionHandler(func(key, value interface{}, reason EvictReason) {
+		evicted++
+	}))
+	defer tm.Close()
+
+	// expired pairs are expired instead of evicted
+	var expired []interface{}
+	tm.Set(2, 2, -time.Second, func(v interface{}) { expired = append(expired, v) })
+	tm.Set(1, 1, time.Hour)
+	tm.Set(3, 3, time.Hour)
+
+	assert.Equal(t, 0, evicted)
+	assert.Equal(t, []interface{}{2}, expired)
+	assert.True(t, tm.Contains(1))
+	assert.True(t, tm.Contains(3))
+}
+
+func TestMaxSizeRetained(t *testing.T) {
+	tm := NewWithOptions(0, WithMaxSize(2))
+	defer tm.Close()
+
+	tm.Set(1, 1, time.Hour)
+	tm.Set(2, 2, time.Hour)
+	assert.NoError(t, tm.Retain(1))
+	assert.NoError(t, tm.Retain(2))
+
+	tm.Set(3, 3, time.Hour)
+	assert.Equal(t, 3, tm.Size())
+
+	assert.NoError(t, tm.ReleaseRef(1))
+	tm.Set(4, 4, time.Hour)
+	assert.Equal(t, 2, tm.Size())
+	assert.True(t, tm.Contains(2))
+	assert.True(t, tm.Contains(4))
+
+	tm.Flush()
+	tm.Section(1).Set(1, 1, time.Hour)
+	tm.Section(2).Set(1, 1, time.Hour)
+	tm.Section(3).Set(1, 1, time.Hour)
+	assert.Equal(t, 2, tm.Size())
+}
+
+func TestEvictionPolicyLFU(t *testing.T) {
+	flood := func(p EvictionPolicy) *TimedMap {
+		tm := NewWithOptions(0, WithMaxSize(10), WithEvictionPolicy(p))
+		tm.Set("hot", 1, time.Hour)
+		for i := 0; i < 20; i++ {
+			tm.GetValue("hot")
+		}
+		for i := 0; i < 30; i++ {
+			tm.Set(i, i, time.Hour)
+		}
+		assert.Equal(t, 10, tm.Size())
+		return tm
+	}
+
+	lru := flood(LRU)
+	defer lru.Close()
+	assert.False(t, lru.Contains("hot"))
+
+	lfu := flood(LFU)
+	defer lfu.Close()
+	assert.True(t, lfu.Contains("hot"))
+
+	// the uses decay, so the pair is
+	// evicted once it is not used anymore
+	for i := 30; i < 100; i++ {
+		lfu.Set(i, i, time.Hour)
+	}
+	assert.False(t, lfu.Contains("hot"))
+}
+
+func TestMaxCost(t *testing.T) {
+	var evicted []eviction
+	tm := NewWithOptions(0, WithMaxCost(10, byteCost), WithEvictionHandler(func(key, value interface{}, reason EvictReason) {
+		evicted = append(evicted, eviction{key, value, reason})
+	}))
+	defer tm.Close()
+
+	tm.Set(1, "aaaa", time.Hour)
+	tm.Set(2, "bbbb", time.Hour)
+	assert.Empty(t, evicted)
+	assert.Equal(t, "aaaa", tm.GetValue(1))
+
+	// 2 is the least recently used pair
+	tm.Set(3, "cccc", time.Hour)
+	assert.Equal(t, []eviction{{2, "bbbb", EvictCost}}, evicted)
+	st := tm.Stats()
+	assert.Equal(t, int64(8), st.Cost)
+	assert.Equal(t, int64(4), st.EvictedCost)
+	assert.Equal(t, uint64(1), st.Evictions)
+
+	// growing a pair evicts others, but
+	// never the pair which has been set
+	tm.Set(3, "cccccccccccc", time.Hour)
+	assert.Equal(t, eviction{1, "aaaa", EvictCost}, evicted[1])
+	assert.Equal(t, 1, tm.Size())
+	assert.Equal(t, int64(12), tm.Stats().Cost)
+}
+
+func TestMaxCostWithCostFunc(t *testing.T) {
+	tm := NewWithOptions(0, WithCostFunc(byteCost), WithMaxCost(4, nil), WithMaxSize(10))
+	defer tm.Close()
+
+	tm.Set(1, "aa", time.Hour)
+	tm.Set(2, "aa", time.Hour)
+	tm.Set(3, "aa", time.Hour)
+	assert.Equal(t, 2, tm.Size())
+	assert.False(t, tm.Contains(1))
+}
+
+func TestMaxCostPrecedence(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithCostFunc(unitCost), WithMaxCost(4, byteCost)},
+		{WithMaxCost(4, byteCost), WithCostFunc(unitCost)},
+	} {
+		tm := NewWithOptions(0, opts...)
+		tm.Set(1, "aa", time.Hour)
+		tm.Set(2, "aa", time.Hour)
+		tm.Set(3, "aa", time.Hour)
+		assert.Equal(t, 2, tm.Size())
+		assert.Equal(t, int64(4), tm.Stats().Cost)
+		tm.Close()
+	}
+}
+
+func TestMaxCostWithoutCostFunc(t *testing.T) {
+	tm := NewWithOptions(0, WithMaxCost(2, nil))
+	defer tm.Close()
+
+	// each pair costs 1
+	tm.Set(1, "aaaa", time.Hour)
+	tm.Set(2, "bbbb", time.Hour)
+	tm.Set(3, "cccc", time.Hour)
+	assert.Equal(t, 2, tm.Size())
+	assert.False(t, tm.Contains(1))
+	assert.Equal(t, int64(2), tm.Stats().Cost)
+}
+
+func TestReconfigureMaxSize(t *testing.T) {
+	var evicted []eviction
+	tm := NewWithOptions(0, WithEvictionHandler(func(key, value interface{}, reason EvictReason) {
+		evicted = append(evicted, eviction{key, value, reason})
+	}))
+	defer tm.Close()
+
+	for i := 0; i < 5; i++ {
+		tm.Set(i, i, time.Hour)
+	}
+
+	// shrinking evicts down to the new size
+	assert.NoError(t, tm.Reconfigure(WithMaxSize(3)))
+	assert.Equal(t, 3, tm.Size())
+	assert.Len(t, evicted, 2)
+	for _, e := range evicted {
+		assert.False(t, tm.Contains(e.key))
+		assert.Equal(t, EvictSize, e.reason)
+	}
+
+	tm.Set(5, 5, time.Hour)
+	assert.Equal(t, 3, tm.Size())
+	assert.True(t, tm.Contains(5))
+
+	// growing and removing the limit
+	assert.NoError(t, tm.Reconfigure(WithMaxSize(4)))
+	tm.Set(6, 6, time.Hour)
+	assert.Equal(t, 4, tm.Size())
+
+	assert.NoError(t, tm.Reconfigure(WithMaxSize(0)))
+	tm.Set(7, 7, time.Hour)
+	assert.Equal(t, 5, tm.Size())
+	assert.Equal(t, uint64(3), tm.Stats().Evictions)
+}
+
+func TestReconfigureMaxCost(t *testing.T) {
+	tm := NewWithOptions(0)
+	defer tm.Close()
+
+	for i := 0; i < 4; i++ {
+		tm.Set(i, "aa", time.Hour)
+	}
+
+	// without a CostFunc, each pair costs 1
+	assert.NoError(t, tm.Reconfigure(WithMaxCost(3, nil)))
+	assert.Equal(t, 3, tm.Size())
+	assert.Equal(t, int64(3), tm.Stats().Cost)
+
+	// a new CostFunc recomputes all costs
+	assert.NoError(t, tm.Reconfigure(WithMaxCost(4, byteCost)))
+	assert.Equal(t, 2, tm.Size())
+	assert.Equal(t, int64(4), tm.Stats().Cost)
+
+	assert.NoError(t, tm.Reconfigure(WithMaxCost(6, nil)))
+	tm.Set(10, "aa", time.Hour)
+	assert.Equal(t, 3, tm.Size())
+	tm.Set(11, "aa", time.Hour)
+	assert.Equal(t, 3, tm.Size())
+	assert.Equal(t, uint64(3), tm.Stats().Evictions)
+}
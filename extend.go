@@ -0,0 +1,73 @@
+package timedmap
+
+import "time"
+
+// expiryCond is a condition the new expiry of
+// a key-value pair must satisfy to be applied.
+type expiryCond int
+
+const (
+	expiryAlways expiryCond = iota
+	expiryIfLater
+	expiryIfEarlier
+)
+
+// holds returns true if an expiry of d after now
+// satisfies the condition for the element v. Pairs
+// which never expire are considered to expire after
+// all other pairs.
+func (c expiryCond) holds(v *element, now time.Time, d time.Duration) bool {
+	switch c {
+	case expiryIfLater:
+		if d == NoExpiration {
+			return v.expired
+		}
+		return v.expired && now.Add(d).After(v.expires)
+	case expiryIfEarlier:
+		if d == NoExpiration {
+			return false
+		}
+		return !v.expired || now.Add(d).Before(v.expires)
+	}
+	return true
+}
+
+// ExtendExpire sets the expire time for a key-value
+// pair to the passed duration from now like SetExpires.
+// If onlyIfLater is true, the expire time is only
+// changed if the new one is later than the current one,
+// so concurrent writers can not shorten the lifetime
+// set by each other.
+func (tm *TimedMap) ExtendExpire(key interface{}, d time.Duration, onlyIfLater bool) error {
+	return tm.setExpiresIf(key, 0, d, laterCond(onlyIfLater))
+}
+
+// ShortenExpire sets the expire time for a key-value
+// pair to the passed duration from now like SetExpires.
+// If onlyIfEarlier is true, the expire time is only
+// changed if the new one is earlier than the current one.
+func (tm *TimedMap) ShortenExpire(key interface{}, d time.Duration, onlyIfEarlier bool) error {
+	return tm.setExpiresIf(key, 0, d, earlierCond(onlyIfEarlier))
+}
+
+func (s *section) ExtendExpire(key interface{}, d time.Duration, onlyIfLater bool) error {
+	return s.tm.setExpiresIf(key, s.sec, d, laterCond(onlyIfLater))
+}
+
+func (s *section) ShortenExpire(key interface{}, d time.Duration, onlyIfEarlier bool) error {
+	return s.tm.setExpiresIf(key, s.sec, d, earlierCond(onlyIfEarlier))
+}
+
+func laterCond(onlyIfLater bool) expiryCond {
+	if onlyIfLater {
+		return expiryIfLater
+	}
+	return expiryAlways
+}
+
+func earlierCond(onlyIfEarlier bool) expiryCond {
+	if onlyIfEarlier {
+		return expiryIfEarlier
+	}
+	return expiryAlways
+}
@@ -0,0 +1,67 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtendExpire(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		assert.ErrorIs(t, s.ExtendExpire("a", time.Hour, true), ErrKeyNotFound)
+
+		s.Set("a", 1, time.Hour)
+		assert.Nil(t, s.ExtendExpire("a", time.Minute, true))
+		exp, _ := s.GetExpires("a")
+		assert.InDelta(t, time.Hour, time.Until(exp), float64(time.Second))
+
+		assert.Nil(t, s.ExtendExpire("a", 2*time.Hour, true))
+		exp, _ = s.GetExpires("a")
+		assert.InDelta(t, 2*time.Hour, time.Until(exp), float64(time.Second))
+
+		assert.Nil(t, s.ExtendExpire("a", time.Minute, false))
+		exp, _ = s.GetExpires("a")
+		assert.InDelta(t, time.Minute, time.Until(exp), float64(time.Second))
+
+		assert.Nil(t, s.ExtendExpire("a", NoExpiration, true))
+		exp, _ = s.GetExpires("a")
+		assert.True(t, exp.IsZero())
+
+		assert.Nil(t, s.ExtendExpire("a", time.Hour, true))
+		exp, _ = s.GetExpires("a")
+		assert.True(t, exp.IsZero())
+	}
+}
+
+func TestShortenExpire(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		assert.ErrorIs(t, s.ShortenExpire("a", time.Hour, true), ErrKeyNotFound)
+
+		s.Set("a", 1, time.Minute)
+		assert.Nil(t, s.ShortenExpire("a", time.Hour, true))
+		exp, _ := s.GetExpires("a")
+		assert.InDelta(t, time.Minute, time.Until(exp), float64(time.Second))
+
+		assert.Nil(t, s.ShortenExpire("a", time.Second, true))
+		exp, _ = s.GetExpires("a")
+		assert.InDelta(t, time.Second, time.Until(exp), float64(time.Second))
+
+		assert.Nil(t, s.ShortenExpire("a", NoExpiration, true))
+		exp, _ = s.GetExpires("a")
+		assert.False(t, exp.IsZero())
+
+		s.Set("b", 1, NoExpiration)
+		assert.Nil(t, s.ShortenExpire("b", time.Hour, true))
+		exp, _ = s.GetExpires("b")
+		assert.InDelta(t, time.Hour, time.Until(exp), float64(time.Second))
+
+		assert.Nil(t, s.ShortenExpire("b", time.Hour*2, false))
+		exp, _ = s.GetExpires("b")
+		assert.InDelta(t, 2*time.Hour, time.Until(exp), float64(time.Second))
+	}
+}
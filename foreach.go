@@ -0,0 +1,91 @@
+package timedmap
+
+import "time"
+
+// pair is a key-value pair collected for iteration.
+type pair struct {
+	key   interface{}
+	value interface{}
+}
+
+// ForEach calls fn for each key-value pair of the map
+// which has not expired until fn returns false. The
+// pairs are collected before fn is called, so fn may
+// access the map and does not see changes made while
+// iterating.
+func (tm *TimedMap) ForEach(fn func(key, value interface{}) bool) {
+	tm.forEach(0, fn)
+}
+
+func (s *section) ForEach(fn func(key, value interface{}) bool) {
+	s.tm.forEach(s.sec, fn)
+}
+
+// Keys returns the keys of all key-value pairs
+// of the map which have not expired.
+func (tm *TimedMap) Keys() []interface{} {
+	return tm.keys(0)
+}
+
+func (s *section) Keys() []interface{} {
+	return s.tm.keys(s.sec)
+}
+
+// Values returns the values of all key-value
+// pairs of the map which have not expired.
+func (tm *TimedMap) Values() []interface{} {
+	return tm.values(0)
+}
+
+func (s *section) Values() []interface{} {
+	return s.tm.values(s.sec)
+}
+
+// keys returns the keys of all live
+// key-value pairs of the given section.
+func (tm *TimedMap) keys(sec int) []interface{} {
+	pairs := tm.pairs(sec)
+	keys := make([]interface{}, len(pairs))
+	for i, p := range pairs {
+		keys[i] = p.key
+	}
+	return keys
+}
+
+// values returns the values of all live
+// key-value pairs of the given section.
+func (tm *TimedMap) values(sec int) []interface{} {
+	pairs := tm.pairs(sec)
+	values := make([]interface{}, len(pairs))
+	for i, p := range pairs {
+		values[i] = p.value
+	}
+	return values
+}
+
+// forEach calls fn for each live key-value
+// pair of the given section.
+func (tm *TimedMap) forEach(sec int, fn func(key, value interface{}) bool) {
+	for _, p := range tm.pairs(sec) {
+		if !fn(p.key, p.value) {
+			return
+		}
+	}
+}
+
+// pairs returns all live key-value
+// pairs of the given section.
+func (tm *TimedMap) pairs(sec int) []pair {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	now := time.Now()
+	var pairs []pair
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if k.sec == sec && !tm.isExpired(v, now) {
+			pairs = append(pairs, pair{key: k.key, value: tm.readValue(k, v.value)})
+		}
+		return true
+	})
+	return pairs
+}
@@ -0,0 +1,51 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEach(t *testing.T) {
+	tm := New(0)
+	for i := 0; i < 5; i++ {
+		tm.Set(i, i*10, time.Hour)
+	}
+	tm.Set(5, 50, -time.Millisecond)
+	tm.Section(1).Set(6, 60, time.Hour)
+
+	seen := make(map[interface{}]interface{})
+	tm.ForEach(func(key, value interface{}) bool {
+		seen[key] = value
+		// the map may be accessed while iterating
+		tm.Remove(key)
+		return true
+	})
+	assert.Equal(t, map[interface{}]interface{}{0: 0, 1: 10, 2: 20, 3: 30, 4: 40}, seen)
+
+	var n int
+	for i := 0; i < 5; i++ {
+		tm.Section(1).Set(i, i, time.Hour)
+	}
+	tm.Section(1).ForEach(func(key, value interface{}) bool {
+		n++
+		return n < 3
+	})
+	assert.Equal(t, 3, n)
+}
+
+func TestKeysValues(t *testing.T) {
+	tm := New(0)
+	assert.Empty(t, tm.Keys())
+
+	tm.Set("a", 1, time.Hour)
+	tm.Set("b", 2, NoExpiration)
+	tm.Set("c", 3, -time.Millisecond)
+	tm.Section(1).Set("d", 4, time.Hour)
+
+	assert.ElementsMatch(t, []interface{}{"a", "b"}, tm.Keys())
+	assert.ElementsMatch(t, []interface{}{1, 2}, tm.Values())
+	assert.Equal(t, []interface{}{"d"}, tm.Section(1).Keys())
+	assert.Equal(t, []interface{}{4}, tm.Section(1).Values())
+}
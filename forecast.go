@@ -0,0 +1,46 @@
+package timedmap
+
+import "time"
+
+// ExpiryForecast returns the number of key-value pairs
+// of all sections which are due to expire in each bucket
+// of the duration bucket from now until horizon, so
+// that load spikes of callbacks can be anticipated.
+// The first element counts the pairs due within the
+// first bucket from now.
+//
+// Pairs are counted at the end of their grace period,
+// when their callbacks are executed. Pairs which never
+// expire or are retained are not counted. If bucket or
+// horizon is 0 or below, nil is returned.
+func (tm *TimedMap) ExpiryForecast(bucket, horizon time.Duration) []int {
+	if bucket <= 0 || horizon <= 0 {
+		return nil
+	}
+
+	n := int(horizon / bucket)
+	if horizon%bucket != 0 {
+		n++
+	}
+	counts := make([]int, n)
+
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	now := time.Now()
+	tm.container.each(func(_ keyWrap, v *element) bool {
+		if !v.expired || v.refs > 0 || tm.isExpired(v, now) {
+			return true
+		}
+		d := v.deadline().Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		if d < horizon {
+			counts[d/bucket]++
+		}
+		return true
+	})
+
+	return counts
+}
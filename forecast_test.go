@@ -0,0 +1,26 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiryForecast(t *testing.T) {
+	tm := New(0)
+	assert.Nil(t, tm.ExpiryForecast(0, time.Hour))
+
+	tm.Set(1, 1, 30*time.Second)
+	tm.Set(2, 2, 90*time.Second)
+	tm.Set(3, 3, 100*time.Second)
+	tm.Set(4, 4, 10*time.Minute)
+	tm.Set(5, 5, NoExpiration)
+	tm.Set(6, 6, -time.Millisecond)
+	tm.Section(1).Set(7, 7, 150*time.Second)
+	tm.Set(8, 8, 20*time.Second)
+	assert.NoError(t, tm.Retain(8))
+
+	assert.Equal(t, []int{1, 2, 1}, tm.ExpiryForecast(time.Minute, 3*time.Minute))
+	assert.Equal(t, []int{1, 2, 1}, tm.ExpiryForecast(time.Minute, 150*time.Second+time.Millisecond))
+}
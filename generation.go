@@ -0,0 +1,62 @@
+package timedmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BumpGeneration invalidates all key-value pairs
+// which are currently stored in the map in O(1).
+//
+// Invalidated pairs are treated as expired and are
+// removed by the next cleanup cycle, or when they are
+// accessed before, so they are still counted by Size
+// until then. Expiration callbacks are not executed
+// for invalidated pairs. The change feed and the
+// write-ahead log record a flush of all sections.
+func (tm *TimedMap) BumpGeneration() {
+	if tm.feed == nil && tm.wal == nil {
+		atomic.AddUint64(&tm.generation, 1)
+		return
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	atomic.AddUint64(&tm.generation, 1)
+	tm.publishFlush(0, true)
+}
+
+// currentGeneration returns the generation
+// new key-value pairs are stamped with.
+func (tm *TimedMap) currentGeneration() uint64 {
+	return atomic.LoadUint64(&tm.generation)
+}
+
+// sweepGenerations removes all pairs invalidated by
+// BumpGeneration since the previous call. The write
+// lock of the map must be held.
+func (tm *TimedMap) sweepGenerations() {
+	gen := tm.currentGeneration()
+	if tm.swept == gen {
+		return
+	}
+	var stale []indexEntry
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if v.gen != gen {
+			stale = append(stale, indexEntry{k: k, v: v})
+		}
+		return true
+	})
+	for _, e := range stale {
+		tm.expireElement(e.k, e.v)
+	}
+	tm.swept = gen
+}
+
+// isExpired returns true if the element has expired
+// at t and is not retained, or was invalidated by
+// BumpGeneration.
+func (tm *TimedMap) isExpired(v *element, t time.Time) bool {
+	return (v.isExpiredAt(t) && v.refs == 0) || v.gen != tm.currentGeneration()
+}
@@ -0,0 +1,52 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpGeneration(t *testing.T) {
+	cb := new(CB)
+	cb.On("Cb").Return()
+
+	tm := New(dCleanupTick)
+
+	tm.Set(1, 1, 20*time.Millisecond, cb.Cb)
+	tm.Set(2, 2, time.Hour)
+	tm.Section(1).Set(3, 3, NoExpiration)
+
+	tm.BumpGeneration()
+	tm.Set(4, 4, time.Hour)
+
+	assert.False(t, tm.Contains(2))
+	assert.False(t, tm.Section(1).Contains(3))
+	assert.True(t, tm.Contains(4))
+	assert.ErrorIs(t, tm.Refresh(2, time.Hour), ErrKeyNotFound)
+	_, err := tm.GetEntry(2)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, map[interface{}]interface{}{4: 4}, tm.Snapshot())
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, tm.getRaw(1, 0))
+	cb.AssertNotCalled(t, "Cb")
+
+	tm.Set(2, 5, time.Hour)
+	assert.EqualValues(t, 5, tm.GetValue(2))
+}
+
+func TestBumpGenerationSweep(t *testing.T) {
+	tm := NewWithOptions(0, WithBackend(NewHeapBackend()))
+
+	tm.Set(1, 1, NoExpiration)
+	tm.Section(1).Set(2, 2, time.Hour)
+	tm.BumpGeneration()
+	tm.Set(3, 3, NoExpiration)
+	assert.Equal(t, 3, tm.Size())
+
+	tm.Cleanup()
+	assert.Equal(t, 1, tm.Size())
+	assert.Nil(t, tm.getRaw(1, 0))
+	assert.EqualValues(t, 3, tm.GetValue(3))
+}
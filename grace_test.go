@@ -0,0 +1,51 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGracePeriod(t *testing.T) {
+	for name, nb := range testBackends {
+		cb := new(CB)
+		cb.On("Cb").Return()
+
+		tm := NewWithOptions(dCleanupTick, WithBackend(nb()), WithGracePeriod(40*time.Millisecond))
+		tm.Set("a", 1, 10*time.Millisecond, cb.Cb)
+
+		e, err := tm.GetEntry("a")
+		assert.Nil(t, err, name)
+		assert.False(t, e.Stale, name)
+
+		time.Sleep(25 * time.Millisecond)
+		assert.EqualValues(t, 1, tm.GetValue("a"), name)
+		e, err = tm.GetEntry("a")
+		assert.Nil(t, err, name)
+		assert.True(t, e.Stale, name)
+		cb.AssertNotCalled(t, "Cb")
+
+		time.Sleep(60 * time.Millisecond)
+		assert.False(t, tm.Contains("a"), name)
+		cb.AssertCalled(t, "Cb")
+	}
+}
+
+func TestWithGrace(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithGracePeriod(time.Hour))
+
+	tm.SetWithOptions("a", 1, 10*time.Millisecond, WithGrace(0))
+	tm.Set("b", 1, 10*time.Millisecond)
+	tm.Section(1).SetWithOptions("c", 1, 10*time.Millisecond, WithGrace(time.Hour))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, tm.Contains("a"))
+	assert.True(t, tm.Contains("b"))
+	assert.True(t, tm.Section(1).Contains("c"))
+
+	assert.Nil(t, tm.Reconfigure(WithGracePeriod(0)))
+	tm.Set("d", 1, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, tm.Contains("d"))
+}
@@ -0,0 +1,88 @@
+// Package grpccache provides a gRPC unary client
+// interceptor which caches responses of idempotent
+// RPCs in a timedmap.
+package grpccache
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// cacheKey identifies a cached response by the
+// full RPC method name and a hash of the request.
+type cacheKey struct {
+	method string
+	hash   [sha256.Size]byte
+}
+
+// UnaryClientInterceptor returns a unary client
+// interceptor which caches responses of the given
+// methods in cache for the duration ttl.
+//
+// Methods must be passed by their full name (for example
+// "/grpc.health.v1.Health/Check") and should only contain
+// idempotent RPCs. Calls to other methods and calls which
+// fail are passed through without being cached.
+func UnaryClientInterceptor(cache timedmap.Section, ttl time.Duration, methods ...string) grpc.UnaryClientInterceptor {
+	cached := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		cached[m] = struct{}{}
+	}
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if _, ok := cached[method]; !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		reqMsg, ok := req.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		key, err := newCacheKey(method, reqMsg)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if v, ok := cache.GetValue(key).(proto.Message); ok {
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, v)
+			return nil
+		}
+
+		if err = invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+
+		cache.Set(key, proto.Clone(replyMsg), ttl)
+		return nil
+	}
+}
+
+// newCacheKey creates the cache key for a call
+// of method with the given request message.
+func newCacheKey(method string, req proto.Message) (k cacheKey, err error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return
+	}
+	k.method = method
+	k.hash = sha256.Sum256(data)
+	return
+}
@@ -0,0 +1,82 @@
+package grpccache
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const methodCheck = "/grpc.health.v1.Health/Check"
+
+func dial(t *testing.T, cache timedmap.Section, calls *int32) (healthpb.HealthClient, func()) {
+	lis := bufconn.Listen(1 << 16)
+
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		atomic.AddInt32(calls, 1)
+		return handler(ctx, req)
+	}))
+	hs := health.NewServer()
+	hs.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(cache, time.Minute, methodCheck)),
+	)
+	assert.Nil(t, err)
+
+	return healthpb.NewHealthClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var calls int32
+	tm := timedmap.New(time.Minute)
+	c, closer := dial(t, tm, &calls)
+	defer closer()
+
+	for i := 0; i < 3; i++ {
+		res, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "svc"})
+		assert.Nil(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	assert.EqualValues(t, 1, tm.Size())
+
+	_, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "other"})
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	assert.EqualValues(t, 1, tm.Size())
+}
+
+func TestUnaryClientInterceptorUncachedMethod(t *testing.T) {
+	var calls int32
+	tm := timedmap.New(time.Minute)
+	c, closer := dial(t, tm, &calls)
+	defer closer()
+
+	_, err := c.List(context.Background(), &healthpb.HealthListRequest{})
+	assert.Nil(t, err)
+	_, err = c.List(context.Background(), &healthpb.HealthListRequest{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	assert.EqualValues(t, 0, tm.Size())
+}
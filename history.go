@@ -0,0 +1,129 @@
+package timedmap
+
+import "time"
+
+// Revision is a previous value of a key
+// retained using WithHistory.
+type Revision struct {
+	// Value is the previous value.
+	Value interface{}
+
+	// Set is the time when the value has been set.
+	Set time.Time
+
+	// Replaced is the time when the value has been
+	// replaced by another value or removed.
+	Replaced time.Time
+}
+
+// history holds the previous values of the keys
+// of a map.
+type history struct {
+	size      int
+	retention time.Duration
+	keys      map[keyWrap][]Revision
+}
+
+// newHistory creates a new history holding up to the
+// last size values of each key for the duration of
+// retention.
+func newHistory(size int, retention time.Duration) *history {
+	return &history{
+		size:      size,
+		retention: retention,
+		keys:      make(map[keyWrap][]Revision),
+	}
+}
+
+// add appends rev to the revisions of k, dropping
+// the oldest one if there are more than size.
+func (h *history) add(k keyWrap, rev Revision) {
+	revs := append(h.keys[k], rev)
+	if len(revs) > h.size {
+		copy(revs, revs[1:])
+		revs[len(revs)-1] = Revision{}
+		revs = revs[:h.size]
+	}
+	h.keys[k] = revs
+}
+
+// get returns the revisions of k which have not
+// exceeded the retention at now.
+func (h *history) get(k keyWrap, now time.Time) []Revision {
+	var res []Revision
+	for _, rev := range h.keys[k] {
+		if h.retained(rev, now) {
+			res = append(res, rev)
+		}
+	}
+	return res
+}
+
+// retained returns true if rev has not
+// exceeded the retention at now.
+func (h *history) retained(rev Revision, now time.Time) bool {
+	return h.retention <= 0 || now.Sub(rev.Replaced) < h.retention
+}
+
+// sweep drops all revisions which have
+// exceeded the retention at now.
+func (h *history) sweep(now time.Time) {
+	for k, revs := range h.keys {
+		i := 0
+		for i < len(revs) && !h.retained(revs[i], now) {
+			i++
+		}
+		switch {
+		case i == len(revs):
+			delete(h.keys, k)
+		case i > 0:
+			h.keys[k] = append(revs[:0:0], revs[i:]...)
+		}
+	}
+}
+
+// History returns the previous values of a key which
+// have been replaced or removed within the retention
+// set using WithHistory, starting with the oldest one.
+// The current value is not included. nil is returned
+// if there are none or the map has no history.
+func (tm *TimedMap) History(key interface{}) []Revision {
+	return tm.getHistory(key, 0)
+}
+
+func (s *section) History(key interface{}) []Revision {
+	return s.tm.getHistory(key, s.sec)
+}
+
+// getHistory returns the previous values
+// of the given key in the given section.
+func (tm *TimedMap) getHistory(key interface{}, sec int) []Revision {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.history == nil {
+		return nil
+	}
+	revs := tm.history.get(k, time.Now())
+	for i := range revs {
+		revs[i].Value = tm.readValue(k, revs[i].Value)
+	}
+	return revs
+}
+
+// addRevision records the value of the live element v
+// of k, which is replaced or removed at now, in the
+// history of the map, if it has one. The write lock of
+// the map must be held.
+func (tm *TimedMap) addRevision(k keyWrap, v *element, now time.Time) {
+	if tm.history == nil {
+		return
+	}
+	tm.history.add(k, Revision{
+		Value:    v.value,
+		Set:      v.updated,
+		Replaced: now,
+	})
+}
@@ -0,0 +1,69 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func historyValues(revs []Revision) []interface{} {
+	var values []interface{}
+	for _, rev := range revs {
+		values = append(values, rev.Value)
+	}
+	return values
+}
+
+func TestHistory(t *testing.T) {
+	tm := NewWithOptions(0, WithHistory(3, time.Hour))
+	defer tm.Close()
+
+	tm.Set(1, "a", time.Hour)
+	assert.Nil(t, tm.History(1))
+
+	start := time.Now()
+	tm.Set(1, "b", time.Hour)
+	tm.Set(1, "c", time.Hour)
+	assert.Equal(t, []interface{}{"a", "b"}, historyValues(tm.History(1)))
+
+	revs := tm.History(1)
+	assert.False(t, revs[0].Set.After(start))
+	assert.False(t, revs[0].Replaced.Before(start))
+	assert.Equal(t, revs[0].Replaced, revs[1].Set)
+
+	// removed values are recorded and only the
+	// last size values are kept
+	tm.Remove(1)
+	tm.Set(1, "d", time.Hour)
+	tm.Set(1, "e", time.Hour)
+	assert.Equal(t, []interface{}{"b", "c", "d"}, historyValues(tm.History(1)))
+
+	// values which expired are not recorded
+	tm.Set(2, "a", -time.Second)
+	tm.Set(2, "b", time.Hour)
+	assert.Nil(t, tm.History(2))
+
+	s := tm.Section(1)
+	s.Set(1, "x", time.Hour)
+	s.Set(1, "y", time.Hour)
+	assert.Equal(t, []interface{}{"x"}, historyValues(s.History(1)))
+
+	assert.Nil(t, New(0).History(1))
+}
+
+func TestHistoryRetention(t *testing.T) {
+	tm := NewWithOptions(0, WithHistory(10, 10*time.Millisecond))
+	defer tm.Close()
+
+	tm.Set(1, "a", time.Hour)
+	tm.Set(1, "b", time.Hour)
+	time.Sleep(15 * time.Millisecond)
+	tm.Set(1, "c", time.Hour)
+	assert.Equal(t, []interface{}{"b"}, historyValues(tm.History(1)))
+
+	time.Sleep(15 * time.Millisecond)
+	assert.Nil(t, tm.History(1))
+	tm.cleanUp()
+	assert.Empty(t, tm.history.keys)
+}
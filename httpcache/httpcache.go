@@ -0,0 +1,217 @@
+// Package httpcache provides an http.RoundTripper which
+// transparently caches responses to GET requests in a
+// timedmap using the lifetime announced by the
+// Cache-Control and Expires response headers.
+package httpcache
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// HeaderFromCache is set to "1" on responses which
+// were served from the cache.
+const HeaderFromCache = "X-From-Cache"
+
+// Transport is an http.RoundTripper which caches
+// responses to GET requests in a timedmap Section.
+//
+// Only responses with a cacheable status code and a
+// positive lifetime derived from the max-age directive
+// of the Cache-Control header or from the Expires header
+// are stored. Responses carrying a Vary header are not
+// cached.
+type Transport struct {
+	// Transport is the underlying RoundTripper used
+	// to perform requests which can not be served from
+	// the cache. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	cache timedmap.Section
+}
+
+// cachedResponse contains all data required to
+// re-create a response from the cache.
+type cachedResponse struct {
+	status     string
+	statusCode int
+	proto      string
+	protoMajor int
+	protoMinor int
+	header     http.Header
+	body       []byte
+}
+
+// NewTransport creates a new Transport which stores
+// cached responses in the given cache section and
+// performs requests using next.
+//
+// If next is nil, http.DefaultTransport is used.
+func NewTransport(cache timedmap.Section, next http.RoundTripper) *Transport {
+	return &Transport{
+		Transport: next,
+		cache:     cache,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isCacheableRequest(req) {
+		return t.transport().RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	if cr, ok := t.cache.GetValue(key).(*cachedResponse); ok {
+		return cr.response(req), nil
+	}
+
+	res, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := lifetime(res, time.Now())
+	if ttl <= 0 || !isCacheableStatus(res.StatusCode) || res.Header.Get("Vary") != "" {
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.cache.Set(key, &cachedResponse{
+		status:     res.Status,
+		statusCode: res.StatusCode,
+		proto:      res.Proto,
+		protoMajor: res.ProtoMajor,
+		protoMinor: res.ProtoMinor,
+		header:     res.Header.Clone(),
+		body:       body,
+	}, ttl)
+
+	return res, nil
+}
+
+// transport returns the underlying RoundTripper.
+func (t *Transport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// response creates a new response from the
+// cached response for the given request.
+func (cr *cachedResponse) response(req *http.Request) *http.Response {
+	header := cr.header.Clone()
+	header.Set(HeaderFromCache, "1")
+
+	return &http.Response{
+		Status:        cr.status,
+		StatusCode:    cr.statusCode,
+		Proto:         cr.proto,
+		ProtoMajor:    cr.protoMajor,
+		ProtoMinor:    cr.protoMinor,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(cr.body)),
+		ContentLength: int64(len(cr.body)),
+		Request:       req,
+	}
+}
+
+// cacheKey returns the key under which the
+// response to req is stored.
+func cacheKey(req *http.Request) string {
+	return req.URL.String()
+}
+
+// isCacheableRequest returns true when the
+// response to req may be served from the cache.
+func isCacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return false
+	}
+	cc := parseCacheControl(req.Header)
+	_, noStore := cc["no-store"]
+	_, noCache := cc["no-cache"]
+	return !noStore && !noCache
+}
+
+// isCacheableStatus returns true if responses with
+// the given status code are cacheable by default.
+func isCacheableStatus(code int) bool {
+	switch code {
+	case http.StatusOK,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusMultipleChoices,
+		http.StatusMovedPermanently,
+		http.StatusNotFound,
+		http.StatusGone:
+		return true
+	}
+	return false
+}
+
+// lifetime returns the duration res may be served
+// from the cache relative to now. A duration <= 0
+// means that the response must not be cached.
+func lifetime(res *http.Response, now time.Time) time.Duration {
+	cc := parseCacheControl(res.Header)
+	if _, ok := cc["no-store"]; ok {
+		return 0
+	}
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+
+	if v, ok := cc["max-age"]; ok {
+		secs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0
+		}
+		age, _ := strconv.ParseInt(res.Header.Get("Age"), 10, 64)
+		return time.Duration(secs-age) * time.Second
+	}
+
+	if v := res.Header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		if date, err := http.ParseTime(res.Header.Get("Date")); err == nil {
+			return expires.Sub(date)
+		}
+		return expires.Sub(now)
+	}
+
+	return 0
+}
+
+// parseCacheControl parses the Cache-Control header
+// of h into a map of lower case directives to their
+// (possibly empty) values.
+func parseCacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, part := range strings.Split(h.Get("Cache-Control"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var val string
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			val = strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+			part = strings.TrimSpace(part[:i])
+		}
+		cc[strings.ToLower(part)] = val
+	}
+	return cc
+}
@@ -0,0 +1,104 @@
+package httpcache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer(cacheControl string, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Write([]byte("hello"))
+	}))
+}
+
+func get(t *testing.T, c *http.Client, url string) *http.Response {
+	res, err := c.Get(url)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, "hello", string(body))
+	return res
+}
+
+func TestRoundTripCached(t *testing.T) {
+	var hits int32
+	srv := testServer("max-age=60", &hits)
+	defer srv.Close()
+
+	tm := timedmap.New(time.Minute)
+	c := &http.Client{Transport: NewTransport(tm, nil)}
+
+	res := get(t, c, srv.URL)
+	assert.Empty(t, res.Header.Get(HeaderFromCache))
+
+	res = get(t, c, srv.URL)
+	assert.Equal(t, "1", res.Header.Get(HeaderFromCache))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+
+	exp, err := tm.GetExpires(srv.URL)
+	assert.Nil(t, err)
+	assert.InDelta(t, 60*time.Second, time.Until(exp), float64(time.Second))
+}
+
+func TestRoundTripNotCached(t *testing.T) {
+	for _, cc := range []string{"", "no-store", "no-cache", "max-age=0"} {
+		var hits int32
+		srv := testServer(cc, &hits)
+
+		tm := timedmap.New(time.Minute)
+		c := &http.Client{Transport: NewTransport(tm, nil)}
+
+		get(t, c, srv.URL)
+		get(t, c, srv.URL)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&hits), cc)
+		assert.EqualValues(t, 0, tm.Size(), cc)
+
+		srv.Close()
+	}
+}
+
+func TestRoundTripRequestNoCache(t *testing.T) {
+	var hits int32
+	srv := testServer("max-age=60", &hits)
+	defer srv.Close()
+
+	c := &http.Client{Transport: NewTransport(timedmap.New(time.Minute), nil)}
+	get(t, c, srv.URL)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	res, err := c.Do(req)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+}
+
+func TestLifetime(t *testing.T) {
+	now := time.Now()
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Cache-Control", "public, max-age=30")
+	res.Header.Set("Age", "10")
+	assert.Equal(t, 20*time.Second, lifetime(res, now))
+
+	res = &http.Response{Header: http.Header{}}
+	res.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+	res.Header.Set("Expires", now.Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Hour, lifetime(res, now))
+
+	res = &http.Response{Header: http.Header{}}
+	res.Header.Set("Expires", "0")
+	assert.Equal(t, time.Duration(0), lifetime(res, now))
+}
@@ -0,0 +1,144 @@
+// Package lease provides expiring leases stored in
+// a timedmap, which have to be kept alive by their
+// holder and are lost when the holder stops doing
+// so, for example as ownership or leader hints
+// within a process.
+package lease
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+var (
+	// ErrHeld is returned by Acquire when the
+	// lease of the key is held by someone else.
+	ErrHeld = errors.New("lease is held")
+
+	// ErrLost is returned when a lease is kept
+	// alive or released after it has expired or
+	// has been released.
+	ErrLost = errors.New("lease is lost")
+)
+
+// Manager hands out leases on keys.
+//
+// Expired leases are detected by the cleanup loop of
+// the map, so leases are lost up to one cleanup
+// interval after their expiry, unless the map uses
+// precise expiry.
+type Manager struct {
+	// OnLost is called in a new go routine with the
+	// key of a lease which expired because it was not
+	// kept alive, if specified.
+	OnLost func(key interface{})
+
+	cache timedmap.Section
+	mtx   sync.Mutex
+}
+
+// Lease is a lease on a key acquired using
+// Manager.Acquire.
+type Lease struct {
+	m    *Manager
+	key  interface{}
+	ttl  time.Duration
+	lost chan struct{}
+	once sync.Once
+}
+
+// New creates a new Manager storing leases in
+// the given cache section.
+func New(cache timedmap.Section) *Manager {
+	return &Manager{
+		cache: cache,
+	}
+}
+
+// Acquire acquires the lease of key, which expires
+// after ttl unless it is kept alive. If the lease is
+// held by someone else, ErrHeld is returned.
+func (m *Manager) Acquire(key interface{}, ttl time.Duration) (*Lease, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.cache.Contains(key) {
+		return nil, ErrHeld
+	}
+
+	l := &Lease{
+		m:    m,
+		key:  key,
+		ttl:  ttl,
+		lost: make(chan struct{}),
+	}
+	m.cache.Set(key, l, ttl, func(interface{}) {
+		l.expire()
+	})
+
+	return l, nil
+}
+
+// Key returns the key of the lease.
+func (l *Lease) Key() interface{} {
+	return l.key
+}
+
+// KeepAlive extends the lease by its ttl from now.
+// If the lease has been lost, ErrLost is returned.
+func (l *Lease) KeepAlive() error {
+	l.m.mtx.Lock()
+	defer l.m.mtx.Unlock()
+
+	if !l.held() {
+		return ErrLost
+	}
+	if err := l.m.cache.SetExpires(l.key, l.ttl); err != nil {
+		return ErrLost
+	}
+	return nil
+}
+
+// Release gives up the lease, so that it can be
+// acquired again. The loss callback is not called.
+// If the lease has been lost, ErrLost is returned.
+func (l *Lease) Release() error {
+	l.m.mtx.Lock()
+	defer l.m.mtx.Unlock()
+
+	if !l.held() {
+		return ErrLost
+	}
+	l.m.cache.Remove(l.key)
+	l.once.Do(func() {
+		close(l.lost)
+	})
+	return nil
+}
+
+// Lost returns a channel which is closed when
+// the lease has expired or has been released.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// held returns true if l is the current lease of
+// its key. The lock of the manager must be held.
+func (l *Lease) held() bool {
+	cur, ok := l.m.cache.Get(l.key)
+	return ok && cur == l
+}
+
+// expire is executed by the map when the lease
+// expired. It runs while the map is locked.
+func (l *Lease) expire() {
+	l.once.Do(func() {
+		close(l.lost)
+		if l.m.OnLost != nil {
+			go l.m.OnLost(l.key)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package lease
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	m := New(timedmap.New(10 * time.Millisecond))
+
+	l, err := m.Acquire("leader", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "leader", l.Key())
+
+	_, err = m.Acquire("leader", time.Hour)
+	assert.ErrorIs(t, err, ErrHeld)
+
+	assert.Nil(t, l.Release())
+	<-l.Lost()
+	assert.ErrorIs(t, l.Release(), ErrLost)
+	assert.ErrorIs(t, l.KeepAlive(), ErrLost)
+
+	l2, err := m.Acquire("leader", time.Hour)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, l.Release(), ErrLost)
+	assert.Nil(t, l2.KeepAlive())
+}
+
+func TestKeepAlive(t *testing.T) {
+	m := New(timedmap.New(5 * time.Millisecond))
+
+	l, err := m.Acquire("leader", 30*time.Millisecond)
+	assert.Nil(t, err)
+
+	for i := 0; i < 5; i++ {
+		time.Sleep(15 * time.Millisecond)
+		assert.Nil(t, l.KeepAlive())
+	}
+
+	select {
+	case <-l.Lost():
+		t.Fatal("lease lost while kept alive")
+	default:
+	}
+}
+
+func TestLost(t *testing.T) {
+	m := New(timedmap.New(5 * time.Millisecond))
+	lost := make(chan interface{}, 1)
+	m.OnLost = func(key interface{}) {
+		lost <- key
+	}
+
+	l, err := m.Acquire("leader", 10*time.Millisecond)
+	assert.Nil(t, err)
+
+	select {
+	case key := <-lost:
+		assert.Equal(t, "leader", key)
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost")
+	}
+	<-l.Lost()
+	assert.ErrorIs(t, l.KeepAlive(), ErrLost)
+
+	_, err = m.Acquire("leader", time.Hour)
+	assert.Nil(t, err)
+}
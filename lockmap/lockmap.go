@@ -0,0 +1,66 @@
+// Package lockmap provides per-key locks stored in
+// a timedmap, which expire automatically if their
+// holder crashes or forgets to unlock them.
+package lockmap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// Map holds expiring locks keyed by resource.
+type Map struct {
+	cache timedmap.Section
+	mtx   sync.Mutex
+}
+
+// token identifies a single acquisition of a lock,
+// so that an unlock function of an expired lock can
+// not release the lock of the next holder. It is
+// not zero-sized, so that each token has a distinct
+// address.
+type token struct {
+	key interface{}
+}
+
+// New creates a new Map storing locks in the
+// given cache section.
+func New(cache timedmap.Section) *Map {
+	return &Map{
+		cache: cache,
+	}
+}
+
+// TryLock acquires the lock of key if it is not
+// held, which is released automatically after ttl.
+// On success, it returns a function releasing the
+// lock and true. Calling the returned function more
+// than once or after the lock expired has no effect.
+func (m *Map) TryLock(key interface{}, ttl time.Duration) (unlock func(), ok bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.cache.Contains(key) {
+		return nil, false
+	}
+
+	t := &token{key: key}
+	m.cache.Set(key, t, ttl)
+
+	return func() {
+		m.mtx.Lock()
+		defer m.mtx.Unlock()
+
+		if cur, ok := m.cache.Get(key); ok && cur == t {
+			m.cache.Remove(key)
+		}
+	}, true
+}
+
+// IsLocked returns true if the lock
+// of key is currently held.
+func (m *Map) IsLocked(key interface{}) bool {
+	return m.cache.Contains(key)
+}
@@ -0,0 +1,67 @@
+package lockmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryLock(t *testing.T) {
+	m := New(timedmap.New(time.Minute))
+
+	unlock, ok := m.TryLock("a", time.Hour)
+	assert.True(t, ok)
+	assert.True(t, m.IsLocked("a"))
+
+	_, ok = m.TryLock("a", time.Hour)
+	assert.False(t, ok)
+
+	_, ok = m.TryLock("b", time.Hour)
+	assert.True(t, ok)
+
+	unlock()
+	assert.False(t, m.IsLocked("a"))
+	_, ok = m.TryLock("a", time.Hour)
+	assert.True(t, ok)
+
+	// a stale unlock must not release the new holder
+	unlock()
+	assert.True(t, m.IsLocked("a"))
+}
+
+func TestTryLockExpires(t *testing.T) {
+	m := New(timedmap.New(time.Minute))
+
+	unlock, ok := m.TryLock("a", 10*time.Millisecond)
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, m.IsLocked("a"))
+
+	_, ok = m.TryLock("a", time.Hour)
+	assert.True(t, ok)
+	unlock()
+	assert.True(t, m.IsLocked("a"))
+}
+
+func TestTryLockConcurrent(t *testing.T) {
+	m := New(timedmap.New(time.Minute))
+
+	var held int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := m.TryLock("a", time.Hour); ok {
+				atomic.AddInt32(&held, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, held)
+}
@@ -0,0 +1,131 @@
+package timedmap
+
+import "time"
+
+// GetExpiresMulti returns the expire times of all passed
+// keys which exist in the map and have not expired. Keys
+// of key-value pairs which never expire are mapped to
+// the zero time. All keys are looked up while holding
+// the lock of the map once.
+func (tm *TimedMap) GetExpiresMulti(keys ...interface{}) map[interface{}]time.Time {
+	return tm.getExpiresMulti(keys, 0)
+}
+
+func (s *section) GetExpiresMulti(keys ...interface{}) map[interface{}]time.Time {
+	return s.tm.getExpiresMulti(keys, s.sec)
+}
+
+// getExpiresMulti returns the expire times of the
+// passed keys in the given section.
+func (tm *TimedMap) getExpiresMulti(keys []interface{}, sec int) map[interface{}]time.Time {
+	m := make(map[interface{}]time.Time, len(keys))
+	now := time.Now()
+
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.closed {
+		return m
+	}
+
+	for _, key := range keys {
+		k := tm.wrapKey(key, sec)
+		if !tm.containsLocked(k, now) {
+			continue
+		}
+		v, _ := tm.container.get(k)
+		m[key] = v.expires
+	}
+	return m
+}
+
+// SetMulti sets the values of all keys of values like
+// Set, which expire after expiresAfter, while holding
+// the lock of the map once. Values which are rejected,
+// for example by the validator, are dropped silently.
+func (tm *TimedMap) SetMulti(values map[interface{}]interface{}, expiresAfter time.Duration) {
+	tm.setMulti(values, 0, expiresAfter)
+}
+
+func (s *section) SetMulti(values map[interface{}]interface{}, expiresAfter time.Duration) {
+	s.tm.setMulti(values, s.sec, expiresAfter)
+}
+
+// GetMulti returns the values of all passed keys which
+// exist in the map and have not expired. All keys are
+// looked up while holding the lock of the map once.
+func (tm *TimedMap) GetMulti(keys ...interface{}) map[interface{}]interface{} {
+	return tm.getMulti(keys, 0)
+}
+
+func (s *section) GetMulti(keys ...interface{}) map[interface{}]interface{} {
+	return s.tm.getMulti(keys, s.sec)
+}
+
+// RemoveMulti deletes the key-value pairs of all passed
+// keys like Remove while holding the lock of the map
+// once.
+func (tm *TimedMap) RemoveMulti(keys ...interface{}) {
+	tm.removeMulti(keys, 0)
+}
+
+func (s *section) RemoveMulti(keys ...interface{}) {
+	s.tm.removeMulti(keys, s.sec)
+}
+
+// setMulti sets the passed values
+// in the given section.
+func (tm *TimedMap) setMulti(values map[interface{}]interface{}, sec int, expiresAfter time.Duration) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	for key, value := range values {
+		tm.swapLocked(tm.wrapKey(key, sec), value, expiresAfter, setOptions{})
+	}
+}
+
+// getMulti returns the values of the
+// passed keys in the given section.
+func (tm *TimedMap) getMulti(keys []interface{}, sec int) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, len(keys))
+	now := time.Now()
+
+	var sliding []indexEntry
+	tm.mtx.RLock()
+	if tm.closed {
+		tm.mtx.RUnlock()
+		return m
+	}
+	for _, key := range keys {
+		k := tm.wrapKey(key, sec)
+		v, ok := tm.container.get(k)
+		if !ok || tm.isExpired(v, now) {
+			continue
+		}
+		m[key] = tm.readValue(k, v.value)
+		tm.markAccess(v)
+		if v.sliding > 0 {
+			sliding = append(sliding, indexEntry{k: k, v: v})
+		}
+	}
+	tm.mtx.RUnlock()
+
+	for _, e := range sliding {
+		tm.slide(e.k, e.v)
+	}
+	return m
+}
+
+// removeMulti removes the passed
+// keys in the given section.
+func (tm *TimedMap) removeMulti(keys []interface{}, sec int) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return
+	}
+	for _, key := range keys {
+		tm.removeLocked(tm.wrapKey(key, sec), "")
+	}
+}
@@ -0,0 +1,48 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExpiresMulti(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.Set("a", 1, time.Hour)
+		s.Set("b", 2, NoExpiration)
+		s.Set("c", 3, 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		m := s.GetExpiresMulti("a", "b", "c", "x")
+		assert.Len(t, m, 2)
+		assert.InDelta(t, time.Hour, time.Until(m["a"]), float64(time.Second))
+		exp, ok := m["b"]
+		assert.True(t, ok)
+		assert.True(t, exp.IsZero())
+	}
+
+	assert.Empty(t, tm.GetExpiresMulti())
+}
+
+func TestSetGetRemoveMulti(t *testing.T) {
+	tm := New(0)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.SetMulti(map[interface{}]interface{}{"a": 1, "b": 2, "c": 3}, time.Hour)
+		s.Set("d", 4, -time.Millisecond)
+
+		assert.Equal(t, map[interface{}]interface{}{"a": 1, "b": 2},
+			s.GetMulti("a", "b", "d", "e"))
+
+		s.RemoveMulti("a", "c", "e")
+		assert.Equal(t, map[interface{}]interface{}{"b": 2},
+			s.GetMulti("a", "b", "c"))
+	}
+
+	tm.Close()
+	tm.SetMulti(map[interface{}]interface{}{"x": 1}, time.Hour)
+	assert.Empty(t, tm.GetMulti("x", "b"))
+}
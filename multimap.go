@@ -0,0 +1,132 @@
+package timedmap
+
+import (
+	"sync"
+	"time"
+)
+
+// MultiMap is a map where each key holds multiple
+// values, each of which expires independently.
+type MultiMap struct {
+	tm  *TimedMap
+	mtx sync.Mutex
+}
+
+// multiValue is a single value of a key in a
+// MultiMap. expires is the zero time if the value
+// never expires.
+type multiValue struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewMultiMap creates a new MultiMap with the given
+// cleanup tick time like New.
+func NewMultiMap(cleanupTickTime time.Duration, tickerChan ...<-chan time.Time) *MultiMap {
+	return &MultiMap{
+		tm: New(cleanupTickTime, tickerChan...),
+	}
+}
+
+// Append adds value to the values of key, which is
+// removed after expiresAfter independently of the
+// other values of key. Pass NoExpiration to add a
+// value which never expires.
+func (m *MultiMap) Append(key, value interface{}, expiresAfter time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := time.Now()
+	mv := multiValue{value: value}
+	if expiresAfter != NoExpiration {
+		mv.expires = now.Add(expiresAfter)
+	}
+
+	vals := append(m.live(key, now), mv)
+	m.tm.Set(key, vals, ttlOf(vals, now))
+}
+
+// GetAll returns the values of key which have not
+// expired in the order they were appended. nil is
+// returned if there are none.
+func (m *MultiMap) GetAll(key interface{}) []interface{} {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	vals := m.live(key, time.Now())
+	if len(vals) == 0 {
+		return nil
+	}
+
+	res := make([]interface{}, len(vals))
+	for i, mv := range vals {
+		res[i] = mv.value
+	}
+	return res
+}
+
+// Count returns the number of values of
+// key which have not expired.
+func (m *MultiMap) Count(key interface{}) int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return len(m.live(key, time.Now()))
+}
+
+// Remove removes all values of key.
+func (m *MultiMap) Remove(key interface{}) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.tm.Remove(key)
+}
+
+// Flush removes all keys and their values.
+func (m *MultiMap) Flush() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.tm.Flush()
+}
+
+// Close stops the cleanup loop of the multimap and
+// removes all of its values like TimedMap.Close.
+func (m *MultiMap) Close() error {
+	return m.tm.Close()
+}
+
+// live returns a new slice holding the values of key
+// which have not expired at now. The lock of the
+// multimap must be held.
+func (m *MultiMap) live(key interface{}, now time.Time) []multiValue {
+	v, ok := m.tm.Get(key)
+	if !ok {
+		return nil
+	}
+
+	vals := v.([]multiValue)
+	kept := make([]multiValue, 0, len(vals)+1)
+	for _, mv := range vals {
+		if mv.expires.IsZero() || now.Before(mv.expires) {
+			kept = append(kept, mv)
+		}
+	}
+	return kept
+}
+
+// ttlOf returns the duration from now after which
+// the last of vals expires, or NoExpiration if one
+// of them never expires.
+func ttlOf(vals []multiValue, now time.Time) time.Duration {
+	var last time.Time
+	for _, mv := range vals {
+		if mv.expires.IsZero() {
+			return NoExpiration
+		}
+		if mv.expires.After(last) {
+			last = mv.expires
+		}
+	}
+	return last.Sub(now)
+}
@@ -0,0 +1,44 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiMap(t *testing.T) {
+	m := NewMultiMap(dCleanupTick)
+
+	m.Append("a", 1, time.Hour)
+	m.Append("a", 2, 5*time.Millisecond)
+	m.Append("a", 3, NoExpiration)
+	m.Append("b", 4, time.Hour)
+
+	assert.Equal(t, []interface{}{1, 2, 3}, m.GetAll("a"))
+	assert.Equal(t, []interface{}{4}, m.GetAll("b"))
+	assert.Nil(t, m.GetAll("c"))
+	assert.Equal(t, 3, m.Count("a"))
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, []interface{}{1, 3}, m.GetAll("a"))
+	assert.Equal(t, 2, m.Count("a"))
+
+	m.Remove("a")
+	assert.Nil(t, m.GetAll("a"))
+
+	m.Flush()
+	assert.Nil(t, m.GetAll("b"))
+	assert.NoError(t, m.Close())
+}
+
+func TestMultiMapExpiresKey(t *testing.T) {
+	m := NewMultiMap(dCleanupTick)
+
+	m.Append("a", 1, 5*time.Millisecond)
+	m.Append("a", 2, 10*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, m.GetAll("a"))
+	assert.Equal(t, 0, m.tm.Size())
+}
@@ -0,0 +1,62 @@
+package timedmap
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// Namespace returns the section of the map with the
+// given name, which is an isolated key space sharing
+// the cleanup loop of the map like any other section.
+//
+// The identifier of the section is derived from the
+// name, so a namespace keeps its identifier across
+// restarts of a map using SaveTo or WithWAL. Namespaces
+// use negative identifiers, so identifiers passed to
+// Section should not be negative to avoid collisions.
+func (tm *TimedMap) Namespace(name string) Section {
+	tm.nsMtx.Lock()
+	defer tm.nsMtx.Unlock()
+
+	if sec, ok := tm.namespaces[name]; ok {
+		return newSection(tm, sec)
+	}
+
+	if tm.namespaces == nil {
+		tm.namespaces = make(map[string]int)
+		tm.namespaceNames = make(map[int]string)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	sec := -1 - int(h.Sum32()&0x7fffffff)
+
+	// probe for a free identifier if the
+	// hashes of two names collide
+	for {
+		if _, taken := tm.namespaceNames[sec]; !taken {
+			break
+		}
+		if sec--; sec >= 0 {
+			sec = -1
+		}
+	}
+
+	tm.namespaces[name] = sec
+	tm.namespaceNames[sec] = name
+	return newSection(tm, sec)
+}
+
+// Namespaces returns the sorted names of
+// all namespaces obtained using Namespace.
+func (tm *TimedMap) Namespaces() []string {
+	tm.nsMtx.Lock()
+	defer tm.nsMtx.Unlock()
+
+	names := make([]string, 0, len(tm.namespaces))
+	for name := range tm.namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
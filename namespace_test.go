@@ -0,0 +1,55 @@
+package timedmap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	tm := New(0)
+	defer tm.Close()
+
+	users := tm.Namespace("users")
+	sessions := tm.Namespace("sessions")
+	assert.Less(t, users.Ident(), 0)
+	assert.NotEqual(t, users.Ident(), sessions.Ident())
+	assert.Equal(t, users.Ident(), tm.Namespace("users").Ident())
+	assert.Equal(t, []string{"sessions", "users"}, tm.Namespaces())
+
+	users.Set(1, "alice", time.Hour)
+	sessions.Set(1, "token", time.Hour)
+	tm.Set(1, "root", time.Hour)
+	assert.Equal(t, "alice", users.GetValue(1))
+	assert.Equal(t, "token", sessions.GetValue(1))
+	assert.Equal(t, "root", tm.GetValue(1))
+
+	users.Flush()
+	assert.False(t, users.Contains(1))
+	assert.True(t, sessions.Contains(1))
+
+	// namespaces keep their identifiers across maps
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+	restored := New(0)
+	defer restored.Close()
+	_, err := restored.LoadFrom(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", restored.Namespace("sessions").GetValue(1))
+}
+
+func TestNamespaceCollision(t *testing.T) {
+	tm := New(0)
+	defer tm.Close()
+
+	a := tm.Namespace("a")
+	tm.nsMtx.Lock()
+	delete(tm.namespaces, "a")
+	tm.namespaceNames[a.Ident()] = "b"
+	tm.nsMtx.Unlock()
+
+	// "a" now collides with the identifier taken by "b"
+	assert.Equal(t, a.Ident()-1, tm.Namespace("a").Ident())
+}
@@ -0,0 +1,5 @@
+package timedmap
+
+// KeyNormalizer maps a key to its normalized form,
+// which is used to store and look up the key.
+type KeyNormalizer func(key interface{}) interface{}
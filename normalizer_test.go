@@ -0,0 +1,87 @@
+package timedmap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lowerKey(key interface{}) interface{} {
+	if s, ok := key.(string); ok {
+		return strings.ToLower(strings.TrimSpace(s))
+	}
+	return key
+}
+
+func TestWithKeyNormalizer(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithKeyNormalizer(lowerKey))
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.Set("Foo", 1, time.Hour)
+		assert.True(t, s.Contains(" foo "))
+		assert.EqualValues(t, 1, s.GetValue("FOO"))
+
+		e, err := s.GetEntry("fOo")
+		assert.Nil(t, err)
+		assert.Equal(t, "foo", e.Key)
+
+		s.Set("foo", 2, time.Hour)
+		assert.EqualValues(t, 2, s.GetValue("Foo"))
+		assert.Equal(t, 1, s.Size())
+
+		assert.Nil(t, s.Refresh("FOO", time.Minute))
+		s.Remove("FOO")
+		assert.False(t, s.Contains("foo"))
+	}
+}
+
+func TestWithKeyNormalizerValidator(t *testing.T) {
+	var got interface{}
+	tm := NewWithOptions(dCleanupTick,
+		WithKeyNormalizer(lowerKey),
+		WithValidator(func(key, value interface{}) error {
+			got = key
+			return nil
+		}))
+
+	tm.Set("Foo", 1, time.Hour)
+	assert.Equal(t, "foo", got)
+	assert.Equal(t, map[interface{}]interface{}{"foo": 1}, tm.Snapshot())
+}
+
+func TestWithKeyNormalizerOnce(t *testing.T) {
+	prefix := func(key interface{}) interface{} {
+		return "n:" + key.(string)
+	}
+	var transformed []interface{}
+	tm := NewWithOptions(0,
+		WithKeyNormalizer(prefix),
+		WithReadTransform(func(key, value interface{}) interface{} {
+			transformed = append(transformed, key)
+			return value
+		}))
+
+	tm.Set("a", 1, time.Millisecond)
+	v, err := tm.GetOrCompute("b", func() (interface{}, time.Duration, error) {
+		return 2, time.Hour, nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, v)
+	assert.EqualValues(t, 2, tm.GetValue("b"))
+	assert.Equal(t, []interface{}{"n:b"}, transformed)
+
+	time.Sleep(5 * time.Millisecond)
+	tm.Cleanup()
+	assert.Equal(t, 1, tm.Size())
+	assert.Equal(t, map[interface{}]interface{}{"n:b": 2}, tm.Snapshot())
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+	restored := NewWithOptions(0, WithKeyNormalizer(prefix))
+	_, err = restored.LoadFrom(&buf)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, restored.GetValue("b"))
+}
@@ -0,0 +1,59 @@
+package timedmap
+
+import "time"
+
+// CleanerObserver is notified of the events of the
+// cleanup cycles of a map, which allows to integrate
+// them into custom monitoring. It is set using
+// WithCleanerObserver.
+//
+// The methods are called from the goroutine running
+// the cleanup cycle while the map is not locked, so
+// they may access the map, but delay the next cycle
+// until they return.
+type CleanerObserver interface {
+	// OnSweep is called after each cleanup cycle with
+	// the time the cycle started, the number of pairs
+	// it removed and its duration.
+	OnSweep(start time.Time, removed int, d time.Duration)
+
+	// OnSkippedTick is called for each tick of the
+	// cleanup loop which has been skipped because it
+	// was sent while a cycle was running.
+	OnSkippedTick()
+
+	// OnPanic is called with the value passed to panic
+	// when a cleanup cycle panicked, for example in an
+	// expiration callback. The panic is recovered and
+	// the cleanup loop keeps running.
+	OnPanic(v interface{})
+}
+
+// cleanerObserver returns the CleanerObserver
+// of the map, if any.
+func (tm *TimedMap) cleanerObserver() CleanerObserver {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	return tm.opts.cleanerObserver
+}
+
+// observedCleanUp runs a cleanup cycle like cleanUp and
+// notifies the CleanerObserver of the map, if any.
+func (tm *TimedMap) observedCleanUp() (expired, size int) {
+	obs := tm.cleanerObserver()
+	if obs == nil {
+		return tm.cleanUp()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			obs.OnPanic(v)
+		}
+	}()
+
+	start := time.Now()
+	expired, size = tm.cleanUp()
+	obs.OnSweep(start, expired, time.Since(start))
+	return
+}
@@ -0,0 +1,94 @@
+package timedmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testObserver struct {
+	mtx     sync.Mutex
+	sweeps  int
+	removed int
+	skipped int
+	panics  []interface{}
+}
+
+func (o *testObserver) OnSweep(start time.Time, removed int, d time.Duration) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.sweeps++
+	o.removed += removed
+}
+
+func (o *testObserver) OnSkippedTick() {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.skipped++
+}
+
+func (o *testObserver) OnPanic(v interface{}) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.panics = append(o.panics, v)
+}
+
+func TestCleanerObserver(t *testing.T) {
+	obs := new(testObserver)
+	tm := NewWithOptions(0, WithCleanerObserver(obs))
+
+	tm.Set(1, 1, -time.Second)
+	tm.Set(2, 2, -time.Second)
+	tm.Set(3, 3, time.Hour)
+	tm.Cleanup()
+	tm.Cleanup()
+	assert.Equal(t, 2, obs.sweeps)
+	assert.Equal(t, 2, obs.removed)
+
+	// panics of callbacks are recovered and the
+	// pair is removed nevertheless
+	tm.Set(4, 4, -time.Second, func(interface{}) {
+		panic("callback")
+	})
+	tm.Cleanup()
+	assert.Equal(t, []interface{}{"callback"}, obs.panics)
+	assert.Equal(t, 1, tm.Size())
+	tm.Cleanup()
+	assert.Equal(t, 1, len(obs.panics))
+}
+
+func TestCleanerObserverSkippedTicks(t *testing.T) {
+	obs := new(testObserver)
+	tc := make(chan time.Time, 3)
+	tm := NewWithOptions(0, WithCleanerObserver(obs))
+
+	block := make(chan struct{})
+	tm.Set(1, 1, -time.Second, func(interface{}) {
+		<-block
+	})
+	tm.StartCleanerExternal(tc)
+	defer tm.StopCleaner()
+
+	tc <- time.Now()
+	time.Sleep(10 * time.Millisecond)
+	tc <- time.Now()
+	tc <- time.Now()
+	close(block)
+
+	assert.Eventually(t, func() bool {
+		obs.mtx.Lock()
+		defer obs.mtx.Unlock()
+		return obs.sweeps == 1 && obs.skipped == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(2), tm.Stats().SkippedTicks)
+}
+
+func TestReconfigureCleanerObserver(t *testing.T) {
+	obs := new(testObserver)
+	tm := New(0)
+	assert.NoError(t, tm.Reconfigure(WithCleanerObserver(obs)))
+	tm.Cleanup()
+	assert.Equal(t, 1, obs.sweeps)
+}
@@ -0,0 +1,526 @@
+package timedmap
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures optional features of a
+// TimedMap created with NewWithOptions.
+type Option func(o *options)
+
+// options contains the optional
+// configuration of a TimedMap.
+type options struct {
+	backend       Backend
+	preciseExpiry bool
+	timerWindow   time.Duration
+	maxTimers     int
+
+	bloomExpectedKeys      int
+	bloomFalsePositiveRate float64
+
+	slowCallbackThreshold time.Duration
+	slowCallbackHandler   SlowCallbackHandler
+
+	validator     Validator
+	keyNormalizer KeyNormalizer
+	copyOnRead    CopyFunc
+
+	maxKeySize   int
+	maxValueSize int
+	valueSize    SizeFunc
+
+	grace time.Duration
+
+	tombstone time.Duration
+
+	binSize      int
+	binRetention time.Duration
+
+	auditSize   int
+	auditWriter io.Writer
+
+	feedSize int
+
+	writeLimit  int
+	writeWindow time.Duration
+
+	ttlRules []TTLRule
+
+	name string
+
+	cleanupStrategy CleanupStrategy
+
+	costFunc CostFunc
+
+	ttlPolicy TTLPolicy
+
+	readTransform  TransformFunc
+	writeTransform TransformFunc
+
+	callbackWorkers int
+
+	walPath string
+
+	cleanerObserver CleanerObserver
+
+	maxSize         int
+	evictionHandler EvictionHandler
+	evictionPolicy  EvictionPolicy
+
+	historySize      int
+	historyRetention time.Duration
+
+	maxCost     int64
+	maxCostFunc CostFunc
+}
+
+// NewWithOptions creates and returns a new instance
+// of TimedMap configured with the given options.
+//
+// The passed cleanupTickTime is handled like in New.
+// To control the cleanup loop with a custom channel,
+// pass 0 and call StartCleanerExternal afterwards.
+func NewWithOptions(cleanupTickTime time.Duration, opts ...Option) *TimedMap {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tm := newTimedMap(o)
+	register(tm)
+
+	if cleanupTickTime > 0 {
+		tm.StartCleanerInternal(cleanupTickTime)
+	}
+
+	return tm
+}
+
+// WithBloomFilter maintains a counting bloom filter
+// alongside the map, which is sized for the given
+// number of expected keys and the desired false
+// positive rate.
+//
+// Lookups of keys which are definitely not present
+// in the map are answered by the filter without
+// acquiring the maps lock, which reduces contention
+// in workloads with many misses.
+func WithBloomFilter(expectedKeys int, falsePositiveRate float64) Option {
+	return func(o *options) {
+		o.bloomExpectedKeys = expectedKeys
+		o.bloomFalsePositiveRate = falsePositiveRate
+	}
+}
+
+// WithBackend sets the storage engine of the map.
+// By default, the backend returned by NewMapBackend
+// is used.
+func WithBackend(b Backend) Option {
+	return func(o *options) {
+		o.backend = b
+	}
+}
+
+// WithPreciseExpiry schedules a runtime timer for the
+// deadline of each key-value pair, which expires the pair
+// and executes its callbacks right when it is due instead
+// of on the next cleanup cycle. Pairs with equal deadlines
+// share a timer.
+//
+// Use this for time-sensitive callbacks which can not
+// tolerate being late for up to one cleanup interval.
+// The cleanup loop keeps running as a fallback.
+func WithPreciseExpiry() Option {
+	return func(o *options) {
+		o.preciseExpiry = true
+	}
+}
+
+// WithTimerCoalescing limits the timers created in
+// precise expiry mode (see WithPreciseExpiry).
+//
+// Deadlines within the same window share a timer, so
+// pairs may expire up to window late. At most maxTimers
+// timers are running at once; pairs which would need
+// another timer are expired by the cleanup loop
+// instead. A maxTimers of 0 means no limit.
+func WithTimerCoalescing(window time.Duration, maxTimers int) Option {
+	return func(o *options) {
+		o.timerWindow = window
+		o.maxTimers = maxTimers
+	}
+}
+
+// WithSlowCallbackHandler measures the execution time of
+// expiration callbacks and calls handler with the key
+// of the expired pair and the execution time for each
+// callback which took longer than threshold. If handler
+// is nil, slow callbacks are logged using the standard
+// logger.
+//
+// The number of slow callbacks is reported in Stats.
+func WithSlowCallbackHandler(threshold time.Duration, handler SlowCallbackHandler) Option {
+	return func(o *options) {
+		o.slowCallbackThreshold = threshold
+		o.slowCallbackHandler = handler
+	}
+}
+
+// WithValidator sets a function which validates all
+// values before they are stored in the map. Values
+// for which the validator returns an error are not
+// stored.
+//
+// SetWithOptions and SetCtx return a *ValidationError
+// wrapping the error of the validator for rejected
+// values, while Set silently drops them.
+func WithValidator(v Validator) Option {
+	return func(o *options) {
+		o.validator = v
+	}
+}
+
+// WithKeyNormalizer sets a function which is applied
+// to all keys passed to the map before they are used,
+// for example to lower-case string keys.
+//
+// Each key is normalized once when it is passed to
+// the map. Callbacks, validators and snapshots receive
+// the normalized key, so keys restored using LoadFrom,
+// Recover or the write-ahead log are not normalized
+// again, while keys read by ImportCSV are.
+func WithKeyNormalizer(n KeyNormalizer) Option {
+	return func(o *options) {
+		o.keyNormalizer = n
+	}
+}
+
+// WithMaxKeySize sets the maximum size of keys in
+// bytes as returned by DefaultSize. Setting keys
+// which exceed this size fails with ErrKeyTooLarge.
+func WithMaxKeySize(max int) Option {
+	return func(o *options) {
+		o.maxKeySize = max
+	}
+}
+
+// WithMaxValueSize sets the maximum size of values
+// in bytes as returned by size. If size is nil,
+// DefaultSize is used. Setting values which exceed
+// this size fails with ErrValueTooLarge.
+func WithMaxValueSize(max int, size SizeFunc) Option {
+	return func(o *options) {
+		o.maxValueSize = max
+		o.valueSize = size
+	}
+}
+
+// WithCopyOnRead sets a function which is used to
+// return a copy of stored values on every read, so
+// that callers can not mutate shared values like
+// slices or maps stored in the map. Copying adds to
+// the cost of every read, so only enable it for maps
+// storing mutable values.
+//
+// The function is applied by GetValue, Get, GetCtx,
+// GetEntry, GetOrCompute and Snapshot. It may be
+// executed while the map is locked and must not
+// access the map.
+func WithCopyOnRead(c CopyFunc) Option {
+	return func(o *options) {
+		o.copyOnRead = c
+	}
+}
+
+// WithGracePeriod sets the duration for which expired
+// key-value pairs are still served before they are
+// removed from the map and their callbacks are executed,
+// which smooths over short outages of the source of the
+// values. Pairs served during their grace period are
+// flagged as stale by GetEntry.
+//
+// The grace period can be overridden for single pairs
+// using WithGrace.
+func WithGracePeriod(d time.Duration) Option {
+	return func(o *options) {
+		o.grace = d
+	}
+}
+
+// WithTombstones makes Remove leave a tombstone for the
+// removed key, which lasts for the duration of window.
+// While the tombstone exists, setting the key fails with
+// ErrRecentlyDeleted, so that late writers do not recreate
+// a key which has just been deleted by someone else.
+//
+// Set drops such writes silently. Use SetWithOptions to
+// detect them and WithResurrect to set the key anyway.
+func WithTombstones(window time.Duration) Option {
+	return func(o *options) {
+		o.tombstone = window
+	}
+}
+
+// WithRecycleBin keeps up to size key-value pairs which
+// expired or have been removed for the duration of
+// retention, so that they can be brought back using
+// Restore. When the bin is full, the oldest pair is
+// dropped. Pairs removed by Flush or invalidated by
+// BumpGeneration are not kept.
+func WithRecycleBin(size int, retention time.Duration) Option {
+	return func(o *options) {
+		o.binSize = size
+		o.binRetention = retention
+	}
+}
+
+// WithAuditLog records every Set, Remove and change of
+// an expire time in an in-memory ring buffer holding the
+// last size records, which can be queried using AuditLog.
+// If w is not nil, each record is also written to w as
+// a single line. Expirations are not recorded.
+//
+// Records are written while the map is locked, so w
+// must not access the map.
+func WithAuditLog(size int, w io.Writer) Option {
+	return func(o *options) {
+		o.auditSize = size
+		o.auditWriter = w
+	}
+}
+
+// WithChangeFeed records all changes of the map,
+// including expirations, in an ordered feed retaining
+// the last size changes, which can be read using
+// Changes and WaitChanges to mirror the map.
+func WithChangeFeed(size int) Option {
+	return func(o *options) {
+		o.feedSize = size
+	}
+}
+
+// WithWriteRateLimit limits the number of times the value
+// of a single key can be set to n within each window, so
+// that pathological writers can not flood the callbacks
+// or replication of the map. Further writes within the
+// window fail with ErrRateLimited.
+//
+// Set drops such writes silently. Use SetWithOptions to
+// detect them. Pass 0 as n to disable the limit.
+func WithWriteRateLimit(n int, window time.Duration) Option {
+	return func(o *options) {
+		o.writeLimit = n
+		o.writeWindow = window
+	}
+}
+
+// WithTTLRules sets the rules which determine the
+// expiry of keys set with DefaultExpiration. The first
+// rule whose pattern matches the key applies.
+func WithTTLRules(rules ...TTLRule) Option {
+	return func(o *options) {
+		o.ttlRules = rules
+	}
+}
+
+// WithName sets the name of the map and adds it to the
+// registry of the package, which can be enumerated
+// using RegisteredMaps until the map is closed.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithCleanupStrategy sets the strategy deciding which
+// key-value pairs are examined in each cleanup cycle.
+// By default, IndexedCleanup is used.
+func WithCleanupStrategy(s CleanupStrategy) Option {
+	return func(o *options) {
+		o.cleanupStrategy = s
+	}
+}
+
+// WithCostFunc sets the function which determines the
+// cost of each key-value pair when it is set. The total
+// cost of the stored pairs and the cost of expired pairs
+// are reported in Stats, and the most costly pairs can
+// be listed using LargestEntries.
+//
+// The function is executed while the map is locked and
+// must not access the map.
+func WithCostFunc(fn CostFunc) Option {
+	return func(o *options) {
+		o.costFunc = fn
+	}
+}
+
+// WithTTLPolicy sets how durations of 0 or below passed
+// to Set are handled. By default, TTLExpireOnCleanup is
+// used.
+//
+// Set drops values rejected by TTLReject silently. Use
+// SetWithOptions to detect them.
+func WithTTLPolicy(p TTLPolicy) Option {
+	return func(o *options) {
+		o.ttlPolicy = p
+	}
+}
+
+// WithReadTransform sets a function which is applied to
+// stored values before they are returned by reads or
+// passed to callbacks, for example to decompress or
+// decrypt them. It is the counterpart of the function
+// set using WithWriteTransform.
+//
+// The function is applied wherever WithCopyOnRead applies,
+// before the value is copied. It may be executed while the
+// map is locked and must not access the map.
+func WithReadTransform(fn TransformFunc) Option {
+	return func(o *options) {
+		o.readTransform = fn
+	}
+}
+
+// WithWriteTransform sets a function which is applied to
+// values when they are set, for example to compress or
+// encrypt them, so the map stores the transformed values.
+//
+// Validators and size limits are applied to the value as
+// passed to Set, while the CostFunc receives the stored
+// value. The function is executed while the map is
+// locked and must not access the map.
+func WithWriteTransform(fn TransformFunc) Option {
+	return func(o *options) {
+		o.writeTransform = fn
+	}
+}
+
+// WithAsyncCallbacks executes expiration callbacks in
+// the given number of background workers instead of
+// while the map is locked, so slow callbacks do not
+// block the map and callbacks may access the map.
+//
+// All callbacks of a key are executed by the same
+// worker, so they run serially in the order the key
+// expired, while callbacks of different keys run in
+// parallel. With a single worker, all callbacks run
+// serially. Callbacks registered using WithRearm are
+// still executed synchronously. Close waits for the
+// callbacks which are queued when it is called, so
+// callbacks must not close the map. The number of
+// workers can be changed using Reconfigure.
+func WithAsyncCallbacks(workers int) Option {
+	return func(o *options) {
+		o.callbackWorkers = workers
+	}
+}
+
+// WithWAL records all Set, Remove and expire time changes
+// in a write-ahead log at path, which is replayed when a
+// map is created with the same path, so that a restarted
+// service finds the key-value pairs which have not
+// expired yet. The log is synced to disk every few
+// milliseconds, so a crash loses at most the changes of
+// the last milliseconds.
+//
+// The log is compacted on every replay and can be
+// compacted at runtime using CompactWAL. Keys and values
+// are encoded using encoding/gob like in SaveTo, and
+// callbacks are not restored. Errors of the log do not
+// fail the operations of the map and are reported by
+// WALError and Close instead.
+func WithWAL(path string) Option {
+	return func(o *options) {
+		o.walPath = path
+	}
+}
+
+// WithCleanerObserver sets the CleanerObserver which is
+// notified of the cleanup cycles of the map, including
+// cycles run using Cleanup. Panics during cleanup cycles
+// are only recovered if an observer is set.
+func WithCleanerObserver(obs CleanerObserver) Option {
+	return func(o *options) {
+		o.cleanerObserver = obs
+	}
+}
+
+// WithMaxSize limits the number of key-value pairs of
+// the map, including all sections, to max. When a key
+// is inserted into a full map, the least recently used
+// pair is evicted, or expired with its callbacks if it
+// has already expired. Pairs which are retained are
+// never evicted, so the map may exceed max if all pairs
+// are retained.
+//
+// The evicted pairs are chosen by the policy set using
+// WithEvictionPolicy. Reads only count the uses of
+// pairs, which approximates the policy with the CLOCK
+// algorithm. Evicted pairs are passed to the handler
+// set using WithEvictionHandler with EvictSize.
+func WithMaxSize(max int) Option {
+	return func(o *options) {
+		o.maxSize = max
+	}
+}
+
+// WithEvictionHandler sets the handler which is called
+// for each key-value pair evicted from the map.
+func WithEvictionHandler(h EvictionHandler) Option {
+	return func(o *options) {
+		o.evictionHandler = h
+	}
+}
+
+// WithEvictionPolicy sets the policy deciding which
+// key-value pairs are evicted when the map exceeds
+// the size set using WithMaxSize. By default, LRU
+// is used.
+func WithEvictionPolicy(p EvictionPolicy) Option {
+	return func(o *options) {
+		o.evictionPolicy = p
+	}
+}
+
+// WithHistory keeps the last size values of each key
+// which have been replaced by Set or removed for the
+// duration of retention, so that they can be queried
+// using History, for example to debug flapping values.
+// A retention of 0 keeps the values until they are
+// pushed out by newer ones, so the history grows with
+// the number of distinct keys ever set.
+func WithHistory(size int, retention time.Duration) Option {
+	return func(o *options) {
+		o.historySize = size
+		o.historyRetention = retention
+	}
+}
+
+// WithMaxCost limits the total cost of the key-value
+// pairs of the map, including all sections, to max, for
+// example to bound its approximate memory footprint.
+// The cost of each pair is determined by costFn like
+// with WithCostFunc. costFn takes precedence over the
+// CostFunc set using WithCostFunc, regardless of the
+// order of the options. If costFn is nil, the CostFunc
+// set using WithCostFunc is used and without one, each
+// pair costs 1, so max limits the number of pairs.
+//
+// When setting a key makes the total cost exceed max,
+// pairs are evicted like with WithMaxSize until it does
+// not anymore, and passed to the handler set using
+// WithEvictionHandler with EvictCost. The pair which has
+// just been set is never evicted, so a single pair may
+// exceed max. The cost of evicted pairs is added to
+// EvictedCost in Stats.
+func WithMaxCost(max int64, costFn CostFunc) Option {
+	return func(o *options) {
+		o.maxCost = max
+		if costFn != nil {
+			o.maxCostFunc = costFn
+		}
+	}
+}
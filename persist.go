@@ -0,0 +1,356 @@
+package timedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// snapshotMagic identifies the files written by SaveTo.
+var snapshotMagic = [4]byte{'T', 'M', 'S', 'N'}
+
+const (
+	// snapshotVersion is the version of the snapshot
+	// format written by SaveTo.
+	snapshotVersion uint16 = 1
+
+	// maxSnapshotRecord is the maximum size of a record,
+	// above which the length is treated as corrupt.
+	maxSnapshotRecord = 1 << 28
+)
+
+// SnapshotError is returned by LoadFrom when a snapshot
+// can not be read. Record is the index of the record
+// and Offset the position in the stream at which the
+// error has been detected.
+//
+// errors.Is(err, ErrSnapshotTruncated), ErrSnapshotCorrupt
+// or ErrSnapshotVersion reports the kind of the error.
+type SnapshotError struct {
+	Record int
+	Offset int64
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *SnapshotError) Error() string {
+	return fmt.Sprintf("snapshot record %d at offset %d: %s", e.Record, e.Offset, e.Err)
+}
+
+// Unwrap returns the kind of the error.
+func (e *SnapshotError) Unwrap() error {
+	return e.Err
+}
+
+// snapshotRecord is a key-value pair as stored
+// in a snapshot.
+//
+// Meta and Sliding have been added to the first
+// version of the format. As encoding/gob skips
+// missing fields, snapshots written before are
+// read without metadata and sliding expiry.
+type snapshotRecord struct {
+	Section int
+	Key     interface{}
+	Value   interface{}
+	Meta    interface{}
+	Expires time.Time
+	Grace   time.Duration
+	Sliding time.Duration
+}
+
+// SaveTo writes all key-value pairs of all sections
+// which have not expired to w together with their
+// absolute expire times, metadata and sliding expiry,
+// so that they can be restored using LoadFrom, for
+// example after a restart.
+//
+// Keys, values and metadata are encoded using
+// encoding/gob, so types other than the basic types
+// must be registered using gob.Register. Callbacks
+// are not saved.
+//
+// The snapshot starts with a version header and each
+// record as well as the whole snapshot is protected by
+// a checksum, so that truncated or corrupted snapshots
+// are detected by LoadFrom.
+func (tm *TimedMap) SaveTo(w io.Writer) error {
+	records, err := tm.snapshotRecords()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	sum := crc32.NewIEEE()
+	out := io.MultiWriter(bw, sum)
+
+	if err = binary.Write(out, binary.BigEndian, snapshotMagic); err != nil {
+		return err
+	}
+	if err = binary.Write(out, binary.BigEndian, snapshotVersion); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, rec := range records {
+		if err = writeRecord(out, &buf, rec); err != nil {
+			return err
+		}
+	}
+
+	// a record length of 0 marks the end of the records
+	if err = binary.Write(out, binary.BigEndian, uint32(0)); err != nil {
+		return err
+	}
+	if err = binary.Write(out, binary.BigEndian, uint64(len(records))); err != nil {
+		return err
+	}
+	if err = binary.Write(bw, binary.BigEndian, sum.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadFrom reads a snapshot written by SaveTo from r and
+// sets all key-value pairs which have not expired yet,
+// with their original expire times. The passed callbacks
+// are registered for each restored pair, as callbacks are
+// not saved. It returns the number of restored pairs.
+//
+// The snapshot is read and verified completely before
+// the map is changed. If it is truncated, corrupted or
+// of an unsupported version, a *SnapshotError is returned
+// and the map is not modified.
+//
+// The pairs are set like using Set, so pairs rejected by
+// the validator, the size limits, tombstones or the write
+// rate limit of the map are skipped and the first error
+// is returned together with the number of restored pairs.
+func (tm *TimedMap) LoadFrom(r io.Reader, cb ...func(key, value interface{})) (int, error) {
+	records, err := readSnapshot(r)
+	if err != nil {
+		return 0, err
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return 0, ErrClosed
+	}
+
+	now := time.Now()
+	var n int
+	var rejected error
+	for _, rec := range records {
+		if !rec.Expires.IsZero() && !rec.Expires.Add(rec.Grace).After(now) {
+			continue
+		}
+		k := keyWrap{sec: rec.Section, key: rec.Key}
+		so := setOptions{meta: rec.Meta, grace: rec.Grace, graceSet: true, sliding: rec.Sliding}
+		for _, c := range cb {
+			c := c
+			so.cbs = append(so.cbs, func(value interface{}) {
+				c(k.key, value)
+			})
+		}
+		if err = tm.restoreLocked(k, now, tm.transformRead(k, rec.Value), rec.Expires, so); err != nil {
+			if rejected == nil {
+				rejected = err
+			}
+			continue
+		}
+		n++
+	}
+	return n, rejected
+}
+
+// restoreLocked sets the value of k like swapLocked,
+// as read by LoadFrom, ImportCSV or the replay of the
+// write-ahead log, with the absolute expire time expires,
+// or without expiry if it is zero. The expire time is
+// kept even if it has passed and the pair is in its
+// grace period. value is passed as read by callers, so
+// the write transform of the map is applied again. The
+// write lock of the map must be held.
+func (tm *TimedMap) restoreLocked(k keyWrap, now time.Time, value interface{}, expires time.Time, so setOptions) error {
+	ttl := NoExpiration
+	if !expires.IsZero() {
+		// any duration above zero passes the TTL
+		// policy, the deadline is set by store
+		ttl = time.Nanosecond
+		so.deadline = expires.Local()
+	}
+	_, _, err := tm.swapLockedAt(k, now, value, ttl, so)
+	return err
+}
+
+// snapshotRecords returns the records of all
+// live key-value pairs of the map.
+func (tm *TimedMap) snapshotRecords() ([]snapshotRecord, error) {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.closed {
+		return nil, ErrClosed
+	}
+
+	now := time.Now()
+	var records []snapshotRecord
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if tm.isExpired(v, now) {
+			return true
+		}
+		rec := snapshotRecord{Section: k.sec, Key: k.key, Value: v.value, Meta: v.meta}
+		if v.expired {
+			// the wall clock is used across restarts
+			rec.Expires = v.expires.Round(0)
+			rec.Grace = v.grace
+			rec.Sliding = v.sliding
+		}
+		records = append(records, rec)
+		return true
+	})
+	return records, nil
+}
+
+// snapshotReader reads a snapshot while keeping track
+// of the offset and the checksum of the read bytes.
+type snapshotReader struct {
+	r      *bufio.Reader
+	sum    hash.Hash32
+	offset int64
+	record int
+}
+
+// readSnapshot reads and verifies all
+// records of the snapshot in r.
+func readSnapshot(r io.Reader) ([]snapshotRecord, error) {
+	sr := &snapshotReader{
+		r:   bufio.NewReader(r),
+		sum: crc32.NewIEEE(),
+	}
+
+	var header struct {
+		Magic   [4]byte
+		Version uint16
+	}
+	if err := sr.read(&header); err != nil {
+		return nil, err
+	}
+	if header.Magic != snapshotMagic || header.Version != snapshotVersion {
+		return nil, sr.fail(0, ErrSnapshotVersion)
+	}
+
+	var records []snapshotRecord
+	for {
+		var length uint32
+		if err := sr.read(&length); err != nil {
+			return nil, err
+		}
+		if length == 0 {
+			break
+		}
+		var rec snapshotRecord
+		if err := sr.readRecord(length, &rec); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+		sr.record++
+	}
+
+	var count uint64
+	if err := sr.read(&count); err != nil {
+		return nil, err
+	}
+	if count != uint64(len(records)) {
+		return nil, sr.fail(8, ErrSnapshotCorrupt)
+	}
+	expected := sr.sum.Sum32()
+	var sum uint32
+	if err := sr.read(&sum); err != nil {
+		return nil, err
+	}
+	if sum != expected {
+		return nil, sr.fail(4, ErrSnapshotCorrupt)
+	}
+	return records, nil
+}
+
+// writeRecord writes rec encoded using encoding/gob
+// to w, preceded by its length and checksum. buf is
+// used to encode the record.
+func writeRecord(w io.Writer, buf *bytes.Buffer, rec interface{}) error {
+	buf.Reset()
+	if err := gob.NewEncoder(buf).Encode(rec); err != nil {
+		return err
+	}
+	frame := [2]uint32{uint32(buf.Len()), crc32.ChecksumIEEE(buf.Bytes())}
+	if err := binary.Write(w, binary.BigEndian, frame); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readRecord reads the checksum and payload of a
+// record of the given length, which has just been
+// read, and decodes the payload into rec.
+func (sr *snapshotReader) readRecord(length uint32, rec interface{}) error {
+	if length > maxSnapshotRecord {
+		return sr.fail(4, ErrSnapshotCorrupt)
+	}
+
+	var crc uint32
+	if err := sr.read(&crc); err != nil {
+		return err
+	}
+	payload := make([]byte, length)
+	if err := sr.read(payload); err != nil {
+		return err
+	}
+	if crc32.ChecksumIEEE(payload) != crc {
+		return sr.fail(int64(length), ErrSnapshotCorrupt)
+	}
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(rec); err != nil {
+		return sr.fail(int64(length), ErrSnapshotCorrupt)
+	}
+	return nil
+}
+
+// read reads data from the snapshot, which is a fixed
+// size value or a byte slice, and returns a truncation
+// error if the snapshot ends early.
+func (sr *snapshotReader) read(data interface{}) error {
+	size := int64(binary.Size(data))
+	if b, ok := data.([]byte); ok {
+		size = int64(len(b))
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(sr.r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return sr.fail(0, ErrSnapshotTruncated)
+		}
+		return err
+	}
+	sr.sum.Write(buf)
+	sr.offset += size
+
+	if b, ok := data.([]byte); ok {
+		copy(b, buf)
+		return nil
+	}
+	return binary.Read(bytes.NewReader(buf), binary.BigEndian, data)
+}
+
+// fail returns a SnapshotError of kind err for the
+// data of the given size which has just been read.
+func (sr *snapshotReader) fail(size int64, err error) error {
+	return &SnapshotError{Record: sr.record, Offset: sr.offset - size, Err: err}
+}
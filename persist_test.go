@@ -0,0 +1,181 @@
+package timedmap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveToLoadFrom(t *testing.T) {
+	tm := New(0)
+	tm.Set(1, "a", time.Hour)
+	tm.Set("b", 2, NoExpiration)
+	tm.Section(2).Set(1, "c", time.Hour)
+	tm.Set(3, "expired", -time.Second)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+	exp, err := tm.GetExpires(1)
+	assert.NoError(t, err)
+
+	var expired []interface{}
+	restored := New(0)
+	n, err := restored.LoadFrom(bytes.NewReader(buf.Bytes()), func(key, value interface{}) {
+		expired = append(expired, key)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	assert.Equal(t, "a", restored.GetValue(1))
+	assert.Equal(t, 2, restored.GetValue("b"))
+	assert.Equal(t, "c", restored.Section(2).GetValue(1))
+	assert.False(t, restored.Contains(3))
+
+	rexp, err := restored.GetExpires(1)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, exp, rexp, time.Millisecond)
+	rexp, err = restored.GetExpires("b")
+	assert.NoError(t, err)
+	assert.True(t, rexp.IsZero())
+
+	// pairs which expire after the snapshot has
+	// been taken are dropped on load
+	tm.Set(4, "d", 10*time.Millisecond)
+	buf.Reset()
+	assert.NoError(t, tm.SaveTo(&buf))
+	time.Sleep(20 * time.Millisecond)
+	restored = New(0)
+	n, err = restored.LoadFrom(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, restored.Contains(4))
+
+	// the passed callbacks are registered
+	// for the restored pairs
+	buf.Reset()
+	tm.Set(5, "e", 10*time.Millisecond)
+	assert.NoError(t, tm.SaveTo(&buf))
+	restored = New(0)
+	_, err = restored.LoadFrom(&buf, func(key, value interface{}) {
+		expired = append(expired, key)
+	})
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	restored.cleanUp()
+	assert.Equal(t, []interface{}{5}, expired)
+}
+
+func TestLoadFromInvalid(t *testing.T) {
+	tm := New(0)
+	tm.Set(1, "a", time.Hour)
+	tm.Set(2, "b", time.Hour)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+	data := buf.Bytes()
+
+	restored := New(0)
+	_, err := restored.LoadFrom(bytes.NewReader(data[:len(data)-20]))
+	assert.ErrorIs(t, err, ErrSnapshotTruncated)
+	var serr *SnapshotError
+	assert.ErrorAs(t, err, &serr)
+	assert.Equal(t, 1, serr.Record)
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[20] ^= 0xff
+	_, err = restored.LoadFrom(bytes.NewReader(corrupt))
+	assert.ErrorIs(t, err, ErrSnapshotCorrupt)
+
+	corrupt = append([]byte(nil), data...)
+	corrupt[5] = 2
+	_, err = restored.LoadFrom(bytes.NewReader(corrupt))
+	assert.ErrorIs(t, err, ErrSnapshotVersion)
+
+	_, err = restored.LoadFrom(bytes.NewReader([]byte("not a snapshot")))
+	assert.ErrorIs(t, err, ErrSnapshotVersion)
+
+	// the map is not modified by invalid snapshots
+	assert.Equal(t, 0, restored.Size())
+
+	restored.Close()
+	_, err = restored.LoadFrom(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, restored.SaveTo(&buf), ErrClosed)
+}
+
+func TestLoadFromChecks(t *testing.T) {
+	tm := New(0)
+	tm.Set(1, 1, time.Hour)
+	tm.Set(2, "b", time.Hour)
+	tm.SetWithOptions(3, 3, 5*time.Millisecond, WithGrace(time.Hour))
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+	exp, err := tm.GetExpires(3)
+	assert.NoError(t, err)
+
+	// pairs are validated like set using Set
+	time.Sleep(10 * time.Millisecond)
+	restored := NewWithOptions(0, WithValidator(intValidator))
+	n, err := restored.LoadFrom(&buf)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	assert.Equal(t, 2, n)
+	assert.False(t, restored.Contains(2))
+
+	// pairs in their grace period keep their expire time
+	rexp, err := restored.GetExpires(3)
+	assert.NoError(t, err)
+	assert.Equal(t, exp.Round(0), rexp.Round(0))
+	e, err := restored.GetEntry(3)
+	assert.NoError(t, err)
+	assert.True(t, e.Stale)
+}
+
+func TestLoadFromTransform(t *testing.T) {
+	opts := []Option{
+		WithWriteTransform(func(key, value interface{}) interface{} {
+			return []byte(value.(string))
+		}),
+		WithReadTransform(func(key, value interface{}) interface{} {
+			return string(value.([]byte))
+		}),
+	}
+	tm := NewWithOptions(0, opts...)
+	tm.Set(1, "a", time.Hour)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+
+	restored := NewWithOptions(0, opts...)
+	n, err := restored.LoadFrom(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "a", restored.GetValue(1))
+}
+
+func TestSaveToMetaSliding(t *testing.T) {
+	tm := NewWithOptions(0, WithTTLRules(
+		TTLRule{Pattern: "session:*", TTL: time.Hour, Sliding: true},
+	))
+	assert.NoError(t, tm.SetWithOptions("session:1", 1, DefaultExpiration, WithMeta("m")))
+
+	var buf bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&buf))
+	data := buf.Bytes()
+
+	loaded := New(0)
+	_, err := loaded.LoadFrom(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	recovered := New(0)
+	assert.NoError(t, recovered.Recover(bytes.NewReader(data), nil))
+
+	for _, m := range []*TimedMap{loaded, recovered} {
+		e, err := m.GetEntry("session:1")
+		assert.NoError(t, err)
+		assert.Equal(t, "m", e.Meta)
+		assert.Equal(t, time.Hour, m.getRaw("session:1", 0).sliding)
+	}
+}
@@ -0,0 +1,36 @@
+package timedmap
+
+// Pop removes a key-value pair from the map and returns
+// its value and true in one step, so that only one of
+// concurrent callers obtains the value, for example of
+// a one-shot token. ok is false if there is no value to
+// the passed key or if the value was expired.
+//
+// Like Remove, Pop does not execute the callbacks of
+// the key-value pair.
+func (tm *TimedMap) Pop(key interface{}) (value interface{}, ok bool) {
+	return tm.pop(key, 0)
+}
+
+func (s *section) Pop(key interface{}) (value interface{}, ok bool) {
+	return s.tm.pop(key, s.sec)
+}
+
+// pop removes the given key in the given
+// section and returns its value.
+func (tm *TimedMap) pop(key interface{}, sec int) (interface{}, bool) {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return nil, false
+	}
+
+	value, ok := tm.removeLocked(k, "")
+	if !ok {
+		return nil, false
+	}
+	return tm.readValue(k, value), true
+}
@@ -0,0 +1,48 @@
+package timedmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPop(t *testing.T) {
+	tm := New(0)
+
+	var called bool
+	tm.Set(1, "token", time.Hour, func(interface{}) {
+		called = true
+	})
+
+	var popped int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := tm.Pop(1); ok {
+				assert.Equal(t, "token", v)
+				atomic.AddInt32(&popped, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, popped)
+	assert.False(t, tm.Contains(1))
+	assert.False(t, called)
+
+	tm.Set(2, 2, -time.Millisecond)
+	_, ok := tm.Pop(2)
+	assert.False(t, ok)
+	assert.Equal(t, 0, tm.Size())
+
+	tm.Section(1).Set(1, 3, time.Hour)
+	_, ok = tm.Pop(1)
+	assert.False(t, ok)
+	v, ok := tm.Section(1).Pop(1)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
@@ -0,0 +1,28 @@
+package timedmap
+
+import "time"
+
+// checkWriteRate counts a write of k at now and returns
+// ErrRateLimited if it exceeds the write rate limit of
+// the map. Writes of keys which are not present or have
+// expired are counted by store. The write lock of the
+// map must be held.
+func (tm *TimedMap) checkWriteRate(k keyWrap, now time.Time) error {
+	if tm.opts.writeLimit <= 0 {
+		return nil
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, now) {
+		return nil
+	}
+
+	if now.Sub(v.window) >= tm.opts.writeWindow {
+		v.writes, v.window = 0, now
+	}
+	if v.writes >= tm.opts.writeLimit {
+		return ErrRateLimited
+	}
+	v.writes++
+	return nil
+}
@@ -0,0 +1,47 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRateLimit(t *testing.T) {
+	tm := NewWithOptions(0, WithWriteRateLimit(2, 20*time.Millisecond))
+
+	assert.NoError(t, tm.SetWithOptions(1, 1, time.Hour))
+	assert.NoError(t, tm.SetWithOptions(1, 2, time.Hour))
+	assert.ErrorIs(t, tm.SetWithOptions(1, 3, time.Hour), ErrRateLimited)
+	tm.Set(1, 4, time.Hour)
+	assert.Equal(t, 2, tm.GetValue(1))
+
+	// other keys and sections are limited independently
+	assert.NoError(t, tm.SetWithOptions(2, 1, time.Hour))
+	assert.NoError(t, tm.Section(1).SetWithOptions(1, 1, time.Hour))
+
+	time.Sleep(25 * time.Millisecond)
+	assert.NoError(t, tm.SetWithOptions(1, 5, time.Hour))
+	assert.Equal(t, 5, tm.GetValue(1))
+}
+
+func TestWriteRateLimitExpired(t *testing.T) {
+	tm := NewWithOptions(0, WithWriteRateLimit(1, time.Hour))
+
+	assert.NoError(t, tm.SetWithOptions(1, 1, -time.Second))
+	assert.NoError(t, tm.SetWithOptions(1, 2, time.Hour))
+	assert.ErrorIs(t, tm.SetWithOptions(1, 3, time.Hour), ErrRateLimited)
+
+	tm.Remove(1)
+	assert.NoError(t, tm.SetWithOptions(1, 4, time.Hour))
+}
+
+func TestWriteRateLimitReconfigure(t *testing.T) {
+	tm := NewWithOptions(0, WithWriteRateLimit(1, time.Hour))
+
+	tm.Set(1, 1, time.Hour)
+	assert.ErrorIs(t, tm.SetWithOptions(1, 2, time.Hour), ErrRateLimited)
+
+	assert.NoError(t, tm.Reconfigure(WithWriteRateLimit(0, 0)))
+	assert.NoError(t, tm.SetWithOptions(1, 2, time.Hour))
+}
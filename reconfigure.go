@@ -0,0 +1,107 @@
+package timedmap
+
+// Reconfigure applies the given options to the map
+// at runtime.
+//
+// The options WithValidator, WithMaxKeySize,
+// WithMaxValueSize, WithSlowCallbackHandler,
+// WithGracePeriod, WithTombstones, WithWriteRateLimit,
+// WithTTLRules, WithCleanupStrategy, WithTTLPolicy,
+// WithCleanerObserver, WithEvictionHandler,
+// WithEvictionPolicy, WithMaxSize, WithMaxCost and
+// WithAsyncCallbacks can be changed at runtime. When
+// any other option is passed, ErrNotReconfigurable is
+// returned and the map is not changed. Limits only
+// apply to values set after the reconfiguration, except
+// for the maximum size and cost, which evict pairs until
+// the map does not exceed them. A CostFunc passed to
+// WithMaxCost recomputes the cost of all stored pairs.
+//
+// Changing the number of callback workers executes the
+// callbacks queued before the change first. Callbacks
+// can not be made synchronous again once they are
+// executed asynchronously, so WithAsyncCallbacks with 0
+// workers returns ErrNotReconfigurable in that case.
+func (tm *TimedMap) Reconfigure(opts ...Option) error {
+	var changed options
+	for _, opt := range opts {
+		opt(&changed)
+	}
+	if changed.hasStructural() {
+		return ErrNotReconfigurable
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	next := tm.opts
+	for _, opt := range opts {
+		opt(&next)
+	}
+	if next.callbackWorkers <= 0 && tm.dispatcher != nil {
+		return ErrNotReconfigurable
+	}
+
+	tm.opts.validator = next.validator
+	tm.opts.maxKeySize = next.maxKeySize
+	tm.opts.maxValueSize = next.maxValueSize
+	tm.opts.valueSize = next.valueSize
+	tm.opts.slowCallbackThreshold = next.slowCallbackThreshold
+	tm.opts.slowCallbackHandler = next.slowCallbackHandler
+	tm.opts.grace = next.grace
+	tm.opts.tombstone = next.tombstone
+	tm.opts.writeLimit = next.writeLimit
+	tm.opts.writeWindow = next.writeWindow
+	tm.opts.ttlRules = next.ttlRules
+	tm.opts.cleanupStrategy = next.cleanupStrategy
+	tm.opts.ttlPolicy = next.ttlPolicy
+	tm.opts.cleanerObserver = next.cleanerObserver
+	tm.opts.evictionHandler = next.evictionHandler
+	tm.opts.evictionPolicy = next.evictionPolicy
+	tm.opts.maxSize = next.maxSize
+	tm.opts.maxCost = next.maxCost
+
+	next.resolveCost()
+	recharge := changed.maxCostFunc != nil ||
+		tm.opts.costFunc == nil && next.costFunc != nil
+	tm.opts.maxCostFunc = next.maxCostFunc
+	tm.opts.costFunc = next.costFunc
+	if recharge {
+		tm.container.each(func(k keyWrap, v *element) bool {
+			tm.charge(k, v)
+			return true
+		})
+	}
+
+	if tm.opts.maxSize > 0 || tm.opts.maxCost > 0 {
+		tm.enableEviction()
+		tm.evictOverflow(nil)
+	}
+
+	tm.opts.callbackWorkers = next.callbackWorkers
+	if tm.opts.callbackWorkers > 0 {
+		tm.resizeDispatcher(tm.opts.callbackWorkers)
+	}
+
+	return nil
+}
+
+// hasStructural returns true if any option is set
+// which can only be set on construction.
+func (o *options) hasStructural() bool {
+	return o.backend != nil || o.preciseExpiry ||
+		o.timerWindow != 0 || o.maxTimers != 0 ||
+		o.bloomExpectedKeys != 0 || o.bloomFalsePositiveRate != 0 ||
+		o.keyNormalizer != nil || o.copyOnRead != nil ||
+		o.binSize != 0 || o.binRetention != 0 ||
+		o.auditSize != 0 || o.auditWriter != nil ||
+		o.feedSize != 0 || o.name != "" ||
+		o.costFunc != nil ||
+		o.readTransform != nil || o.writeTransform != nil ||
+		o.walPath != "" ||
+		o.historySize != 0 || o.historyRetention != 0
+}
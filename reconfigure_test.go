@@ -0,0 +1,41 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconfigure(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithMaxValueSize(4, nil))
+
+	assert.ErrorIs(t, tm.SetWithOptions(1, "hello", time.Hour), ErrValueTooLarge)
+
+	assert.Nil(t, tm.Reconfigure(WithMaxValueSize(8, nil), WithValidator(intValidator)))
+	assert.ErrorIs(t, tm.SetWithOptions(1, "hello", time.Hour), ErrInvalidValue)
+	assert.Nil(t, tm.SetWithOptions(1, 1, time.Hour))
+
+	assert.Nil(t, tm.Reconfigure(WithValidator(nil)))
+	assert.Nil(t, tm.SetWithOptions(1, "hello", time.Hour))
+	assert.ErrorIs(t, tm.SetWithOptions(1, "hello world", time.Hour), ErrValueTooLarge)
+}
+
+func TestReconfigureNotReconfigurable(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, opt := range []Option{
+		WithBackend(NewHeapBackend()),
+		WithBloomFilter(100, 0.01),
+		WithKeyNormalizer(lowerKey),
+		WithCostFunc(byteCost),
+		WithWAL("wal"),
+		WithHistory(3, time.Hour),
+	} {
+		assert.ErrorIs(t, tm.Reconfigure(WithMaxKeySize(1), opt), ErrNotReconfigurable)
+	}
+	assert.Nil(t, tm.SetWithOptions("abc", 1, time.Hour))
+
+	tm.Close()
+	assert.ErrorIs(t, tm.Reconfigure(WithMaxKeySize(1)), ErrClosed)
+}
@@ -0,0 +1,128 @@
+package timedmap
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Recover restores the freshest state of the map from a
+// snapshot written by SaveTo and a write-ahead log written
+// using WithWAL, which holds the changes since the snapshot
+// has been taken, for example after a crash. Either of
+// them may be nil.
+//
+// The changes of the log are applied to the pairs of the
+// snapshot, and only the pairs which are live afterwards
+// are set like using LoadFrom. Pairs which have been
+// removed by the log, have expired or are rejected by the
+// map are discarded. The numbers of recovered and
+// discarded pairs are added to Stats.
+//
+// Both are read completely before the map is changed. If
+// the snapshot can not be read, a *SnapshotError is
+// returned and the map is not modified, while records
+// after a truncated or corrupted record of the log are
+// ignored, like when the log is replayed by WithWAL. If
+// pairs are rejected, the first error is returned.
+func (tm *TimedMap) Recover(snapshot io.Reader, wal io.Reader) error {
+	var records []walRecord
+	if snapshot != nil {
+		snap, err := readSnapshot(snapshot)
+		if err != nil {
+			return err
+		}
+		records = make([]walRecord, 0, len(snap))
+		for _, rec := range snap {
+			records = append(records, walRecord{
+				Op:      ChangeSet,
+				Section: rec.Section,
+				Key:     rec.Key,
+				Value:   rec.Value,
+				Meta:    rec.Meta,
+				Expires: rec.Expires,
+				Grace:   rec.Grace,
+				Sliding: rec.Sliding,
+			})
+		}
+	}
+	if wal != nil {
+		changes, err := readWALFrom(wal)
+		if err != nil {
+			return err
+		}
+		records = append(records, changes...)
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+	return tm.restoreChanges(records, time.Now())
+}
+
+// restoreChanges replays the changes of records on a plain
+// map first, so that only the final state of each key is
+// set at now. It counts the recovered pairs and the pairs
+// which have been set by records, but are not restored,
+// and returns the first error of a rejected pair. The
+// write lock of the map must be held.
+func (tm *TimedMap) restoreChanges(records []walRecord, now time.Time) error {
+	var order []keyWrap
+	state := make(map[keyWrap]walRecord)
+	seen := make(map[keyWrap]struct{})
+	for _, rec := range records {
+		k := keyWrap{sec: rec.Section, key: rec.Key}
+		switch rec.Op {
+		case ChangeSet:
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				order = append(order, k)
+			}
+			state[k] = rec
+		case ChangeExpiry:
+			if cur, ok := state[k]; ok {
+				cur.Expires = rec.Expires
+				state[k] = cur
+			}
+		case ChangeRemove, ChangeExpire:
+			delete(state, k)
+		case ChangeFlush:
+			for k := range state {
+				if rec.All || k.sec == rec.Section {
+					delete(state, k)
+				}
+			}
+		}
+	}
+
+	var recovered, discarded uint64
+	var rejected error
+	for _, k := range order {
+		rec, ok := state[k]
+		if !ok || !rec.Expires.IsZero() && !rec.Expires.Add(rec.Grace).After(now) {
+			discarded++
+			continue
+		}
+		err := tm.restoreLocked(k, now, tm.transformRead(k, rec.Value), rec.Expires, setOptions{
+			meta:     rec.Meta,
+			grace:    rec.Grace,
+			graceSet: true,
+			sliding:  rec.Sliding,
+		})
+		if err != nil {
+			if rejected == nil {
+				rejected = err
+			}
+			discarded++
+			continue
+		}
+		recovered++
+	}
+
+	atomic.AddUint64(&tm.stats.recovered, recovered)
+	atomic.AddUint64(&tm.stats.discarded, discarded)
+	return rejected
+}
@@ -0,0 +1,99 @@
+package timedmap
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	tm := NewWithOptions(0, WithWAL(path))
+	tm.Set(1, "a", time.Hour)
+	tm.Set(2, "b", time.Hour)
+	tm.Set(3, "c", time.Hour)
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&snapshot))
+	assert.NoError(t, tm.CompactWAL())
+
+	// changes after the snapshot are in the log
+	tm.Set(1, "aa", time.Hour)
+	tm.Remove(2)
+	tm.Set(4, "d", time.Hour)
+	tm.Set(5, "e", 5*time.Millisecond)
+	assert.NoError(t, tm.Close())
+	time.Sleep(10 * time.Millisecond)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	restored := New(0)
+	defer restored.Close()
+	assert.NoError(t, restored.Recover(&snapshot, bytes.NewReader(data)))
+	assert.Equal(t, map[interface{}]interface{}{1: "aa", 3: "c", 4: "d"}, restored.Snapshot())
+
+	// 2 has been removed and 5 has expired
+	st := restored.Stats()
+	assert.Equal(t, uint64(3), st.Recovered)
+	assert.Equal(t, uint64(2), st.Discarded)
+}
+
+func TestRecoverCompactedWAL(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	tm := NewWithOptions(0, WithWAL(path))
+	tm.Set("a", 1, time.Hour)
+	tm.Set("b", 2, time.Hour)
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&snapshot))
+	tm.Remove("a")
+	assert.NoError(t, tm.Close())
+
+	// reopening compacts the log
+	tm = NewWithOptions(0, WithWAL(path))
+	assert.NoError(t, tm.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	restored := New(0)
+	defer restored.Close()
+	assert.NoError(t, restored.Recover(&snapshot, bytes.NewReader(data)))
+	assert.Equal(t, map[interface{}]interface{}{"b": 2}, restored.Snapshot())
+}
+
+func TestRecoverPartial(t *testing.T) {
+	tm := New(0)
+	tm.Set(1, 1, time.Hour)
+	tm.Set(2, "b", time.Hour)
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, tm.SaveTo(&snapshot))
+	data := snapshot.Bytes()
+
+	// the log is optional and rejected
+	// pairs are discarded
+	restored := NewWithOptions(0, WithValidator(intValidator))
+	err := restored.Recover(bytes.NewReader(data), nil)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	assert.Equal(t, 1, restored.GetValue(1))
+	assert.Equal(t, uint64(1), restored.Stats().Discarded)
+
+	// invalid snapshots do not modify the map
+	restored = New(0)
+	err = restored.Recover(bytes.NewReader(data[:len(data)-4]), nil)
+	assert.ErrorIs(t, err, ErrSnapshotTruncated)
+	assert.Equal(t, 0, restored.Size())
+
+	assert.NoError(t, restored.Recover(nil, nil))
+	restored.Close()
+	assert.ErrorIs(t, restored.Recover(bytes.NewReader(data), nil), ErrClosed)
+}
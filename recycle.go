@@ -0,0 +1,171 @@
+package timedmap
+
+import (
+	"container/list"
+	"time"
+)
+
+// recycleBin holds the most recently expired or
+// removed key-value pairs of a map.
+type recycleBin struct {
+	size      int
+	retention time.Duration
+	order     *list.List
+	entries   map[keyWrap]*list.Element
+}
+
+// binEntry is a key-value pair kept in a recycle
+// bin. ttl is the duration between the last time
+// the pair was set and its expire time.
+type binEntry struct {
+	k       keyWrap
+	value   interface{}
+	ttl     time.Duration
+	grace   time.Duration
+	sliding time.Duration
+	rearm   RearmFunc
+	cbs     []callback
+	meta    interface{}
+	binned  time.Time
+}
+
+// newRecycleBin creates a new recycle bin holding up
+// to size pairs for the duration of retention.
+func newRecycleBin(size int, retention time.Duration) *recycleBin {
+	return &recycleBin{
+		size:      size,
+		retention: retention,
+		order:     list.New(),
+		entries:   make(map[keyWrap]*list.Element),
+	}
+}
+
+// add puts e into the bin, replacing an older entry
+// of the same key and dropping the oldest entry when
+// the bin is full.
+func (b *recycleBin) add(e *binEntry) {
+	if el, ok := b.entries[e.k]; ok {
+		b.order.Remove(el)
+	}
+	b.entries[e.k] = b.order.PushBack(e)
+
+	for b.order.Len() > b.size {
+		b.remove(b.order.Front())
+	}
+}
+
+// take removes the entry of k from the bin and
+// returns it, if it has not exceeded its retention
+// at now.
+func (b *recycleBin) take(k keyWrap, now time.Time) (*binEntry, bool) {
+	el, ok := b.entries[k]
+	if !ok {
+		return nil, false
+	}
+	b.remove(el)
+
+	e := el.Value.(*binEntry)
+	if now.Sub(e.binned) > b.retention {
+		return nil, false
+	}
+	return e, true
+}
+
+// sweep drops all entries which have exceeded
+// their retention at now.
+func (b *recycleBin) sweep(now time.Time) {
+	for el := b.order.Front(); el != nil; el = b.order.Front() {
+		if now.Sub(el.Value.(*binEntry).binned) <= b.retention {
+			return
+		}
+		b.remove(el)
+	}
+}
+
+// remove drops the entry el from the bin.
+func (b *recycleBin) remove(el *list.Element) {
+	b.order.Remove(el)
+	delete(b.entries, el.Value.(*binEntry).k)
+}
+
+// recycle puts the element v of k into the recycle
+// bin, if the map has one. The write lock of the map
+// must be held.
+func (tm *TimedMap) recycle(k keyWrap, v *element, now time.Time) {
+	if tm.bin == nil {
+		return
+	}
+
+	ttl := NoExpiration
+	if v.expired {
+		ttl = v.expires.Sub(v.updated)
+	}
+	tm.bin.add(&binEntry{
+		k:       k,
+		value:   v.value,
+		ttl:     ttl,
+		grace:   v.grace,
+		sliding: v.sliding,
+		rearm:   v.rearm,
+		cbs:     v.cbs,
+		meta:    v.meta,
+		binned:  now,
+	})
+}
+
+// Restore brings back a key-value pair which expired
+// or has been removed within the retention of the
+// recycle bin configured using WithRecycleBin.
+//
+// The pair is restored with its value, callbacks and
+// metadata and expires after the same duration it was
+// last set with, measured from now. If the key is
+// present in the map, ErrKeyExists is returned. If the
+// pair is not in the recycle bin, ErrKeyNotFound is
+// returned.
+func (tm *TimedMap) Restore(key interface{}) error {
+	return tm.restore(key, 0)
+}
+
+func (s *section) Restore(key interface{}) error {
+	return s.tm.restore(key, s.sec)
+}
+
+// restore brings back the given key in the
+// given section from the recycle bin.
+func (tm *TimedMap) restore(key interface{}, sec int) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+	if tm.bin == nil {
+		return ErrKeyNotFound
+	}
+
+	now := time.Now()
+	if tm.containsLocked(k, now) {
+		return ErrKeyExists
+	}
+
+	e, ok := tm.bin.take(k, now)
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	so := setOptions{
+		cbs:       e.cbs,
+		meta:      e.meta,
+		grace:     e.grace,
+		graceSet:  true,
+		resurrect: true,
+		sliding:   e.sliding,
+		rearm:     e.rearm,
+	}
+	tm.checkTombstone(k, now, so)
+	tm.store(k, now, e.value, e.ttl, so)
+	return nil
+}
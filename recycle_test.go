@@ -0,0 +1,98 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestore(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithRecycleBin(10, time.Hour))
+
+	var expired int
+	assert.NoError(t, tm.SetWithOptions(1, 1, 5*time.Millisecond,
+		WithCallback(func(interface{}) { expired++ }), WithMeta("m")))
+	tm.Set(2, 2, time.Hour)
+	tm.Remove(2)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, tm.Contains(1))
+	assert.Equal(t, 1, expired)
+
+	assert.NoError(t, tm.Restore(1))
+	assert.NoError(t, tm.Restore(2))
+	assert.ErrorIs(t, tm.Restore(2), ErrKeyExists)
+	assert.ErrorIs(t, tm.Restore(3), ErrKeyNotFound)
+
+	e, err := tm.GetEntry(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, e.Value)
+	assert.Equal(t, "m", e.Meta)
+
+	exp, err := tm.GetExpires(2)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Second)
+
+	// the pair expires again after its
+	// original lifetime with its callbacks
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, tm.Contains(1))
+	assert.Equal(t, 2, expired)
+}
+
+func TestRestoreSections(t *testing.T) {
+	tm := NewWithOptions(0, WithRecycleBin(10, time.Hour))
+
+	s := tm.Section(1)
+	s.Set(1, 1, NoExpiration)
+	s.Remove(1)
+
+	assert.ErrorIs(t, tm.Restore(1), ErrKeyNotFound)
+	assert.NoError(t, s.Restore(1))
+	assert.Equal(t, 1, s.GetValue(1))
+
+	exp, err := s.GetExpires(1)
+	assert.NoError(t, err)
+	assert.True(t, exp.IsZero())
+}
+
+func TestRecycleBinBounds(t *testing.T) {
+	tm := NewWithOptions(0, WithRecycleBin(2, 20*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		tm.Set(i, i, time.Hour)
+		tm.Remove(i)
+	}
+	assert.ErrorIs(t, tm.Restore(0), ErrKeyNotFound)
+	assert.NoError(t, tm.Restore(1))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.ErrorIs(t, tm.Restore(2), ErrKeyNotFound)
+
+	tm.Set(3, 3, time.Hour)
+	tm.Remove(3)
+	time.Sleep(30 * time.Millisecond)
+	tm.cleanUp()
+	assert.Equal(t, 0, tm.bin.order.Len())
+	assert.Empty(t, tm.bin.entries)
+}
+
+func TestRestoreWithoutBin(t *testing.T) {
+	tm := New(0)
+
+	tm.Set(1, 1, time.Hour)
+	tm.Remove(1)
+	assert.ErrorIs(t, tm.Restore(1), ErrKeyNotFound)
+	assert.ErrorIs(t, tm.Reconfigure(WithRecycleBin(1, time.Hour)), ErrNotReconfigurable)
+}
+
+func TestRestoreTombstone(t *testing.T) {
+	tm := NewWithOptions(0, WithRecycleBin(1, time.Hour), WithTombstones(time.Hour))
+
+	tm.Set(1, 1, time.Hour)
+	tm.Remove(1)
+	assert.NoError(t, tm.Restore(1))
+	assert.Equal(t, 1, tm.GetValue(1))
+	assert.Empty(t, tm.tombstones)
+}
@@ -0,0 +1,87 @@
+package timedmap
+
+import "time"
+
+// Retain increments the reference count of a key-value
+// pair. While a pair holds references, it does not
+// expire, even if its expire time has passed, so that
+// handles to resources which are still in use are kept
+// in the map. Remove, Flush and BumpGeneration still
+// remove retained pairs.
+//
+// The reference count is kept when the value of the
+// key is set again. If there is no value to the key
+// passed, ErrKeyNotFound is returned.
+func (tm *TimedMap) Retain(key interface{}) error {
+	return tm.retain(key, 0)
+}
+
+// ReleaseRef decrements the reference count of a
+// key-value pair incremented by Retain. When it drops
+// to zero and the expire time of the pair has passed,
+// the pair is expired immediately and its callbacks
+// are executed. If the pair holds no references,
+// ErrNotRetained is returned.
+func (tm *TimedMap) ReleaseRef(key interface{}) error {
+	return tm.releaseRef(key, 0)
+}
+
+func (s *section) Retain(key interface{}) error {
+	return s.tm.retain(key, s.sec)
+}
+
+func (s *section) ReleaseRef(key interface{}) error {
+	return s.tm.releaseRef(key, s.sec)
+}
+
+// retain increments the reference count of
+// the given key in the given section.
+func (tm *TimedMap) retain(key interface{}, sec int) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, time.Now()) {
+		return ErrKeyNotFound
+	}
+	v.refs++
+	return nil
+}
+
+// releaseRef decrements the reference count of
+// the given key in the given section.
+func (tm *TimedMap) releaseRef(key interface{}, sec int) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if v.refs == 0 {
+		return ErrNotRetained
+	}
+	v.refs--
+	if v.refs > 0 {
+		return nil
+	}
+
+	// the cleanup loop has dropped the pair from
+	// the index if it expired while it was retained
+	if tm.isExpired(v, time.Now()) {
+		tm.expireElement(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetain(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	var expired int
+	tm.Set(1, 1, 5*time.Millisecond, func(interface{}) {
+		expired++
+	})
+	assert.NoError(t, tm.Retain(1))
+	assert.NoError(t, tm.Retain(1))
+	assert.ErrorIs(t, tm.Retain(2), ErrKeyNotFound)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, tm.Contains(1))
+
+	assert.NoError(t, tm.ReleaseRef(1))
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, tm.Contains(1))
+
+	assert.NoError(t, tm.ReleaseRef(1))
+	assert.False(t, tm.Contains(1))
+	assert.Equal(t, 1, expired)
+	assert.ErrorIs(t, tm.ReleaseRef(1), ErrKeyNotFound)
+}
+
+func TestReleaseRefBackends(t *testing.T) {
+	for name, b := range map[string]Backend{
+		"heap":  NewHeapBackend(),
+		"wheel": NewWheelBackend(dCleanupTick, 16),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tm := NewWithOptions(dCleanupTick, WithBackend(b))
+
+			tm.Set(1, 1, 5*time.Millisecond)
+			tm.Set(2, 2, 5*time.Millisecond)
+			assert.NoError(t, tm.Retain(1))
+			assert.NoError(t, tm.Retain(2))
+
+			time.Sleep(30 * time.Millisecond)
+			assert.Equal(t, 2, tm.Size())
+
+			assert.NoError(t, tm.ReleaseRef(1))
+			assert.Equal(t, 1, tm.Size())
+
+			assert.NoError(t, tm.SetExpires(2, 20*time.Millisecond))
+			assert.NoError(t, tm.ReleaseRef(2))
+			assert.True(t, tm.Contains(2))
+
+			time.Sleep(50 * time.Millisecond)
+			assert.Equal(t, 0, tm.Size())
+		})
+	}
+}
+
+func TestReleaseRefNotRetained(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	tm.Set(1, 1, time.Hour)
+	assert.ErrorIs(t, tm.ReleaseRef(1), ErrNotRetained)
+
+	s := tm.Section(1)
+	s.Set(1, 1, time.Hour)
+	assert.NoError(t, s.Retain(1))
+	assert.ErrorIs(t, tm.ReleaseRef(1), ErrNotRetained)
+	assert.NoError(t, s.ReleaseRef(1))
+}
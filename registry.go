@@ -0,0 +1,79 @@
+package timedmap
+
+import (
+	"sort"
+	"sync"
+)
+
+// registry holds all named maps
+// which have not been closed.
+var registry = struct {
+	mtx  sync.Mutex
+	maps map[*TimedMap]struct{}
+}{
+	maps: make(map[*TimedMap]struct{}),
+}
+
+// Name returns the name of the map set
+// using WithName, or an empty string.
+func (tm *TimedMap) Name() string {
+	return tm.opts.name
+}
+
+// RegisteredMaps returns all maps created with
+// WithName which have not been closed, ordered by
+// name, so that every cache of the process can be
+// exposed, for example by an admin API or metrics.
+func RegisteredMaps() []*TimedMap {
+	registry.mtx.Lock()
+	maps := make([]*TimedMap, 0, len(registry.maps))
+	for tm := range registry.maps {
+		maps = append(maps, tm)
+	}
+	registry.mtx.Unlock()
+
+	sort.SliceStable(maps, func(i, j int) bool {
+		return maps[i].Name() < maps[j].Name()
+	})
+	return maps
+}
+
+// RegisteredStats returns the statistics of
+// all registered maps by name. The statistics
+// of maps sharing a name are summed up.
+func RegisteredStats() map[string]Stats {
+	res := make(map[string]Stats)
+	for _, tm := range RegisteredMaps() {
+		st := tm.Stats()
+		sum := res[tm.Name()]
+		sum.Size += st.Size
+		sum.Callbacks += st.Callbacks
+		sum.SlowCallbacks += st.SlowCallbacks
+		sum.SkippedTicks += st.SkippedTicks
+		sum.Cost += st.Cost
+		sum.EvictedCost += st.EvictedCost
+		sum.Evictions += st.Evictions
+		sum.Recovered += st.Recovered
+		sum.Discarded += st.Discarded
+		res[tm.Name()] = sum
+	}
+	return res
+}
+
+// register adds tm to the registry
+// if it has a name.
+func register(tm *TimedMap) {
+	if tm.opts.name == "" {
+		return
+	}
+	registry.mtx.Lock()
+	registry.maps[tm] = struct{}{}
+	registry.mtx.Unlock()
+}
+
+// unregister removes tm from the registry.
+func unregister(tm *TimedMap) {
+	registry.mtx.Lock()
+	delete(registry.maps, tm)
+	registry.mtx.Unlock()
+}
@@ -0,0 +1,96 @@
+package timedmap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	users := NewWithOptions(0, WithName("users"))
+	sessions := NewWithOptions(0, WithName("sessions"))
+	sessions2 := NewWithOptions(0, WithName("sessions"))
+	unnamed := New(0)
+
+	assert.Equal(t, "users", users.Name())
+	assert.Equal(t, "", unnamed.Name())
+
+	maps := RegisteredMaps()
+	assert.Len(t, maps, 3)
+	assert.Equal(t, "sessions", maps[0].Name())
+	assert.Equal(t, "sessions", maps[1].Name())
+	assert.Same(t, users, maps[2])
+
+	users.Set(1, 1, time.Hour)
+	sessions.Set(1, 1, time.Hour)
+	sessions2.Set(1, 1, time.Hour)
+	sessions.stats.cost, sessions2.stats.cost = 3, 4
+	sessions.stats.evictedCost, sessions2.stats.evictedCost = 5, 6
+
+	st := RegisteredStats()
+	assert.Len(t, st, 2)
+	assert.Equal(t, 1, st["users"].Size)
+	assert.Equal(t, 2, st["sessions"].Size)
+	assert.Equal(t, int64(7), st["sessions"].Cost)
+	assert.Equal(t, int64(11), st["sessions"].EvictedCost)
+
+	assert.NoError(t, sessions.Close())
+	assert.NoError(t, sessions2.Close())
+	assert.Equal(t, []*TimedMap{users}, RegisteredMaps())
+
+	assert.NoError(t, users.Close())
+	assert.Empty(t, RegisteredMaps())
+
+	assert.ErrorIs(t, unnamed.Reconfigure(WithName("x")), ErrNotReconfigurable)
+}
+
+func TestRegisteredStatsSumsAllFields(t *testing.T) {
+	a := NewWithOptions(0, WithName("sum"))
+	defer a.Close()
+	b := NewWithOptions(0, WithName("sum"))
+	defer b.Close()
+
+	for i, tm := range []*TimedMap{a, b} {
+		n := uint64(i + 1)
+		tm.Set(1, 1, time.Hour)
+		tm.stats = statsCounters{
+			callbacks:     n,
+			slowCallbacks: n * 10,
+			skippedTicks:  n * 100,
+			cost:          int64(n * 1000),
+			evictedCost:   int64(n * 10000),
+			evictions:     n * 100000,
+			recovered:     n * 1000000,
+			discarded:     n * 10000000,
+		}
+	}
+
+	want := sumStats(a.Stats(), b.Stats())
+	got := RegisteredStats()["sum"]
+	assert.Equal(t, want, got)
+
+	// every field must be set, so that a field
+	// missing in the sum fails the test
+	rv := reflect.ValueOf(got)
+	for i := 0; i < rv.NumField(); i++ {
+		assert.False(t, rv.Field(i).IsZero(), rv.Type().Field(i).Name)
+	}
+}
+
+// sumStats adds all fields of a and b.
+func sumStats(a, b Stats) (sum Stats) {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	sv := reflect.ValueOf(&sum).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		f := sv.Field(i)
+		switch f.Kind() {
+		case reflect.Int, reflect.Int64:
+			f.SetInt(av.Field(i).Int() + bv.Field(i).Int())
+		case reflect.Uint64:
+			f.SetUint(av.Field(i).Uint() + bv.Field(i).Uint())
+		}
+	}
+	return
+}
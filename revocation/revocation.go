@@ -0,0 +1,63 @@
+// Package revocation provides a list of revoked token
+// IDs (for example the "jti" claim of JWTs) which are
+// kept in a timedmap until the tokens would have
+// expired naturally.
+package revocation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// List holds revoked token IDs until the expiry
+// time of the corresponding tokens.
+type List struct {
+	mtx   sync.Mutex
+	cache timedmap.Section
+}
+
+// New creates a new revocation List storing the
+// revoked token IDs in the given cache section.
+func New(cache timedmap.Section) *List {
+	return &List{
+		cache: cache,
+	}
+}
+
+// Revoke adds the token ID jti to the list until
+// expiresAt, which should be the expiry time of the
+// token. Tokens which have already expired are not
+// added because they are invalid anyway.
+//
+// When jti is already revoked, the later one of both
+// expiry times is kept. The entry expires at expiresAt
+// on the wall clock, like the token.
+func (l *List) Revoke(jti string, expiresAt time.Time) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if cur, ok := l.cache.GetValue(jti).(time.Time); ok && cur.After(expiresAt) {
+		return
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+	l.cache.Set(jti, expiresAt, ttl)
+	l.cache.SetExpireAt(jti, expiresAt)
+}
+
+// IsRevoked returns true if the token ID jti has
+// been revoked and did not expire yet.
+func (l *List) IsRevoked(jti string) bool {
+	return l.cache.Contains(jti)
+}
+
+// Size returns the number of currently revoked
+// token IDs.
+func (l *List) Size() int {
+	return l.cache.Size()
+}
@@ -0,0 +1,46 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevoke(t *testing.T) {
+	l := New(timedmap.New(10 * time.Millisecond))
+
+	l.Revoke("a", time.Now().Add(30*time.Millisecond))
+	l.Revoke("b", time.Now().Add(-time.Second))
+
+	assert.True(t, l.IsRevoked("a"))
+	assert.False(t, l.IsRevoked("b"))
+	assert.False(t, l.IsRevoked("c"))
+	assert.EqualValues(t, 1, l.Size())
+
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, l.IsRevoked("a"))
+}
+
+func TestRevokeKeepsLaterExpiry(t *testing.T) {
+	l := New(timedmap.New(10 * time.Millisecond))
+
+	l.Revoke("a", time.Now().Add(50*time.Millisecond))
+	l.Revoke("a", time.Now().Add(10*time.Millisecond))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, l.IsRevoked("a"))
+}
+
+func TestRevokeExpiresAtTokenExpiry(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+	l := New(tm)
+
+	expiresAt := time.Now().Add(time.Hour)
+	l.Revoke("a", expiresAt)
+
+	exp, err := tm.GetExpires("a")
+	assert.NoError(t, err)
+	assert.Equal(t, expiresAt.Round(0), exp)
+}
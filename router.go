@@ -0,0 +1,425 @@
+package timedmap
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultRouterReplicas is the number of points each
+// map is placed on the hash ring of a Router when no
+// other number is passed to NewRouter.
+const DefaultRouterReplicas = 100
+
+// Router partitions keys across multiple maps using
+// consistent hashing, so capacity and cleanup load
+// can be spread across several TimedMap instances.
+// Adding or removing a map only moves the keys of
+// about 1/n of the key space.
+//
+// Router implements Section, so it can be used in
+// place of a single map. Operations on single keys
+// are passed to the map the key is routed to, while
+// Flush, Size, Stats and Snapshot cover all maps.
+// Keys are routed before they reach the maps, so
+// maps passed to a Router should not use a key
+// normalizer.
+type Router struct {
+	maps []Section
+	ring []ringNode
+
+	// none is the map all keys are routed to if
+	// the Router has no maps.
+	none Section
+}
+
+// ringNode is a point on the hash ring of a Router,
+// which belongs to the map at index idx.
+type ringNode struct {
+	hash uint64
+	idx  int
+}
+
+// NewRouter creates a new Router partitioning keys
+// across maps. Each map is placed replicas times on
+// the hash ring; if replicas is <= 0,
+// DefaultRouterReplicas is used.
+//
+// A Router without maps routes all keys to a closed
+// map, so it behaves like a map passed to Close:
+// operations which return an error return ErrClosed,
+// all other operations are no-ops.
+func NewRouter(replicas int, maps ...Section) *Router {
+	if replicas <= 0 {
+		replicas = DefaultRouterReplicas
+	}
+
+	r := &Router{
+		maps: maps,
+		ring: make([]ringNode, 0, replicas*len(maps)),
+	}
+	if len(maps) == 0 {
+		none := New(0)
+		none.Close()
+		r.none = none
+	}
+
+	for i := range maps {
+		for j := 0; j < replicas; j++ {
+			h := fnv.New64a()
+			h.Write(strconv.AppendInt(nil, int64(i), 10))
+			h.Write([]byte{'-'})
+			h.Write(strconv.AppendInt(nil, int64(j), 10))
+			r.ring = append(r.ring, ringNode{hash: mix64(h.Sum64()), idx: i})
+		}
+	}
+	sort.Slice(r.ring, func(i, j int) bool {
+		return r.ring[i].hash < r.ring[j].hash
+	})
+
+	return r
+}
+
+// MapFor returns the map the given key is routed to.
+func (r *Router) MapFor(key interface{}) Section {
+	return r.at(r.index(key))
+}
+
+// at returns the map at index i, or the closed
+// map if the Router has no maps.
+func (r *Router) at(i int) Section {
+	if i < 0 {
+		return r.none
+	}
+	return r.maps[i]
+}
+
+// index returns the index of the map the given
+// key is routed to, or -1 if the Router has no
+// maps.
+func (r *Router) index(key interface{}) int {
+	if len(r.ring) == 0 {
+		return -1
+	}
+	h, _ := hashKey(keyWrap{key: key})
+	h = mix64(h)
+	i := sort.Search(len(r.ring), func(i int) bool {
+		return r.ring[i].hash >= h
+	})
+	if i == len(r.ring) {
+		i = 0
+	}
+	return r.ring[i].idx
+}
+
+// mix64 spreads the bits of the FNV hash h, whose
+// upper bits are poorly distributed for short inputs,
+// across the whole ring.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// group returns the passed keys grouped by the
+// index of the map they are routed to.
+func (r *Router) group(keys []interface{}) map[int][]interface{} {
+	groups := make(map[int][]interface{})
+	for _, key := range keys {
+		i := r.index(key)
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// Ident always returns 0 for a Router.
+func (r *Router) Ident() int {
+	return 0
+}
+
+func (r *Router) Set(key, value interface{}, expiresAfter time.Duration, cb ...callback) {
+	r.MapFor(key).Set(key, value, expiresAfter, cb...)
+}
+
+func (r *Router) SetGet(key, value interface{}, expiresAfter time.Duration) (prev interface{}, replaced bool) {
+	return r.MapFor(key).SetGet(key, value, expiresAfter)
+}
+
+func (r *Router) GetValue(key interface{}) interface{} {
+	return r.MapFor(key).GetValue(key)
+}
+
+func (r *Router) Get(key interface{}) (value interface{}, ok bool) {
+	return r.MapFor(key).Get(key)
+}
+
+func (r *Router) GetExpires(key interface{}) (time.Time, error) {
+	return r.MapFor(key).GetExpires(key)
+}
+
+func (r *Router) GetExpiresMulti(keys ...interface{}) map[interface{}]time.Time {
+	m := make(map[interface{}]time.Time, len(keys))
+	for i, group := range r.group(keys) {
+		for k, exp := range r.at(i).GetExpiresMulti(group...) {
+			m[k] = exp
+		}
+	}
+	return m
+}
+
+func (r *Router) SetMulti(values map[interface{}]interface{}, expiresAfter time.Duration) {
+	groups := make(map[int]map[interface{}]interface{})
+	for key, value := range values {
+		i := r.index(key)
+		if groups[i] == nil {
+			groups[i] = make(map[interface{}]interface{})
+		}
+		groups[i][key] = value
+	}
+	for i, group := range groups {
+		r.at(i).SetMulti(group, expiresAfter)
+	}
+}
+
+func (r *Router) GetMulti(keys ...interface{}) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, len(keys))
+	for i, group := range r.group(keys) {
+		for k, v := range r.at(i).GetMulti(group...) {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+func (r *Router) RemoveMulti(keys ...interface{}) {
+	for i, group := range r.group(keys) {
+		r.at(i).RemoveMulti(group...)
+	}
+}
+
+func (r *Router) SetExpires(key interface{}, d time.Duration) error {
+	return r.MapFor(key).SetExpires(key, d)
+}
+
+func (r *Router) SetExpireAt(key interface{}, at time.Time) error {
+	return r.MapFor(key).SetExpireAt(key, at)
+}
+
+func (r *Router) Contains(key interface{}) bool {
+	return r.MapFor(key).Contains(key)
+}
+
+func (r *Router) ContainsAll(keys ...interface{}) bool {
+	for i, group := range r.group(keys) {
+		if !r.at(i).ContainsAll(group...) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Router) ContainsAny(keys ...interface{}) bool {
+	for i, group := range r.group(keys) {
+		if r.at(i).ContainsAny(group...) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) Remove(key interface{}) {
+	r.MapFor(key).Remove(key)
+}
+
+func (r *Router) Pop(key interface{}) (value interface{}, ok bool) {
+	return r.MapFor(key).Pop(key)
+}
+
+func (r *Router) RemoveAfterConfirm(key interface{}, confirm func(value interface{}) bool) bool {
+	return r.MapFor(key).RemoveAfterConfirm(key, confirm)
+}
+
+func (r *Router) ExtendExpire(key interface{}, d time.Duration, onlyIfLater bool) error {
+	return r.MapFor(key).ExtendExpire(key, d, onlyIfLater)
+}
+
+func (r *Router) ShortenExpire(key interface{}, d time.Duration, onlyIfEarlier bool) error {
+	return r.MapFor(key).ShortenExpire(key, d, onlyIfEarlier)
+}
+
+func (r *Router) Refresh(key interface{}, d time.Duration) error {
+	return r.MapFor(key).Refresh(key, d)
+}
+
+func (r *Router) TryRefresh(key interface{}, d time.Duration) bool {
+	return r.MapFor(key).TryRefresh(key, d)
+}
+
+func (r *Router) Retain(key interface{}) error {
+	return r.MapFor(key).Retain(key)
+}
+
+func (r *Router) ReleaseRef(key interface{}) error {
+	return r.MapFor(key).ReleaseRef(key)
+}
+
+func (r *Router) Restore(key interface{}) error {
+	return r.MapFor(key).Restore(key)
+}
+
+// Flush deletes all key-value pairs of all maps.
+func (r *Router) Flush() {
+	for _, m := range r.maps {
+		m.Flush()
+	}
+}
+
+// Size returns the sum of the sizes of all maps.
+func (r *Router) Size() (i int) {
+	for _, m := range r.maps {
+		i += m.Size()
+	}
+	return
+}
+
+// Stats returns the sum of the statistics of all maps.
+func (r *Router) Stats() (st Stats) {
+	for _, m := range r.maps {
+		mst := m.Stats()
+		st.Size += mst.Size
+		st.Callbacks += mst.Callbacks
+		st.SlowCallbacks += mst.SlowCallbacks
+		st.SkippedTicks += mst.SkippedTicks
+		st.Cost += mst.Cost
+		st.EvictedCost += mst.EvictedCost
+		st.Evictions += mst.Evictions
+		st.Recovered += mst.Recovered
+		st.Discarded += mst.Discarded
+	}
+	return
+}
+
+// Snapshot returns a new map containing the
+// key-value pairs of all maps.
+func (r *Router) Snapshot() map[interface{}]interface{} {
+	s := make(map[interface{}]interface{})
+	for _, m := range r.maps {
+		for k, v := range m.Snapshot() {
+			s[k] = v
+		}
+	}
+	return s
+}
+
+// ForEach calls fn for each live key-value pair
+// of all maps until fn returns false.
+func (r *Router) ForEach(fn func(key, value interface{}) bool) {
+	for _, m := range r.maps {
+		cont := true
+		m.ForEach(func(key, value interface{}) bool {
+			cont = fn(key, value)
+			return cont
+		})
+		if !cont {
+			return
+		}
+	}
+}
+
+// Keys returns the keys of all live
+// key-value pairs of all maps.
+func (r *Router) Keys() (keys []interface{}) {
+	for _, m := range r.maps {
+		keys = append(keys, m.Keys()...)
+	}
+	return
+}
+
+// Values returns the values of all live
+// key-value pairs of all maps.
+func (r *Router) Values() (values []interface{}) {
+	for _, m := range r.maps {
+		values = append(values, m.Values()...)
+	}
+	return
+}
+
+// ShardCount returns the sum of the
+// shard counts of all maps.
+func (r *Router) ShardCount() (n int) {
+	for _, m := range r.maps {
+		n += m.ShardCount()
+	}
+	return
+}
+
+// RangeShard calls fn for each live key-value pair of
+// the given shard, where the shards of all maps are
+// numbered consecutively.
+func (r *Router) RangeShard(shard int, fn func(key, value interface{}) bool) {
+	for _, m := range r.maps {
+		n := m.ShardCount()
+		if shard < n {
+			m.RangeShard(shard, fn)
+			return
+		}
+		shard -= n
+	}
+}
+
+func (r *Router) GetCtx(ctx context.Context, key interface{}) (interface{}, error) {
+	return r.MapFor(key).GetCtx(ctx, key)
+}
+
+func (r *Router) SetCtx(ctx context.Context, key, value interface{}, expiresAfter time.Duration, cb ...callback) error {
+	return r.MapFor(key).SetCtx(ctx, key, value, expiresAfter, cb...)
+}
+
+func (r *Router) RemoveCtx(ctx context.Context, key interface{}) error {
+	return r.MapFor(key).RemoveCtx(ctx, key)
+}
+
+func (r *Router) GetEntry(key interface{}) (Entry, error) {
+	return r.MapFor(key).GetEntry(key)
+}
+
+func (r *Router) GetOrCompute(key interface{}, fn ComputeFunc) (interface{}, error) {
+	return r.MapFor(key).GetOrCompute(key, fn)
+}
+
+func (r *Router) GetElseSet(key interface{}, ttl time.Duration, fn func() interface{}) interface{} {
+	return r.MapFor(key).GetElseSet(key, ttl, fn)
+}
+
+func (r *Router) Add(key, value interface{}, ttl time.Duration) error {
+	return r.MapFor(key).Add(key, value, ttl)
+}
+
+func (r *Router) Update(key interface{}, fn UpdateFunc) error {
+	return r.MapFor(key).Update(key, fn)
+}
+
+func (r *Router) GetOrSet(key, value interface{}, ttl time.Duration) (actual interface{}, loaded bool) {
+	return r.MapFor(key).GetOrSet(key, value, ttl)
+}
+
+func (r *Router) MarkStale(key interface{}) error {
+	return r.MapFor(key).MarkStale(key)
+}
+
+func (r *Router) GetStale(key interface{}) (value interface{}, stale, ok bool) {
+	return r.MapFor(key).GetStale(key)
+}
+
+func (r *Router) History(key interface{}) []Revision {
+	return r.MapFor(key).History(key)
+}
+
+func (r *Router) SetWithOptions(key, value interface{}, expiresAfter time.Duration, opts ...SetOption) error {
+	return r.MapFor(key).SetWithOptions(key, value, expiresAfter, opts...)
+}
@@ -0,0 +1,87 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRouterMaps(n int) []Section {
+	maps := make([]Section, n)
+	for i := range maps {
+		maps[i] = New(dCleanupTick)
+	}
+	return maps
+}
+
+func TestRouter(t *testing.T) {
+	maps := testRouterMaps(3)
+	var s Section = NewRouter(0, maps...)
+
+	for i := 0; i < 300; i++ {
+		s.Set(i, i, time.Hour)
+	}
+
+	assert.Equal(t, 300, s.Size())
+	assert.Equal(t, 300, s.Stats().Size)
+	assert.Len(t, s.Snapshot(), 300)
+	for _, m := range maps {
+		assert.InDelta(t, 100, m.Size(), 50)
+	}
+
+	r := s.(*Router)
+	for i := 0; i < 300; i++ {
+		assert.EqualValues(t, i, s.GetValue(i))
+		assert.EqualValues(t, i, r.MapFor(i).GetValue(i))
+	}
+
+	assert.True(t, s.ContainsAll(1, 2, 3, 4, 5))
+	assert.False(t, s.ContainsAll(1, 2, 1000))
+	assert.True(t, s.ContainsAny(1000, 5))
+	assert.Len(t, s.GetExpiresMulti(1, 2, 3, 1000), 3)
+
+	s.Remove(1)
+	assert.False(t, s.Contains(1))
+
+	s.SetMulti(map[interface{}]interface{}{1000: 1, 1001: 2}, time.Hour)
+	assert.Equal(t, map[interface{}]interface{}{1000: 1, 1001: 2}, s.GetMulti(1000, 1001, 1002))
+	s.RemoveMulti(1000, 1001)
+	assert.Empty(t, s.GetMulti(1000, 1001))
+
+	s.Flush()
+	assert.Equal(t, 0, s.Size())
+}
+
+func TestRouterConsistent(t *testing.T) {
+	maps := testRouterMaps(5)
+	r4 := NewRouter(0, maps[:4]...)
+	r5 := NewRouter(0, maps...)
+
+	const n = 1000
+	moved := 0
+	for i := 0; i < n; i++ {
+		if r4.index(i) != r5.index(i) {
+			moved++
+			assert.Equal(t, 4, r5.index(i))
+		}
+	}
+	assert.Less(t, moved, n*2/5)
+	assert.Greater(t, moved, 0)
+}
+
+func TestRouterWithoutMaps(t *testing.T) {
+	var s Section = NewRouter(0)
+
+	s.Set(1, 1, time.Hour)
+	s.SetMulti(map[interface{}]interface{}{2: 2}, time.Hour)
+	assert.Nil(t, s.GetValue(1))
+	assert.False(t, s.Contains(1))
+	assert.Empty(t, s.GetMulti(1, 2))
+	assert.False(t, s.ContainsAny(1, 2))
+	assert.ErrorIs(t, s.SetExpires(1, time.Hour), ErrClosed)
+	assert.ErrorIs(t, s.Add(1, 1, time.Hour), ErrClosed)
+	s.Remove(1)
+	assert.Equal(t, 0, s.Size())
+	assert.Equal(t, 0, s.ShardCount())
+}
@@ -1,6 +1,7 @@
 package timedmap
 
 import (
+	"context"
 	"time"
 )
 
@@ -13,37 +14,135 @@ type Section interface {
 
 	// Set appends a key-value pair to the map or sets the value of
 	// a key. expiresAfter sets the expire time after the key-value pair
-	// will automatically be removed from the map.
+	// will automatically be removed from the map. Pass NoExpiration
+	// to store a key-value pair which never expires, or
+	// DefaultExpiration to apply the rules set by WithTTLRules.
 	Set(key, value interface{}, expiresAfter time.Duration, cb ...callback)
 
+	// SetGet sets the value of a key like Set and returns the
+	// previous value of the key. replaced is false if the key
+	// was not present in the map or the value was expired.
+	SetGet(key, value interface{}, expiresAfter time.Duration) (prev interface{}, replaced bool)
+
+	// Add sets the value of a key like Set only if there
+	// is no live value to the key. Otherwise, ErrKeyExists
+	// is returned and the map is not modified.
+	Add(key, value interface{}, ttl time.Duration) error
+
+	// Update sets the value of a key to the value returned
+	// by fn, which is called with the current value while
+	// holding the lock of the map.
+	Update(key interface{}, fn UpdateFunc) error
+
 	// GetValue returns an interface of the value of a key in the
 	// map. The returned value is nil if there is no value to the
 	// passed key or if the value was expired.
 	GetValue(key interface{}) interface{}
 
+	// Get returns the value of a key in the map. ok is false
+	// if there is no value to the passed key or if the value
+	// was expired, so stored nil values can be distinguished
+	// from missing ones.
+	Get(key interface{}) (value interface{}, ok bool)
+
 	// GetExpires returns the expire time of a key-value pair.
 	// If the key-value pair does not exist in the map or
 	// was expired, this will return an error object.
 	GetExpires(key interface{}) (time.Time, error)
 
+	// GetExpiresMulti returns the expire times of all passed
+	// keys which exist in the map and have not expired. Keys
+	// of key-value pairs which never expire are mapped to
+	// the zero time.
+	GetExpiresMulti(keys ...interface{}) map[interface{}]time.Time
+
+	// SetMulti sets the values of all keys of values like
+	// Set while holding the lock of the map once.
+	SetMulti(values map[interface{}]interface{}, expiresAfter time.Duration)
+
+	// GetMulti returns the values of all passed keys which
+	// exist in the map and have not expired.
+	GetMulti(keys ...interface{}) map[interface{}]interface{}
+
+	// RemoveMulti deletes the key-value pairs of all
+	// passed keys while holding the lock of the map once.
+	RemoveMulti(keys ...interface{})
+
 	// SetExpires sets the expire time for a key-value
-	// pair to the passed duration. If there is no value
+	// pair to the passed duration from now, or removes it
+	// when NoExpiration is passed. If there is no value
 	// to the key passed , this will return an error.
 	SetExpires(key interface{}, d time.Duration) error
 
+	// SetExpireAt sets the expire time for a key-value
+	// pair to the wall clock time at. If there is no value
+	// to the key passed, this will return an error.
+	SetExpireAt(key interface{}, at time.Time) error
+
 	// Contains returns true, if the key exists in the map.
 	// false will be returned, if there is no value to the
 	// key or if the key-value pair was expired.
 	Contains(key interface{}) bool
 
+	// ContainsAll returns true, if all of the passed
+	// keys exist in the map and have not expired.
+	ContainsAll(keys ...interface{}) bool
+
+	// ContainsAny returns true, if at least one of the
+	// passed keys exists in the map and has not expired.
+	ContainsAny(keys ...interface{}) bool
+
 	// Remove deletes a key-value pair in the map.
 	Remove(key interface{})
 
+	// Pop deletes a key-value pair in the map and returns
+	// its value and true in one step. ok is false if there
+	// is no value to the passed key or if the value was
+	// expired.
+	Pop(key interface{}) (value interface{}, ok bool)
+
+	// RemoveAfterConfirm deletes a key-value pair in the map
+	// only if confirm returns true for its value, which is
+	// called while holding the lock of the map. It returns
+	// true if the pair has been removed.
+	RemoveAfterConfirm(key interface{}, confirm func(value interface{}) bool) bool
+
+	// ExtendExpire sets the expire time for a key-value
+	// pair to the passed duration from now like SetExpires.
+	// If onlyIfLater is true, the expire time is only
+	// changed if the new one is later than the current one.
+	ExtendExpire(key interface{}, d time.Duration, onlyIfLater bool) error
+
+	// ShortenExpire sets the expire time for a key-value
+	// pair to the passed duration from now like SetExpires.
+	// If onlyIfEarlier is true, the expire time is only
+	// changed if the new one is earlier than the current one.
+	ShortenExpire(key interface{}, d time.Duration, onlyIfEarlier bool) error
+
 	// Refresh extends the expire time for a key-value pair
 	// about the passed duration. If there is no value to
 	// the key passed, this will return an error.
 	Refresh(key interface{}, d time.Duration) error
 
+	// TryRefresh extends the expire time for a key-value pair
+	// about the passed duration like Refresh and returns true.
+	// If there is no value to the key passed, false is returned.
+	TryRefresh(key interface{}, d time.Duration) bool
+
+	// Retain increments the reference count of a key-value
+	// pair. Pairs with references do not expire. If there is
+	// no value to the key passed, this will return an error.
+	Retain(key interface{}) error
+
+	// ReleaseRef decrements the reference count of a key-value
+	// pair. When it drops to zero, the pair expires as usual.
+	ReleaseRef(key interface{}) error
+
+	// Restore brings back a key-value pair which expired or
+	// has been removed from the recycle bin of the map. If
+	// the pair is not in the bin, this will return an error.
+	Restore(key interface{}) error
+
 	// Flush deletes all key-value pairs of the section
 	// in the map.
 	Flush()
@@ -52,9 +151,97 @@ type Section interface {
 	// existent in the section of the map.
 	Size() (i int)
 
+	// Stats returns a snapshot of the runtime statistics
+	// of the section. For the root TimedMap, these are the
+	// statistics of the whole map.
+	Stats() Stats
+
 	// Snapshot returns a new map which represents the
-	// current key-value state of the internal container.
+	// current key-value state of the internal container,
+	// excluding key-value pairs which have expired.
 	Snapshot() map[interface{}]interface{}
+
+	// ForEach calls fn for each key-value pair of the
+	// section which has not expired until fn returns
+	// false.
+	ForEach(fn func(key, value interface{}) bool)
+
+	// Keys returns the keys of all key-value pairs
+	// of the section which have not expired.
+	Keys() []interface{}
+
+	// Values returns the values of all key-value pairs
+	// of the section which have not expired.
+	Values() []interface{}
+
+	// ShardCount returns the number of shards which
+	// can be iterated using RangeShard.
+	ShardCount() int
+
+	// RangeShard calls fn for each key-value pair of
+	// the given shard of the section which has not
+	// expired until fn returns false. Each key belongs
+	// to exactly one shard.
+	RangeShard(shard int, fn func(key, value interface{}) bool)
+
+	// GetCtx returns the value of a key in the map like
+	// GetValue. If there is no value to the passed key or
+	// if the value was expired, ErrKeyNotFound is returned.
+	// If ctx is done before the value could be obtained,
+	// the error of ctx is returned.
+	GetCtx(ctx context.Context, key interface{}) (interface{}, error)
+
+	// SetCtx sets the value of a key like Set. If ctx is
+	// done before the value could be set, the error of ctx
+	// is returned and the map is not modified.
+	SetCtx(ctx context.Context, key, value interface{}, expiresAfter time.Duration, cb ...callback) error
+
+	// RemoveCtx deletes a key-value pair like Remove. If
+	// ctx is done before the pair could be removed, the
+	// error of ctx is returned and the map is not modified.
+	RemoveCtx(ctx context.Context, key interface{}) error
+
+	// GetEntry returns the key-value pair of key together
+	// with its metadata. If there is no value to the passed
+	// key or if the value was expired, ErrKeyNotFound is
+	// returned.
+	GetEntry(key interface{}) (Entry, error)
+
+	// GetOrCompute returns the value of a key or, if there
+	// is no value to the key, stores and returns the value
+	// computed by fn, which expires after the duration
+	// returned by fn along with it.
+	GetOrCompute(key interface{}, fn ComputeFunc) (interface{}, error)
+
+	// GetElseSet returns the value of a key or, if there is
+	// no value to the key, stores and returns the value
+	// returned by fn, which expires after ttl.
+	GetElseSet(key interface{}, ttl time.Duration, fn func() interface{}) interface{}
+
+	// GetOrSet returns the value of a key and true or, if
+	// there is no value to the key, atomically stores value,
+	// which expires after ttl, and returns it and false.
+	GetOrSet(key, value interface{}, ttl time.Duration) (actual interface{}, loaded bool)
+
+	// MarkStale flags the key-value pair of key as stale
+	// without removing it. The flag is reported by
+	// GetEntry and GetStale and cleared when the value
+	// of the key is set again.
+	MarkStale(key interface{}) error
+
+	// GetStale returns the value of a key like Get,
+	// together with whether the pair is stale.
+	GetStale(key interface{}) (value interface{}, stale, ok bool)
+
+	// History returns the previous values of a key
+	// retained using WithHistory, starting with the
+	// oldest one.
+	History(key interface{}) []Revision
+
+	// SetWithOptions sets the value of a key like Set,
+	// configured with the given set options. Unlike Set,
+	// it returns an error if the value could not be set.
+	SetWithOptions(key, value interface{}, expiresAfter time.Duration, opts ...SetOption) error
 }
 
 // section wraps access to a specific
@@ -82,26 +269,45 @@ func (s *section) Set(key, value interface{}, expiresAfter time.Duration, cb ...
 	s.tm.set(key, s.sec, value, expiresAfter, cb...)
 }
 
+func (s *section) SetGet(key, value interface{}, expiresAfter time.Duration) (prev interface{}, replaced bool) {
+	k := s.tm.wrapKey(key, s.sec)
+	prev, replaced, _ = s.tm.swap(k, value, expiresAfter, setOptions{})
+	if replaced {
+		prev = s.tm.transformRead(k, prev)
+	}
+	return
+}
+
 func (s *section) GetValue(key interface{}) interface{} {
-	v := s.tm.get(key, s.sec)
+	k := s.tm.wrapKey(key, s.sec)
+	v := s.tm.lookup(k)
 	if v == nil {
 		return nil
 	}
-	return v.value
+	return s.tm.readValue(k, v.value)
 }
 
-func (s *section) GetExpires(key interface{}) (time.Time, error) {
-	v := s.tm.get(key, s.sec)
+func (s *section) Get(key interface{}) (value interface{}, ok bool) {
+	k := s.tm.wrapKey(key, s.sec)
+	v := s.tm.lookup(k)
 	if v == nil {
-		return time.Time{}, ErrKeyNotFound
+		return nil, false
 	}
-	return v.expires, nil
+	return s.tm.readValue(k, v.value), true
+}
+
+func (s *section) GetExpires(key interface{}) (time.Time, error) {
+	return s.tm.getExpires(key, s.sec)
 }
 
 func (s *section) SetExpires(key interface{}, d time.Duration) error {
 	return s.tm.setExpires(key, s.sec, d)
 }
 
+func (s *section) SetExpireAt(key interface{}, at time.Time) error {
+	return s.tm.setExpireAt(key, s.sec, at)
+}
+
 func (s *section) Contains(key interface{}) bool {
 	return s.tm.get(key, s.sec) != nil
 }
@@ -114,21 +320,16 @@ func (s *section) Refresh(key interface{}, d time.Duration) error {
 	return s.tm.refresh(key, s.sec, d)
 }
 
+func (s *section) TryRefresh(key interface{}, d time.Duration) bool {
+	return s.tm.refresh(key, s.sec, d) == nil
+}
+
 func (s *section) Flush() {
-	for k := range s.tm.container {
-		if k.sec == s.sec {
-			s.tm.remove(k.key, k.sec)
-		}
-	}
+	s.tm.flushSection(s.sec)
 }
 
 func (s *section) Size() (i int) {
-	for k := range s.tm.container {
-		if k.sec == s.sec {
-			i++
-		}
-	}
-	return
+	return s.tm.size(s.sec)
 }
 
 func (s *section) Snapshot() map[interface{}]interface{} {
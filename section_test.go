@@ -20,13 +20,13 @@ func TestSectionFlush(t *testing.T) {
 		tm.set(i, 2, 1, time.Hour)
 	}
 	tm.Section(2).Flush()
-	assert.EqualValues(t, 15, len(tm.container))
+	assert.EqualValues(t, 15, tm.container.len())
 
 	tm.Section(1).Flush()
-	assert.EqualValues(t, 5, len(tm.container))
+	assert.EqualValues(t, 5, tm.container.len())
 
 	tm.Section(0).Flush()
-	assert.EqualValues(t, 0, len(tm.container))
+	assert.EqualValues(t, 0, tm.container.len())
 }
 
 func TestSectionIdent(t *testing.T) {
@@ -53,6 +53,48 @@ func TestSectionSet(t *testing.T) {
 	assert.Nil(t, tm.get(key, sec))
 }
 
+func TestSectionSetGet(t *testing.T) {
+	tm := New(dCleanupTick)
+	s := tm.Section(1)
+
+	prev, replaced := s.SetGet("a", 1, 20*time.Millisecond)
+	assert.False(t, replaced)
+	assert.Nil(t, prev)
+
+	prev, replaced = s.SetGet("a", 2, 20*time.Millisecond)
+	assert.True(t, replaced)
+	assert.EqualValues(t, 1, prev)
+	assert.EqualValues(t, 2, s.GetValue("a"))
+
+	time.Sleep(50 * time.Millisecond)
+	prev, replaced = s.SetGet("a", 3, time.Hour)
+	assert.False(t, replaced)
+	assert.Nil(t, prev)
+}
+
+func TestSectionGet(t *testing.T) {
+	tm := New(dCleanupTick)
+	s := tm.Section(1)
+
+	s.Set("nil", nil, time.Hour)
+	s.Set("exp", 1, 10*time.Millisecond)
+
+	v, ok := s.Get("nil")
+	assert.True(t, ok)
+	assert.Nil(t, v)
+
+	v, ok = s.Get("exp")
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, v)
+
+	_, ok = s.Get("missing")
+	assert.False(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok = s.Get("exp")
+	assert.False(t, ok)
+}
+
 func TestSectionGetValue(t *testing.T) {
 	const key = "tKeyGetVal"
 	const val = "tValGetVal"
@@ -172,6 +214,27 @@ func TestSectionRefresh(t *testing.T) {
 	assert.Nil(t, tm.get(key, sec))
 }
 
+func TestSectionTryRefresh(t *testing.T) {
+	const key = "tKeyRef"
+
+	const sec = 1
+
+	tm := New(dCleanupTick)
+	s := tm.Section(sec)
+
+	assert.False(t, s.TryRefresh("keyNotExists", time.Hour))
+
+	s.Set(key, 1, 12*time.Millisecond)
+	assert.True(t, s.TryRefresh(key, 50*time.Millisecond))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NotNil(t, tm.get(key, sec))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, tm.get(key, sec))
+	assert.False(t, s.TryRefresh(key, time.Hour))
+}
+
 func TestSectionSize(t *testing.T) {
 	tm := New(dCleanupTick)
 
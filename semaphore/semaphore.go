@@ -0,0 +1,135 @@
+// Package semaphore provides keyed semaphores stored
+// in a timedmap, whose permits are returned
+// automatically after their ttl, so that permits of
+// crashed workers do not leak.
+package semaphore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// Semaphore limits the number of permits held
+// concurrently per key.
+//
+// The permits of a key are stored as a single value in
+// the section, which expires together with the last
+// permit, so keys which are not used anymore do not
+// occupy any memory.
+type Semaphore struct {
+	cache timedmap.Section
+	limit int
+	mtx   sync.Mutex
+}
+
+// permit is a single permit of a key, which
+// expires at expires, or never if it is zero.
+type permit struct {
+	expires time.Time
+}
+
+// New creates a new Semaphore storing permits in the
+// given cache section, which hands out at most limit
+// permits per key at a time. The section should
+// not be used for other data.
+func New(cache timedmap.Section, limit int) *Semaphore {
+	return &Semaphore{
+		cache: cache,
+		limit: limit,
+	}
+}
+
+// TryAcquire acquires a permit of key if less than
+// the limit of permits are held, which is returned
+// automatically after ttl. On success, it returns a
+// function returning the permit and true. Calling the
+// returned function more than once or after the
+// permit expired has no effect.
+func (s *Semaphore) TryAcquire(key interface{}, ttl time.Duration) (release func(), ok bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	ps := s.held(key, now)
+	if len(ps) >= s.limit {
+		return nil, false
+	}
+
+	p := new(permit)
+	if ttl != timedmap.NoExpiration {
+		p.expires = now.Add(ttl)
+	}
+	s.store(key, append(ps, p), now)
+
+	return func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		now := time.Now()
+		ps := s.held(key, now)
+		for i, q := range ps {
+			if q == p {
+				ps = append(ps[:i], ps[i+1:]...)
+				s.store(key, ps, now)
+				return
+			}
+		}
+	}, true
+}
+
+// Held returns the number of permits
+// of key which are currently held.
+func (s *Semaphore) Held(key interface{}) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return len(s.held(key, time.Now()))
+}
+
+// held returns a copy of the permits of key which have
+// not expired at now. The lock of the semaphore must
+// be held.
+func (s *Semaphore) held(key interface{}, now time.Time) []*permit {
+	v, ok := s.cache.Get(key)
+	if !ok {
+		return nil
+	}
+	ps, _ := v.([]*permit)
+	kept := make([]*permit, 0, len(ps)+1)
+	for _, p := range ps {
+		if p.live(now) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// store stores the permits ps of key, which expire with
+// the last of them, or removes key if none of them is
+// live at now. The lock of the semaphore must be held.
+func (s *Semaphore) store(key interface{}, ps []*permit, now time.Time) {
+	var last time.Time
+	for _, p := range ps {
+		if p.expires.IsZero() {
+			s.cache.Set(key, ps, timedmap.NoExpiration)
+			return
+		}
+		if p.expires.After(last) {
+			last = p.expires
+		}
+	}
+
+	if !last.After(now) {
+		s.cache.Remove(key)
+		return
+	}
+	s.cache.Set(key, ps, last.Sub(now))
+}
+
+// live returns true if the permit
+// has not expired at now.
+func (p *permit) live(now time.Time) bool {
+	return p.expires.IsZero() || p.expires.After(now)
+}
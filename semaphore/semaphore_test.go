@@ -0,0 +1,95 @@
+package semaphore
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryAcquire(t *testing.T) {
+	s := New(timedmap.New(time.Minute), 2)
+
+	r1, ok := s.TryAcquire("a", time.Hour)
+	assert.True(t, ok)
+	_, ok = s.TryAcquire("a", time.Hour)
+	assert.True(t, ok)
+	_, ok = s.TryAcquire("a", time.Hour)
+	assert.False(t, ok)
+	assert.Equal(t, 2, s.Held("a"))
+
+	_, ok = s.TryAcquire("b", time.Hour)
+	assert.True(t, ok)
+
+	r1()
+	assert.Equal(t, 1, s.Held("a"))
+	_, ok = s.TryAcquire("a", time.Hour)
+	assert.True(t, ok)
+
+	// releasing twice must not return another permit
+	r1()
+	assert.Equal(t, 2, s.Held("a"))
+}
+
+func TestTryAcquireExpires(t *testing.T) {
+	tm := timedmap.New(0)
+	s := New(tm, 1)
+
+	release, ok := s.TryAcquire("a", 10*time.Millisecond)
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, s.Held("a"))
+
+	// keys which are never queried again are removed
+	// from the section with their last permit
+	_, ok = s.TryAcquire("b", 5*time.Millisecond)
+	assert.True(t, ok)
+	time.Sleep(10 * time.Millisecond)
+	tm.Cleanup()
+	assert.Equal(t, 0, tm.Size())
+
+	_, ok = s.TryAcquire("a", time.Hour)
+	assert.True(t, ok)
+	release()
+	assert.Equal(t, 1, s.Held("a"))
+}
+
+func TestTryAcquireConcurrent(t *testing.T) {
+	s := New(timedmap.New(time.Minute), 3)
+
+	var held int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := s.TryAcquire("a", time.Hour); ok {
+				atomic.AddInt32(&held, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 3, held)
+}
+
+func TestTryAcquireNoExpiration(t *testing.T) {
+	tm := timedmap.New(0)
+	s := New(tm, 2)
+
+	release, ok := s.TryAcquire("a", timedmap.NoExpiration)
+	assert.True(t, ok)
+	_, ok = s.TryAcquire("a", 5*time.Millisecond)
+	assert.True(t, ok)
+
+	time.Sleep(10 * time.Millisecond)
+	tm.Cleanup()
+	assert.Equal(t, 1, s.Held("a"))
+
+	release()
+	assert.Equal(t, 0, s.Held("a"))
+	assert.Equal(t, 0, tm.Size())
+}
@@ -0,0 +1,96 @@
+package timedmap
+
+import "time"
+
+// Set is a membership-only container whose members
+// expire like the key-value pairs of a TimedMap.
+type Set struct {
+	tm *TimedMap
+}
+
+// NewSet creates a new Set with the given cleanup
+// tick time like New.
+func NewSet(cleanupTickTime time.Duration, tickerChan ...<-chan time.Time) *Set {
+	return &Set{
+		tm: New(cleanupTickTime, tickerChan...),
+	}
+}
+
+// Add adds member to the set or sets its expire time
+// if it is already a member. The member will be
+// removed from the set after expiresAfter. Pass
+// NoExpiration to add a member which never expires.
+func (s *Set) Add(member interface{}, expiresAfter time.Duration) {
+	s.tm.set(member, 0, struct{}{}, expiresAfter)
+}
+
+// Contains returns true if member is in the set
+// and has not expired.
+func (s *Set) Contains(member interface{}) bool {
+	return s.tm.get(member, 0) != nil
+}
+
+// Remove removes member from the set.
+func (s *Set) Remove(member interface{}) {
+	s.tm.remove(member, 0)
+}
+
+// Len returns the number of members of the
+// set which have not expired.
+func (s *Set) Len() (n int) {
+	now := time.Now()
+
+	s.tm.mtx.RLock()
+	defer s.tm.mtx.RUnlock()
+
+	s.tm.container.each(func(_ keyWrap, v *element) bool {
+		if !s.tm.isExpired(v, now) {
+			n++
+		}
+		return true
+	})
+
+	return
+}
+
+// Range calls fn for each member of the set which
+// has not expired until fn returns false. The members
+// are collected before fn is called, so fn may modify
+// the set.
+func (s *Set) Range(fn func(member interface{}) bool) {
+	for _, member := range s.members() {
+		if !fn(member) {
+			return
+		}
+	}
+}
+
+// Flush removes all members from the set.
+func (s *Set) Flush() {
+	s.tm.Flush()
+}
+
+// Close stops the cleanup loop of the set and
+// removes all of its members like TimedMap.Close.
+func (s *Set) Close() error {
+	return s.tm.Close()
+}
+
+// members returns the members of the
+// set which have not expired.
+func (s *Set) members() []interface{} {
+	now := time.Now()
+
+	s.tm.mtx.RLock()
+	defer s.tm.mtx.RUnlock()
+
+	members := make([]interface{}, 0, s.tm.container.len())
+	s.tm.container.each(func(k keyWrap, v *element) bool {
+		if !s.tm.isExpired(v, now) {
+			members = append(members, k.key)
+		}
+		return true
+	})
+
+	return members
+}
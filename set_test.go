@@ -0,0 +1,59 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMembers(t *testing.T) {
+	s := NewSet(dCleanupTick)
+
+	s.Add("a", time.Hour)
+	s.Add("b", NoExpiration)
+	s.Add("c", 5*time.Millisecond)
+
+	assert.True(t, s.Contains("a"))
+	assert.True(t, s.Contains("b"))
+	assert.False(t, s.Contains("d"))
+	assert.Equal(t, 3, s.Len())
+
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, s.Contains("c"))
+	assert.Equal(t, 2, s.Len())
+
+	s.Remove("a")
+	assert.False(t, s.Contains("a"))
+	assert.Equal(t, 1, s.Len())
+
+	s.Flush()
+	assert.Equal(t, 0, s.Len())
+	assert.NoError(t, s.Close())
+}
+
+func TestSetRange(t *testing.T) {
+	s := NewSet(0)
+
+	s.Add(1, time.Hour)
+	s.Add(2, time.Hour)
+	s.Add(3, -time.Second)
+
+	members := map[interface{}]bool{}
+	s.Range(func(member interface{}) bool {
+		members[member] = true
+		s.Remove(member)
+		return true
+	})
+	assert.Equal(t, map[interface{}]bool{1: true, 2: true}, members)
+	assert.Equal(t, 0, s.Len())
+
+	s.Add(1, time.Hour)
+	s.Add(2, time.Hour)
+	n := 0
+	s.Range(func(interface{}) bool {
+		n++
+		return false
+	})
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,111 @@
+package timedmap
+
+import "time"
+
+// SetOption configures how a single key-value
+// pair is set using SetWithOptions.
+type SetOption func(so *setOptions)
+
+// setOptions contains the configuration
+// of a single set operation.
+type setOptions struct {
+	cbs       []callback
+	meta      interface{}
+	grace     time.Duration
+	graceSet  bool
+	resurrect bool
+	actor     string
+	sliding   time.Duration
+	rearm     RearmFunc
+	deadline  time.Time
+}
+
+// WithCallback registers the given callbacks, which
+// are executed when the key-value pair expires.
+func WithCallback(cb ...func(value interface{})) SetOption {
+	return func(so *setOptions) {
+		for _, c := range cb {
+			so.cbs = append(so.cbs, c)
+		}
+	}
+}
+
+// WithRearm registers fn, which is executed before the
+// other callbacks when the key-value pair expires. If fn
+// returns a duration above zero, the pair is re-armed to
+// expire after that duration instead of being removed,
+// and the other callbacks are not executed.
+func WithRearm(fn RearmFunc) SetOption {
+	return func(so *setOptions) {
+		so.rearm = fn
+	}
+}
+
+// WithMeta attaches arbitrary metadata to the key-value
+// pair, like tracing IDs, source attribution or
+// invalidation hints. The metadata can be read
+// using GetEntry.
+func WithMeta(meta interface{}) SetOption {
+	return func(so *setOptions) {
+		so.meta = meta
+	}
+}
+
+// WithGrace sets the grace period of the key-value
+// pair, overriding the one set by WithGracePeriod.
+func WithGrace(d time.Duration) SetOption {
+	return func(so *setOptions) {
+		so.grace = d
+		so.graceSet = true
+	}
+}
+
+// WithResurrect sets the key-value pair even if the
+// key has a tombstone left by Remove, and removes
+// the tombstone.
+func WithResurrect() SetOption {
+	return func(so *setOptions) {
+		so.resurrect = true
+	}
+}
+
+// WithActor sets the actor which is recorded
+// in the audit log for the set operation.
+func WithActor(actor string) SetOption {
+	return func(so *setOptions) {
+		so.actor = actor
+	}
+}
+
+// SetWithOptions sets the value of a key like Set,
+// configured with the given set options. Unlike Set,
+// it returns an error if the value could not be set.
+//
+// Like callbacks, the metadata of an existing pair
+// is replaced, so it is removed when no metadata
+// is passed.
+func (tm *TimedMap) SetWithOptions(key, value interface{}, expiresAfter time.Duration, opts ...SetOption) error {
+	return tm.setWith(key, 0, value, expiresAfter, newSetOptions(opts))
+}
+
+func (s *section) SetWithOptions(key, value interface{}, expiresAfter time.Duration, opts ...SetOption) error {
+	return s.tm.setWith(key, s.sec, value, expiresAfter, newSetOptions(opts))
+}
+
+// newSetOptions applies opts to
+// new set options.
+func newSetOptions(opts []SetOption) (so setOptions) {
+	for _, opt := range opts {
+		opt(&so)
+	}
+	return
+}
+
+// graceOf returns the grace period of a key-value
+// pair set with so.
+func (tm *TimedMap) graceOf(so setOptions) time.Duration {
+	if so.graceSet {
+		return so.grace
+	}
+	return tm.opts.grace
+}
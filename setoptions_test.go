@@ -0,0 +1,37 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetWithOptionsMeta(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		s.SetWithOptions("a", 1, time.Hour, WithMeta("trace-1"))
+
+		e, err := s.GetEntry("a")
+		assert.Nil(t, err)
+		assert.Equal(t, "trace-1", e.Meta)
+
+		s.SetWithOptions("a", 2, time.Hour)
+		e, err = s.GetEntry("a")
+		assert.Nil(t, err)
+		assert.Nil(t, e.Meta)
+	}
+}
+
+func TestSetWithOptionsCallback(t *testing.T) {
+	cb := new(CB)
+	cb.On("Cb").Return()
+
+	tm := New(dCleanupTick)
+	tm.SetWithOptions(1, 3, 5*time.Millisecond, WithCallback(cb.Cb))
+
+	time.Sleep(30 * time.Millisecond)
+	cb.AssertCalled(t, "Cb")
+	assert.EqualValues(t, 3, cb.TestData().Get("v").Int())
+}
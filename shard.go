@@ -0,0 +1,135 @@
+package timedmap
+
+import "time"
+
+// scanShards is the number of shards the keys of a
+// TimedMap are partitioned into for RangeShard.
+const scanShards = 16
+
+// ShardCount returns the number of shards which can be
+// iterated using RangeShard.
+func (tm *TimedMap) ShardCount() int {
+	return scanShards
+}
+
+func (s *section) ShardCount() int {
+	return scanShards
+}
+
+// RangeShard calls fn for each key-value pair of the
+// given shard which has not expired until fn returns
+// false, like ForEach. Each key belongs to exactly one
+// of the shards from 0 to ShardCount()-1, so a batch job
+// can process the map in parallel with one goroutine
+// per shard. Nothing is iterated for shards out of
+// this range.
+//
+// The first call partitions the keys of the map into
+// the shards, which are kept up to date from then on,
+// so each call only examines the keys of its shard.
+func (tm *TimedMap) RangeShard(shard int, fn func(key, value interface{}) bool) {
+	tm.rangeShard(0, shard, fn)
+}
+
+func (s *section) RangeShard(shard int, fn func(key, value interface{}) bool) {
+	s.tm.rangeShard(s.sec, shard, fn)
+}
+
+// rangeShard calls fn for each live key-value pair of
+// the given shard of the given section.
+func (tm *TimedMap) rangeShard(sec, shard int, fn func(key, value interface{}) bool) {
+	if shard < 0 || shard >= scanShards {
+		return
+	}
+	for _, p := range tm.shardPairs(sec, shard) {
+		if !fn(p.key, p.value) {
+			return
+		}
+	}
+}
+
+// shardPairs returns all live key-value pairs
+// of the given shard of the given section.
+func (tm *TimedMap) shardPairs(sec, shard int) []pair {
+	tm.indexShards()
+
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	now := time.Now()
+	var pairs []pair
+	for k, v := range tm.scan.shards[shard] {
+		if k.sec == sec && !tm.isExpired(v, now) {
+			pairs = append(pairs, pair{key: k.key, value: tm.readValue(k, v.value)})
+		}
+	}
+	return pairs
+}
+
+// indexShards wraps the backend of the map with a
+// shardIndex holding all stored keys, if the map has
+// none yet.
+func (tm *TimedMap) indexShards() {
+	tm.mtx.RLock()
+	indexed := tm.scan != nil
+	tm.mtx.RUnlock()
+	if indexed {
+		return
+	}
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.scan != nil {
+		return
+	}
+	scan := newShardIndex(tm.container)
+	tm.container.each(func(k keyWrap, v *element) bool {
+		scan.shards[shardOf(k, scanShards)][k] = v
+		return true
+	})
+	tm.scan = scan
+	tm.container = scan
+}
+
+// shardIndex wraps a Backend and partitions the
+// stored elements into the shards iterated by
+// RangeShard.
+type shardIndex struct {
+	Backend
+
+	shards [scanShards]map[keyWrap]*element
+}
+
+// newShardIndex wraps b with a shardIndex.
+func newShardIndex(b Backend) *shardIndex {
+	s := &shardIndex{Backend: b}
+	for i := range s.shards {
+		s.shards[i] = make(map[keyWrap]*element)
+	}
+	return s
+}
+
+func (s *shardIndex) put(k keyWrap, v *element) {
+	s.Backend.put(k, v)
+	s.shards[shardOf(k, scanShards)][k] = v
+}
+
+func (s *shardIndex) del(k keyWrap) {
+	s.Backend.del(k)
+	delete(s.shards[shardOf(k, scanShards)], k)
+}
+
+func (s *shardIndex) clear() {
+	s.Backend.clear()
+	for i := range s.shards {
+		s.shards[i] = make(map[keyWrap]*element)
+	}
+}
+
+// shardOf returns the shard out of n
+// the key k belongs to.
+func shardOf(k keyWrap, n int) int {
+	h, _ := hashKey(k)
+	return int(mix64(h) % uint64(n))
+}
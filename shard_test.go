@@ -0,0 +1,101 @@
+package timedmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRangeShards(t *testing.T, s Section, n int) {
+	var mtx sync.Mutex
+	seen := make(map[interface{}]int)
+
+	var wg sync.WaitGroup
+	for shard := 0; shard < s.ShardCount(); shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			s.RangeShard(shard, func(key, value interface{}) bool {
+				assert.Equal(t, key, value)
+				mtx.Lock()
+				seen[key]++
+				mtx.Unlock()
+				return true
+			})
+		}(shard)
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, n)
+	for _, c := range seen {
+		assert.Equal(t, 1, c)
+	}
+}
+
+func TestRangeShard(t *testing.T) {
+	tm := New(0)
+	for i := 0; i < 1000; i++ {
+		tm.Set(i, i, time.Hour)
+	}
+	tm.Set("expired", 0, -time.Second)
+	tm.Section(1).Set(0, 0, time.Hour)
+
+	assert.Equal(t, scanShards, tm.ShardCount())
+	testRangeShards(t, tm, 1000)
+	testRangeShards(t, tm.Section(1), 1)
+
+	var n int
+	for shard := 0; shard < tm.ShardCount(); shard++ {
+		tm.RangeShard(shard, func(key, value interface{}) bool {
+			n++
+			return false
+		})
+	}
+	assert.Equal(t, tm.ShardCount(), n)
+
+	tm.RangeShard(-1, func(key, value interface{}) bool {
+		t.Fatal("out of range shard iterated")
+		return true
+	})
+	tm.RangeShard(tm.ShardCount(), func(key, value interface{}) bool {
+		t.Fatal("out of range shard iterated")
+		return true
+	})
+}
+
+func TestRangeShardIndex(t *testing.T) {
+	tm := New(0)
+	for i := 0; i < 100; i++ {
+		tm.Set(i, i, time.Hour)
+	}
+	testRangeShards(t, tm, 100)
+
+	for i := 0; i < 50; i++ {
+		tm.Remove(i)
+	}
+	for i := 100; i < 120; i++ {
+		tm.Set(i, i, time.Hour)
+	}
+	testRangeShards(t, tm, 70)
+
+	var n int
+	for _, shard := range tm.scan.shards {
+		n += len(shard)
+	}
+	assert.Equal(t, tm.Size(), n)
+
+	tm.Flush()
+	testRangeShards(t, tm, 0)
+}
+
+func TestRouterRangeShard(t *testing.T) {
+	r := NewRouter(0, testRouterMaps(3)...)
+	for i := 0; i < 300; i++ {
+		r.Set(i, i, time.Hour)
+	}
+
+	assert.Equal(t, 3*scanShards, r.ShardCount())
+	testRangeShards(t, r, 300)
+}
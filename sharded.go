@@ -0,0 +1,107 @@
+package timedmap
+
+import (
+	"runtime"
+	"time"
+)
+
+// Sharded is a map partitioning its keys across
+// several TimedMap instances, each with its own lock
+// and cleanup loop, which reduces lock contention in
+// write-heavy workloads compared to a single map.
+//
+// Sharded implements Section like Router, on which
+// it is built, so it can be used in place of a single
+// map. Its shards are the underlying maps, so
+// RangeShard iterates a single map.
+type Sharded struct {
+	*Router
+	shards []*TimedMap
+}
+
+// NewSharded creates a new Sharded map with the given
+// number of shards, each created using NewWithOptions
+// with cleanupTickTime and opts. If shards is <= 0,
+// runtime.GOMAXPROCS(0) shards are created.
+//
+// As keys are routed before they reach the shards,
+// opts should not contain a key normalizer, and must
+// not contain WithWAL, as the shards would share the
+// same log.
+func NewSharded(cleanupTickTime time.Duration, shards int, opts ...Option) *Sharded {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+
+	s := &Sharded{shards: make([]*TimedMap, shards)}
+	maps := make([]Section, shards)
+	for i := range s.shards {
+		s.shards[i] = NewWithOptions(cleanupTickTime, opts...)
+		maps[i] = s.shards[i]
+	}
+	s.Router = NewRouter(0, maps...)
+	return s
+}
+
+// Shard returns the map of the shard i.
+func (s *Sharded) Shard(i int) *TimedMap {
+	return s.shards[i]
+}
+
+// ShardFor returns the map of the shard
+// the given key is routed to.
+func (s *Sharded) ShardFor(key interface{}) *TimedMap {
+	return s.shards[s.index(key)]
+}
+
+// ShardCount returns the number of shards.
+func (s *Sharded) ShardCount() int {
+	return len(s.shards)
+}
+
+// RangeShard calls fn for each live key-value pair
+// of the given shard until fn returns false. Nothing
+// is iterated for shards out of range.
+func (s *Sharded) RangeShard(shard int, fn func(key, value interface{}) bool) {
+	if shard < 0 || shard >= len(s.shards) {
+		return
+	}
+	s.shards[shard].ForEach(fn)
+}
+
+// Cleanup runs a cleanup cycle on all shards.
+func (s *Sharded) Cleanup() {
+	for _, tm := range s.shards {
+		tm.Cleanup()
+	}
+}
+
+// Barrier returns a channel which is closed once
+// the barriers of all shards are released, see
+// TimedMap.Barrier.
+func (s *Sharded) Barrier(before time.Time) <-chan struct{} {
+	barriers := make([]<-chan struct{}, len(s.shards))
+	for i, tm := range s.shards {
+		barriers[i] = tm.Barrier(before)
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		for _, b := range barriers {
+			<-b
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// Close closes all shards and returns
+// the first error, if any.
+func (s *Sharded) Close() (err error) {
+	for _, tm := range s.shards {
+		if cErr := tm.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return
+}
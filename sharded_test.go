@@ -0,0 +1,61 @@
+package timedmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharded(t *testing.T) {
+	s := NewSharded(0, 4)
+	var sec Section = s
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w * 100; i < (w+1)*100; i++ {
+				sec.Set(i, i, time.Hour)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 400, s.Size())
+	assert.Equal(t, 400, s.Stats().Size)
+	assert.Equal(t, 4, s.ShardCount())
+	for i := 0; i < 4; i++ {
+		assert.InDelta(t, 100, s.Shard(i).Size(), 60)
+	}
+	assert.Equal(t, 7, s.ShardFor(7).GetValue(7))
+	testRangeShards(t, s, 400)
+
+	s.Set("expired", 1, -time.Second)
+	assert.Equal(t, 401, s.Size())
+	b := s.Barrier(time.Now())
+	s.Cleanup()
+	assert.Equal(t, 400, s.Size())
+	select {
+	case <-b:
+	case <-time.After(time.Second):
+		t.Fatal("barrier not released")
+	}
+
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Close(), ErrClosed)
+}
+
+func TestShardedDefaultShards(t *testing.T) {
+	s := NewSharded(dCleanupTick, 0)
+	defer s.Close()
+
+	assert.Greater(t, s.ShardCount(), 0)
+	s.Set(1, "a", 5*time.Millisecond)
+	assert.Equal(t, "a", s.GetValue(1))
+	assert.Eventually(t, func() bool {
+		return s.Size() == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,438 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+// Package shm provides an experimental expiring key
+// space backed by a memory mapped file, which can be
+// shared by multiple processes on the same host (for
+// example pre-fork workers).
+//
+// The key space is a fixed size open addressing hash
+// table with string keys and byte slice values of a
+// maximum size, which is set on creation of the file.
+// Accesses are coordinated between processes using
+// flock(2) on the backing file. Expiry times are
+// stored as wall clock times, because monotonic clock
+// readings can not be shared between processes.
+package shm
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+var (
+	// ErrFull is returned when no free slot
+	// is left to store a new key.
+	ErrFull = timedmap.ErrFull
+	// ErrKeyTooLarge is returned when a key exceeds
+	// the maximum key size of the map.
+	ErrKeyTooLarge = timedmap.ErrKeyTooLarge
+	// ErrValueTooLarge is returned when a value exceeds
+	// the maximum value size of the map.
+	ErrValueTooLarge = timedmap.ErrValueTooLarge
+	// ErrClosed is returned when the map
+	// has been closed.
+	ErrClosed = timedmap.ErrClosed
+	// ErrLayoutMismatch is returned when an existing file
+	// was created with a different layout than requested.
+	ErrLayoutMismatch = errors.New("layout of existing file does not match")
+	// ErrInvalidFile is returned when the file is not
+	// a valid shared map file.
+	ErrInvalidFile = errors.New("invalid shared map file")
+	// ErrInvalidLayout is returned when the requested
+	// layout can not be stored in a shared map file.
+	ErrInvalidLayout = errors.New("invalid layout")
+)
+
+const (
+	magic      = "TMSHM001"
+	headerSize = 64
+
+	// slot header: state (1), padding (1),
+	// key length (2), value length (4),
+	// expires (8)
+	slotHeaderSize = 16
+
+	// never is the expire time stored for
+	// keys which never expire.
+	never = math.MaxInt64
+
+	// maxSize is the maximum size of a file
+	// which can be memory mapped.
+	maxSize = uint64(^uint(0) >> 1)
+)
+
+const (
+	slotEmpty byte = iota
+	slotUsed
+	slotDeleted
+)
+
+// Map is an expiring key space in a
+// memory mapped file.
+type Map struct {
+	mtx  sync.RWMutex
+	f    *os.File
+	data []byte
+
+	slots    uint32
+	maxKey   uint32
+	maxValue uint32
+	slotSize uint32
+}
+
+// Open opens the shared map backed by the file at path
+// or creates it if it does not exist. The map has a
+// capacity of slots keys with up to maxKey bytes per key
+// and maxValue bytes per value. slots must be greater
+// than 0, maxKey must not exceed 65535 and the file
+// must fit into the address space, otherwise
+// ErrInvalidLayout is returned.
+//
+// If the file already exists, its layout must match the
+// passed parameters.
+func Open(path string, slots, maxKey, maxValue uint32) (m *Map, err error) {
+	slotSize := uint64(slotHeaderSize) + uint64(maxKey) + uint64(maxValue)
+	if slots == 0 || maxKey > math.MaxUint16 || slotSize > math.MaxUint32 ||
+		slotSize > (maxSize-headerSize)/uint64(slots) {
+		err = ErrInvalidLayout
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+		}
+	}()
+
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	m = &Map{
+		f:        f,
+		slots:    slots,
+		maxKey:   maxKey,
+		maxValue: maxValue,
+		slotSize: uint32(slotSize),
+	}
+	size := int64(headerSize) + int64(slots)*int64(slotSize)
+
+	stat, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	created := stat.Size() == 0
+	if created {
+		if err = f.Truncate(size); err != nil {
+			return
+		}
+	} else if stat.Size() != size {
+		err = ErrLayoutMismatch
+		return
+	}
+
+	m.data, err = syscall.Mmap(int(f.Fd()), 0, int(size),
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		if created {
+			f.Truncate(0)
+		}
+		if err == syscall.ENOMEM || err == syscall.EINVAL {
+			err = ErrInvalidLayout
+		}
+		return
+	}
+
+	if created {
+		m.writeHeader()
+	} else if err = m.checkHeader(); err != nil {
+		syscall.Munmap(m.data)
+		return
+	}
+
+	return
+}
+
+// Close unmaps the shared memory and closes
+// the backing file.
+func (m *Map) Close() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.data == nil {
+		return ErrClosed
+	}
+	if err := syscall.Munmap(m.data); err != nil {
+		return err
+	}
+	m.data = nil
+	return m.f.Close()
+}
+
+// Set sets the value of key, which expires after
+// expiresAfter, or never if expiresAfter is
+// timedmap.NoExpiration. A copy of value is stored.
+func (m *Map) Set(key string, value []byte, expiresAfter time.Duration) error {
+	if uint32(len(key)) > m.maxKey {
+		return ErrKeyTooLarge
+	}
+	if uint32(len(value)) > m.maxValue {
+		return ErrValueTooLarge
+	}
+
+	unlock, err := m.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := time.Now().UnixNano()
+	free := -1
+	i, found := m.find(key, now, func(idx int, state byte) {
+		if free < 0 && state != slotUsed {
+			free = idx
+		}
+	})
+	if !found {
+		if free < 0 {
+			return ErrFull
+		}
+		i = free
+	}
+
+	expires := int64(never)
+	if expiresAfter != timedmap.NoExpiration {
+		expires = time.Now().Add(expiresAfter).UnixNano()
+	}
+
+	s := m.slot(i)
+	s[0] = slotUsed
+	binary.LittleEndian.PutUint16(s[2:], uint16(len(key)))
+	binary.LittleEndian.PutUint32(s[4:], uint32(len(value)))
+	binary.LittleEndian.PutUint64(s[8:], uint64(expires))
+	copy(s[slotHeaderSize:], key)
+	copy(s[slotHeaderSize+m.maxKey:], value)
+
+	return nil
+}
+
+// Get returns a copy of the value of key. If there
+// is no value for key or if it has been expired,
+// false is returned.
+func (m *Map) Get(key string) ([]byte, bool) {
+	unlock, err := m.lock(false)
+	if err != nil {
+		return nil, false
+	}
+	defer unlock()
+
+	i, found := m.find(key, time.Now().UnixNano(), nil)
+	if !found {
+		return nil, false
+	}
+
+	s := m.slot(i)
+	vLen := binary.LittleEndian.Uint32(s[4:])
+	value := make([]byte, vLen)
+	copy(value, s[slotHeaderSize+m.maxKey:])
+
+	return value, true
+}
+
+// GetExpires returns the expire time of key, which
+// is the zero time if the key never expires.
+func (m *Map) GetExpires(key string) (time.Time, bool) {
+	unlock, err := m.lock(false)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer unlock()
+
+	i, found := m.find(key, time.Now().UnixNano(), nil)
+	if !found {
+		return time.Time{}, false
+	}
+	expires := int64(binary.LittleEndian.Uint64(m.slot(i)[8:]))
+	if expires == never {
+		return time.Time{}, true
+	}
+	return time.Unix(0, expires), true
+}
+
+// Remove deletes the value of key.
+func (m *Map) Remove(key string) error {
+	unlock, err := m.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if i, found := m.find(key, time.Now().UnixNano(), nil); found {
+		m.slot(i)[0] = slotDeleted
+	}
+	return nil
+}
+
+// Cleanup removes all expired keys and returns
+// the number of removed keys.
+func (m *Map) Cleanup() (n int, err error) {
+	unlock, err := m.lock(true)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	now := time.Now().UnixNano()
+	for i := 0; i < int(m.slots); i++ {
+		s := m.slot(i)
+		if s[0] == slotUsed && m.expired(s, now) {
+			s[0] = slotDeleted
+			n++
+		}
+	}
+	return
+}
+
+// Size returns the number of non-expired keys.
+func (m *Map) Size() (n int) {
+	unlock, err := m.lock(false)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	now := time.Now().UnixNano()
+	for i := 0; i < int(m.slots); i++ {
+		s := m.slot(i)
+		if s[0] == slotUsed && !m.expired(s, now) {
+			n++
+		}
+	}
+	return
+}
+
+// lock acquires the in-process and the inter-process
+// lock and returns a function releasing both.
+func (m *Map) lock(exclusive bool) (unlock func(), err error) {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+		m.mtx.Lock()
+	} else {
+		m.mtx.RLock()
+	}
+
+	release := func() {
+		if exclusive {
+			m.mtx.Unlock()
+		} else {
+			m.mtx.RUnlock()
+		}
+	}
+
+	if m.data == nil {
+		release()
+		return nil, ErrClosed
+	}
+
+	// flock locks belong to the open file description, so
+	// shared lock holders of this process may overlap with
+	// an exclusive lock request of another goroutine. This
+	// is prevented by the in-process lock acquired above.
+	if err = syscall.Flock(int(m.f.Fd()), how); err != nil {
+		release()
+		return
+	}
+
+	return func() {
+		syscall.Flock(int(m.f.Fd()), syscall.LOCK_UN)
+		release()
+	}, nil
+}
+
+// find probes for the slot of key. visit is called for
+// each visited slot which does not hold a live key.
+// Expired slots are reported as deleted.
+func (m *Map) find(key string, now int64, visit func(idx int, state byte)) (int, bool) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	start := uint64(h.Sum32() % m.slots)
+
+	for n := uint64(0); n < uint64(m.slots); n++ {
+		idx := int((start + n) % uint64(m.slots))
+		s := m.slot(idx)
+
+		switch s[0] {
+		case slotEmpty:
+			if visit != nil {
+				visit(idx, slotEmpty)
+			}
+			return 0, false
+		case slotUsed:
+			if m.expired(s, now) {
+				if visit != nil {
+					visit(idx, slotDeleted)
+				}
+				continue
+			}
+			kLen := binary.LittleEndian.Uint16(s[2:])
+			if string(s[slotHeaderSize:slotHeaderSize+uint32(kLen)]) == key {
+				return idx, true
+			}
+		case slotDeleted:
+			if visit != nil {
+				visit(idx, slotDeleted)
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// slot returns the data of the slot with index i.
+func (m *Map) slot(i int) []byte {
+	off := headerSize + i*int(m.slotSize)
+	return m.data[off : off+int(m.slotSize)]
+}
+
+// expired returns true if the slot s expired at now.
+func (m *Map) expired(s []byte, now int64) bool {
+	return int64(binary.LittleEndian.Uint64(s[8:])) < now
+}
+
+// writeHeader writes the file header.
+func (m *Map) writeHeader() {
+	copy(m.data, magic)
+	binary.LittleEndian.PutUint32(m.data[8:], m.slots)
+	binary.LittleEndian.PutUint32(m.data[12:], m.maxKey)
+	binary.LittleEndian.PutUint32(m.data[16:], m.maxValue)
+}
+
+// checkHeader validates the file header against
+// the configured layout.
+func (m *Map) checkHeader() error {
+	if string(m.data[:len(magic)]) != magic {
+		return ErrInvalidFile
+	}
+	if binary.LittleEndian.Uint32(m.data[8:]) != m.slots ||
+		binary.LittleEndian.Uint32(m.data[12:]) != m.maxKey ||
+		binary.LittleEndian.Uint32(m.data[16:]) != m.maxValue {
+		return ErrLayoutMismatch
+	}
+	return nil
+}
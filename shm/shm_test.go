@@ -0,0 +1,158 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package shm
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func open(t *testing.T, dir string) *Map {
+	m, err := Open(filepath.Join(dir, "shm"), 8, 16, 32)
+	assert.Nil(t, err)
+	return m
+}
+
+func TestSetGet(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	m := open(t, dir)
+	defer m.Close()
+
+	assert.Nil(t, m.Set("a", []byte("hello"), time.Hour))
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("hello"), v)
+
+	_, ok = m.Get("b")
+	assert.False(t, ok)
+
+	exp, ok := m.GetExpires("a")
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, time.Until(exp), float64(time.Second))
+
+	assert.Nil(t, m.Remove("a"))
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+}
+
+func TestShared(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	m1 := open(t, dir)
+	defer m1.Close()
+	m2 := open(t, dir)
+	defer m2.Close()
+
+	assert.Nil(t, m1.Set("a", []byte("1"), time.Hour))
+	v, ok := m2.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	assert.Nil(t, m2.Set("a", []byte("2"), time.Hour))
+	v, _ = m1.Get("a")
+	assert.Equal(t, []byte("2"), v)
+	assert.Equal(t, 1, m1.Size())
+
+	_, err := Open(filepath.Join(dir, "shm"), 16, 16, 32)
+	assert.ErrorIs(t, err, ErrLayoutMismatch)
+}
+
+func TestExpiry(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	m := open(t, dir)
+	defer m.Close()
+
+	m.Set("a", []byte("1"), 10*time.Millisecond)
+	m.Set("b", []byte("2"), time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := m.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, m.Size())
+
+	n, err := m.Cleanup()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestNoExpiration(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	m := open(t, dir)
+	defer m.Close()
+
+	assert.Nil(t, m.Set("a", []byte("1"), timedmap.NoExpiration))
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	exp, ok := m.GetExpires("a")
+	assert.True(t, ok)
+	assert.True(t, exp.IsZero())
+
+	n, err := m.Cleanup()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 1, m.Size())
+}
+
+func TestInvalidLayout(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	_, err := Open(filepath.Join(dir, "shm"), 0, 16, 32)
+	assert.ErrorIs(t, err, ErrInvalidLayout)
+	_, err = Open(filepath.Join(dir, "shm"), 8, 1<<16, 32)
+	assert.ErrorIs(t, err, ErrInvalidLayout)
+	_, err = Open(filepath.Join(dir, "shm"), math.MaxUint32, 1, math.MaxUint32-slotHeaderSize-1)
+	assert.ErrorIs(t, err, ErrInvalidLayout)
+	_, err = os.Stat(filepath.Join(dir, "shm"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLimits(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	m := open(t, dir)
+	defer m.Close()
+
+	assert.ErrorIs(t, m.Set("this key is way too long", nil, time.Hour), ErrKeyTooLarge)
+	assert.ErrorIs(t, m.Set("a", make([]byte, 33), time.Hour), ErrValueTooLarge)
+
+	for i := 0; i < 8; i++ {
+		assert.Nil(t, m.Set(string(rune('a'+i)), nil, time.Hour))
+	}
+	assert.ErrorIs(t, m.Set("z", nil, time.Hour), ErrFull)
+
+	m.Remove("a")
+	assert.Nil(t, m.Set("z", nil, time.Hour))
+}
+
+func TestClosed(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "shm")
+	defer os.RemoveAll(dir)
+
+	m := open(t, dir)
+	assert.Nil(t, m.Close())
+
+	assert.ErrorIs(t, m.Set("a", nil, time.Hour), ErrClosed)
+	assert.ErrorIs(t, m.Remove("a"), ErrClosed)
+	assert.ErrorIs(t, m.Close(), ErrClosed)
+	_, ok := m.Get("a")
+	assert.False(t, ok)
+}
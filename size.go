@@ -0,0 +1,37 @@
+package timedmap
+
+// SizeFunc returns the size of a key or value
+// in bytes.
+type SizeFunc func(v interface{}) int
+
+// DefaultSize is the SizeFunc used when no other
+// SizeFunc is specified. It returns the length of
+// strings and byte slices and 0 for all other types,
+// so values of other types are never rejected.
+func DefaultSize(v interface{}) int {
+	switch vt := v.(type) {
+	case string:
+		return len(vt)
+	case []byte:
+		return len(vt)
+	}
+	return 0
+}
+
+// checkSize returns ErrKeyTooLarge or ErrValueTooLarge
+// if key or value exceed the limits set for the map.
+func (o *options) checkSize(key, value interface{}) error {
+	if o.maxKeySize > 0 && DefaultSize(key) > o.maxKeySize {
+		return ErrKeyTooLarge
+	}
+	if o.maxValueSize > 0 {
+		size := o.valueSize
+		if size == nil {
+			size = DefaultSize
+		}
+		if size(value) > o.maxValueSize {
+			return ErrValueTooLarge
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package timedmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSize(t *testing.T) {
+	assert.Equal(t, 3, DefaultSize("abc"))
+	assert.Equal(t, 2, DefaultSize([]byte{1, 2}))
+	assert.Equal(t, 0, DefaultSize(123))
+}
+
+func TestWithMaxKeySize(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithMaxKeySize(4))
+
+	assert.Nil(t, tm.SetWithOptions("abcd", 1, time.Hour))
+	assert.ErrorIs(t, tm.SetWithOptions("abcde", 1, time.Hour), ErrKeyTooLarge)
+	assert.Nil(t, tm.SetWithOptions(123456, 1, time.Hour))
+	assert.Equal(t, 2, tm.Size())
+}
+
+func TestWithMaxValueSize(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithMaxValueSize(8, nil))
+
+	assert.Nil(t, tm.SetWithOptions(1, "small", time.Hour))
+	assert.ErrorIs(t, tm.SetWithOptions(1, strings.Repeat("x", 9), time.Hour), ErrValueTooLarge)
+	assert.Equal(t, "small", tm.GetValue(1))
+
+	tm.Set(2, []byte(strings.Repeat("x", 9)), time.Hour)
+	assert.False(t, tm.Contains(2))
+}
+
+func TestWithMaxValueSizeFunc(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithMaxValueSize(2, func(v interface{}) int {
+		return len(v.([]int))
+	}))
+
+	assert.Nil(t, tm.Section(1).SetWithOptions(1, []int{1, 2}, time.Hour))
+	assert.ErrorIs(t, tm.Section(1).SetWithOptions(1, []int{1, 2, 3}, time.Hour), ErrValueTooLarge)
+}
@@ -0,0 +1,109 @@
+// Package slidingwindow provides sliding window
+// counters, which keep timestamped sub-buckets in
+// a timedmap which expire automatically once they
+// leave the largest supported window.
+package slidingwindow
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// Counter manages sliding window counters
+// keyed by arbitrary keys.
+type Counter struct {
+	mtx   sync.Mutex
+	cache timedmap.Section
+
+	resolution time.Duration
+	maxWindow  time.Duration
+}
+
+// Window is the sliding window counter
+// of a single key.
+type Window struct {
+	c   *Counter
+	key interface{}
+}
+
+// bucketKey identifies the sub-bucket with
+// the index idx of the counter of key.
+type bucketKey struct {
+	key interface{}
+	idx int64
+}
+
+// New creates a new Counter storing its sub-buckets
+// in the given cache section. Each sub-bucket covers
+// resolution and is kept for maxWindow, which is the
+// largest window which can be counted.
+func New(cache timedmap.Section, resolution, maxWindow time.Duration) *Counter {
+	return &Counter{
+		cache:      cache,
+		resolution: resolution,
+		maxWindow:  maxWindow,
+	}
+}
+
+// SlidingWindow returns the sliding window
+// counter for key.
+func (c *Counter) SlidingWindow(key interface{}) *Window {
+	return &Window{c: c, key: key}
+}
+
+// Incr increments the counter by one.
+func (w *Window) Incr() {
+	w.IncrBy(1)
+}
+
+// IncrBy increments the counter by n.
+func (w *Window) IncrBy(n int64) {
+	atomic.AddInt64(w.c.bucket(w.key, w.c.index(time.Now())), n)
+}
+
+// Count returns the sum of all increments within
+// the last window, with a precision of the counters
+// resolution. Windows larger than the counters
+// maximum window are capped to it.
+func (w *Window) Count(window time.Duration) (n int64) {
+	if window > w.c.maxWindow {
+		window = w.c.maxWindow
+	}
+
+	buckets := int64((window + w.c.resolution - 1) / w.c.resolution)
+	cur := w.c.index(time.Now())
+	for idx := cur - buckets + 1; idx <= cur; idx++ {
+		if v, ok := w.c.cache.GetValue(bucketKey{w.key, idx}).(*int64); ok {
+			n += atomic.LoadInt64(v)
+		}
+	}
+
+	return
+}
+
+// index returns the sub-bucket index of t.
+func (c *Counter) index(t time.Time) int64 {
+	return t.UnixNano() / int64(c.resolution)
+}
+
+// bucket returns the sub-bucket of key with the
+// given index and creates it if it does not exist.
+func (c *Counter) bucket(key interface{}, idx int64) *int64 {
+	k := bucketKey{key, idx}
+	if v, ok := c.cache.GetValue(k).(*int64); ok {
+		return v
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if v, ok := c.cache.GetValue(k).(*int64); ok {
+		return v
+	}
+	v := new(int64)
+	c.cache.Set(k, v, c.maxWindow+c.resolution)
+	return v
+}
@@ -0,0 +1,56 @@
+package slidingwindow
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCount(t *testing.T) {
+	c := New(timedmap.New(time.Minute), 10*time.Millisecond, 100*time.Millisecond)
+	w := c.SlidingWindow("a")
+
+	for i := 0; i < 5; i++ {
+		w.Incr()
+	}
+	assert.EqualValues(t, 5, w.Count(20*time.Millisecond))
+	assert.EqualValues(t, 0, c.SlidingWindow("b").Count(time.Second))
+
+	time.Sleep(30 * time.Millisecond)
+	w.IncrBy(3)
+	assert.EqualValues(t, 3, w.Count(20*time.Millisecond))
+	assert.EqualValues(t, 8, w.Count(100*time.Millisecond))
+	assert.EqualValues(t, 8, w.Count(time.Hour))
+}
+
+func TestBucketsExpire(t *testing.T) {
+	tm := timedmap.New(5 * time.Millisecond)
+	c := New(tm, 10*time.Millisecond, 20*time.Millisecond)
+
+	c.SlidingWindow("a").Incr()
+	assert.EqualValues(t, 1, tm.Size())
+
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 0, c.SlidingWindow("a").Count(20*time.Millisecond))
+	assert.EqualValues(t, 0, tm.Size())
+}
+
+func TestConcurrentIncr(t *testing.T) {
+	c := New(timedmap.New(time.Minute), time.Second, time.Minute)
+	w := c.SlidingWindow("a")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Incr()
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 100, w.Count(time.Minute))
+}
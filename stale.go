@@ -0,0 +1,66 @@
+package timedmap
+
+import "time"
+
+// MarkStale flags the key-value pair of key as stale
+// without removing it, for example when its source has
+// been changed. Readers still get the value, but GetEntry
+// and GetStale report it as stale, so they can decide to
+// serve it while it is refreshed. The flag is cleared
+// when the value of the key is set again.
+//
+// If there is no value to the passed key or if the value
+// was expired, ErrKeyNotFound is returned.
+func (tm *TimedMap) MarkStale(key interface{}) error {
+	return tm.markStale(key, 0)
+}
+
+func (s *section) MarkStale(key interface{}) error {
+	return s.tm.markStale(key, s.sec)
+}
+
+// GetStale returns the value of a key like Get,
+// together with whether the pair is stale, because
+// it has been flagged using MarkStale or is served
+// during its grace period.
+func (tm *TimedMap) GetStale(key interface{}) (value interface{}, stale, ok bool) {
+	return tm.getStale(key, 0)
+}
+
+func (s *section) GetStale(key interface{}) (value interface{}, stale, ok bool) {
+	return s.tm.getStale(key, s.sec)
+}
+
+// markStale flags the given key in
+// the given section as stale.
+func (tm *TimedMap) markStale(key interface{}, sec int) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, time.Now()) {
+		return ErrKeyNotFound
+	}
+	v.marked = true
+	return nil
+}
+
+// getStale returns the value of the given key in the
+// given section and whether it is stale.
+func (tm *TimedMap) getStale(key interface{}, sec int) (value interface{}, stale, ok bool) {
+	k := tm.wrapKey(key, sec)
+
+	var e Entry
+	if !tm.view(k, func(v *element) {
+		e = v.entry(k.key)
+	}) {
+		return nil, false, false
+	}
+	return tm.readValue(k, e.Value), e.Stale, true
+}
@@ -0,0 +1,69 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkStale(t *testing.T) {
+	tm := New(0)
+	defer tm.Close()
+
+	tm.Set(1, "a", time.Hour)
+	v, stale, ok := tm.GetStale(1)
+	assert.Equal(t, "a", v)
+	assert.False(t, stale)
+	assert.True(t, ok)
+
+	assert.NoError(t, tm.MarkStale(1))
+	v, stale, ok = tm.GetStale(1)
+	assert.Equal(t, "a", v)
+	assert.True(t, stale)
+	assert.True(t, ok)
+	assert.Equal(t, "a", tm.GetValue(1))
+
+	e, err := tm.GetEntry(1)
+	assert.NoError(t, err)
+	assert.True(t, e.Stale)
+
+	// changing the expiry keeps the flag
+	assert.NoError(t, tm.SetExpires(1, 2*time.Hour))
+	_, stale, _ = tm.GetStale(1)
+	assert.True(t, stale)
+
+	// setting the value clears the flag
+	tm.Set(1, "b", time.Hour)
+	v, stale, ok = tm.GetStale(1)
+	assert.Equal(t, "b", v)
+	assert.False(t, stale)
+
+	_, _, ok = tm.GetStale(2)
+	assert.False(t, ok)
+	assert.ErrorIs(t, tm.MarkStale(2), ErrKeyNotFound)
+	tm.Set(3, "c", -time.Second)
+	assert.ErrorIs(t, tm.MarkStale(3), ErrKeyNotFound)
+
+	s := tm.Section(1)
+	s.Set(1, "d", time.Hour)
+	assert.NoError(t, s.MarkStale(1))
+	_, stale, _ = s.GetStale(1)
+	assert.True(t, stale)
+	_, stale, _ = tm.GetStale(1)
+	assert.False(t, stale)
+}
+
+func TestGetStaleGrace(t *testing.T) {
+	tm := NewWithOptions(0, WithGracePeriod(time.Hour))
+	defer tm.Close()
+
+	tm.Set(1, "a", -time.Second)
+	v, stale, ok := tm.GetStale(1)
+	assert.Equal(t, "a", v)
+	assert.True(t, stale)
+	assert.True(t, ok)
+
+	tm.Close()
+	assert.ErrorIs(t, tm.MarkStale(1), ErrClosed)
+}
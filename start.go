@@ -0,0 +1,58 @@
+package timedmap
+
+import "context"
+
+// StartHook is a function which is executed when
+// the map is started using Start, for example to
+// pre-populate the map from a snapshot or loader.
+// The passed context is the one passed to Start.
+type StartHook func(ctx context.Context) error
+
+// OnStart registers hook to be executed by Start.
+// Hooks are executed in registration order. Hooks
+// which are registered after the map has been
+// started or closed are never executed.
+func (tm *TimedMap) OnStart(hook StartHook) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.started || tm.closed || tm.closing {
+		return
+	}
+	tm.startHooks = append(tm.startHooks, hook)
+}
+
+// Start executes the hooks registered with OnStart
+// with ctx. It should be called after the map has been
+// created and before it is handed to traffic.
+//
+// When a hook fails or ctx is done, the remaining hooks
+// are skipped and the error is returned. Hooks are only
+// executed once, so further calls of Start return nil.
+// Calling Start on a closed map returns ErrClosed.
+func (tm *TimedMap) Start(ctx context.Context) error {
+	tm.mtx.Lock()
+	if tm.closed || tm.closing {
+		tm.mtx.Unlock()
+		return ErrClosed
+	}
+	if tm.started {
+		tm.mtx.Unlock()
+		return nil
+	}
+	tm.started = true
+	hooks := tm.startHooks
+	tm.startHooks = nil
+	tm.mtx.Unlock()
+
+	for _, hook := range hooks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package timedmap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	var order []int
+	tm.OnStart(func(ctx context.Context) error {
+		order = append(order, 1)
+		tm.Set("a", 1, time.Hour)
+		return nil
+	})
+	tm.OnStart(func(ctx context.Context) error {
+		order = append(order, 2)
+		return nil
+	})
+
+	assert.Nil(t, tm.Start(context.Background()))
+	assert.Equal(t, []int{1, 2}, order)
+	assert.EqualValues(t, 1, tm.GetValue("a"))
+
+	tm.OnStart(func(ctx context.Context) error {
+		t.Error("hook registered after start executed")
+		return nil
+	})
+	assert.Nil(t, tm.Start(context.Background()))
+	assert.Equal(t, []int{1, 2}, order)
+
+	tm.Close()
+	assert.ErrorIs(t, tm.Start(context.Background()), ErrClosed)
+}
+
+func TestStartError(t *testing.T) {
+	tm := New(dCleanupTick)
+	errWarmup := errors.New("snapshot not found")
+
+	tm.OnStart(func(ctx context.Context) error {
+		return errWarmup
+	})
+	tm.OnStart(func(ctx context.Context) error {
+		t.Error("hook executed after failed hook")
+		return nil
+	})
+	assert.ErrorIs(t, tm.Start(context.Background()), errWarmup)
+
+	tm = New(dCleanupTick)
+	tm.OnStart(func(ctx context.Context) error {
+		t.Error("hook executed with done context")
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, tm.Start(ctx), context.Canceled)
+}
@@ -0,0 +1,189 @@
+package timedmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats contains runtime statistics of a TimedMap.
+type Stats struct {
+	// Size is the number of key-value pairs
+	// currently stored in the map.
+	Size int
+
+	// Callbacks is the number of expiration
+	// callbacks which have been executed.
+	Callbacks uint64
+
+	// SlowCallbacks is the number of expiration
+	// callbacks which took longer than the threshold
+	// passed to WithSlowCallbackHandler.
+	SlowCallbacks uint64
+
+	// SkippedTicks is the number of cleanup ticks
+	// which arrived while a cleanup cycle was running
+	// and have been dropped. It is only counted for
+	// the whole map.
+	SkippedTicks uint64
+
+	// Cost is the total cost of the key-value pairs
+	// currently stored in the map as returned by the
+	// CostFunc set using WithCostFunc.
+	Cost int64
+
+	// EvictedCost is the total cost of the key-value
+	// pairs which have been expired or evicted.
+	EvictedCost int64
+
+	// Evictions is the number of key-value pairs
+	// which have been evicted before they expired.
+	Evictions uint64
+
+	// Recovered is the number of key-value pairs which
+	// have been restored by Recover or the replay of
+	// the write-ahead log. It is only counted for the
+	// whole map.
+	Recovered uint64
+
+	// Discarded is the number of key-value pairs read
+	// by Recover or the replay of the write-ahead log,
+	// which have not been restored, because they have
+	// been removed, have expired or have been rejected.
+	// It is only counted for the whole map.
+	Discarded uint64
+}
+
+// statsCounters holds the counters which are
+// updated atomically while the map is used.
+type statsCounters struct {
+	callbacks     uint64
+	slowCallbacks uint64
+	skippedTicks  uint64
+	cost          int64
+	evictedCost   int64
+	evictions     uint64
+	recovered     uint64
+	discarded     uint64
+}
+
+// Stats returns a snapshot of the
+// runtime statistics of the whole map,
+// including all sections.
+func (tm *TimedMap) Stats() Stats {
+	return Stats{
+		Size:          tm.Size(),
+		Callbacks:     atomic.LoadUint64(&tm.stats.callbacks),
+		SlowCallbacks: atomic.LoadUint64(&tm.stats.slowCallbacks),
+		SkippedTicks:  atomic.LoadUint64(&tm.stats.skippedTicks),
+		Cost:          atomic.LoadInt64(&tm.stats.cost),
+		EvictedCost:   atomic.LoadInt64(&tm.stats.evictedCost),
+		Evictions:     atomic.LoadUint64(&tm.stats.evictions),
+		Recovered:     atomic.LoadUint64(&tm.stats.recovered),
+		Discarded:     atomic.LoadUint64(&tm.stats.discarded),
+	}
+}
+
+// StatsOf returns a snapshot of the runtime
+// statistics of the given section.
+func (tm *TimedMap) StatsOf(sec int) Stats {
+	st := Stats{
+		Size: tm.size(sec),
+	}
+
+	tm.mtx.RLock()
+	sc, ok := tm.sectionStats[sec]
+	tm.mtx.RUnlock()
+
+	if ok {
+		st.Callbacks = atomic.LoadUint64(&sc.callbacks)
+		st.SlowCallbacks = atomic.LoadUint64(&sc.slowCallbacks)
+		st.Cost = atomic.LoadInt64(&sc.cost)
+		st.EvictedCost = atomic.LoadInt64(&sc.evictedCost)
+		st.Evictions = atomic.LoadUint64(&sc.evictions)
+	}
+	return st
+}
+
+func (s *section) Stats() Stats {
+	return s.tm.StatsOf(s.sec)
+}
+
+// SizeOf returns the current number of key-value
+// pairs existent in the given section.
+func (tm *TimedMap) SizeOf(sec int) int {
+	return tm.size(sec)
+}
+
+// sectionCounters returns the counters of the given
+// section. The write lock of the map must be held.
+func (tm *TimedMap) sectionCounters(sec int) *statsCounters {
+	sc, ok := tm.sectionStats[sec]
+	if !ok {
+		if tm.sectionStats == nil {
+			tm.sectionStats = make(map[int]*statsCounters)
+		}
+		sc = new(statsCounters)
+		tm.sectionStats[sec] = sc
+	}
+	return sc
+}
+
+// StartStatsReporter starts a go routine which calls
+// report with a snapshot of the statistics of the map
+// in the given interval, for example to push them to
+// a metrics backend.
+//
+// If a reporter is already running, it will be stopped
+// and restarted using the new specification. Like the
+// cleanup loop, the reporter is stopped when the map
+// is closed.
+func (tm *TimedMap) StartStatsReporter(interval time.Duration, report func(Stats)) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return
+	}
+	tm.stopStatsReporter()
+
+	stop := make(chan struct{})
+	tm.reporterStopChan = stop
+	go tm.statsReporterLoop(time.NewTicker(interval), stop, report)
+}
+
+// StopStatsReporter stops the go routine started
+// by StartStatsReporter.
+func (tm *TimedMap) StopStatsReporter() {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	tm.stopStatsReporter()
+}
+
+// stopStatsReporter stops the running stats reporter.
+// The write lock of the map must be held.
+func (tm *TimedMap) stopStatsReporter() {
+	if tm.reporterStopChan != nil {
+		close(tm.reporterStopChan)
+		tm.reporterStopChan = nil
+	}
+}
+
+// statsReporterLoop calls report on every tick of
+// ticker until stop is closed.
+func (tm *TimedMap) statsReporterLoop(ticker *time.Ticker, stop <-chan struct{}, report func(Stats)) {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			report(tm.Stats())
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	tm.Set(1, 1, time.Hour)
+	tm.Set(2, 1, 5*time.Millisecond, func(interface{}) {}, func(interface{}) {})
+	tm.Section(1).Set(1, 1, time.Hour)
+
+	time.Sleep(30 * time.Millisecond)
+
+	st := tm.Stats()
+	assert.EqualValues(t, 2, st.Size)
+	assert.EqualValues(t, 2, st.Callbacks)
+	assert.EqualValues(t, 0, st.SlowCallbacks)
+}
+
+func TestStatsOf(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	tm.Set(1, 1, time.Hour)
+	tm.Section(1).Set(1, 1, 5*time.Millisecond, func(interface{}) {})
+	tm.Section(1).Set(2, 1, time.Hour)
+
+	time.Sleep(30 * time.Millisecond)
+
+	assert.Equal(t, 1, tm.SizeOf(0))
+	assert.Equal(t, 1, tm.SizeOf(1))
+	assert.Equal(t, 0, tm.SizeOf(2))
+
+	st := tm.StatsOf(1)
+	assert.EqualValues(t, 1, st.Size)
+	assert.EqualValues(t, 1, st.Callbacks)
+	assert.Equal(t, st, tm.Section(1).Stats())
+
+	st = tm.StatsOf(0)
+	assert.EqualValues(t, 1, st.Size)
+	assert.EqualValues(t, 0, st.Callbacks)
+
+	assert.EqualValues(t, 1, tm.Stats().Callbacks)
+	assert.Equal(t, Stats{}, tm.StatsOf(2))
+}
+
+func TestStatsReporter(t *testing.T) {
+	tm := New(dCleanupTick)
+	tm.Set(1, 1, time.Hour)
+
+	reports := make(chan Stats, 100)
+	tm.StartStatsReporter(5*time.Millisecond, func(st Stats) {
+		reports <- st
+	})
+
+	select {
+	case st := <-reports:
+		assert.EqualValues(t, 1, st.Size)
+	case <-time.After(time.Second):
+		t.Fatal("no stats reported")
+	}
+
+	tm.StopStatsReporter()
+	time.Sleep(10 * time.Millisecond)
+	for len(reports) > 0 {
+		<-reports
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, reports)
+
+	tm.StartStatsReporter(5*time.Millisecond, func(st Stats) {
+		reports <- st
+	})
+	tm.Close()
+	time.Sleep(20 * time.Millisecond)
+	for len(reports) > 0 {
+		<-reports
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, reports)
+	assert.Nil(t, tm.reporterStopChan)
+}
+
+func TestStatsSkippedTicks(t *testing.T) {
+	c := make(chan time.Time, 10)
+	tm := New(0, c)
+
+	tm.Set(1, 1, -time.Second, func(interface{}) {
+		time.Sleep(20 * time.Millisecond)
+	})
+
+	c <- time.Now()
+	time.Sleep(5 * time.Millisecond)
+	c <- time.Now()
+	c <- time.Now()
+	time.Sleep(40 * time.Millisecond)
+
+	st := tm.Stats()
+	assert.EqualValues(t, 1, st.Callbacks)
+	assert.EqualValues(t, 2, st.SkippedTicks)
+	assert.EqualValues(t, 0, tm.StatsOf(0).SkippedTicks)
+
+	c <- time.Now()
+	time.Sleep(5 * time.Millisecond)
+	assert.EqualValues(t, 2, tm.Stats().SkippedTicks)
+}
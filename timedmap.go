@@ -2,23 +2,73 @@ package timedmap
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type callback func(value interface{})
 
+// NoExpiration can be passed as duration to Set and
+// SetExpires to store a key-value pair which never
+// expires. It is still removed by Remove and Flush.
+//
+// All other durations except DefaultExpiration are
+// relative to the current time. By default, a duration
+// of 0 or below results in a pair which has expired
+// immediately; see WithTTLPolicy for alternatives.
+const NoExpiration time.Duration = -1
+
 // TimedMap contains a map with all key-value pairs,
 // and a timer, which cleans the map in the set
 // tick durations from expired keys.
 type TimedMap struct {
+	// stats and generation must be the first fields to
+	// guarantee 64 bit alignment for atomic operations.
+	stats      statsCounters
+	generation uint64
+
+	// swept is the generation up to which pairs
+	// invalidated by BumpGeneration have been
+	// removed by the cleanup loop.
+	swept uint64
+
 	mtx         sync.RWMutex
-	container   map[keyWrap]*element
+	container   Backend
 	elementPool *sync.Pool
+	bloom       *bloomFilter
+	opts        options
 
 	cleanupTickTime time.Duration
 	cleanerTicker   *time.Ticker
 	cleanerStopChan chan bool
 	cleanerRunning  bool
+
+	reporterStopChan chan struct{}
+
+	started    bool
+	startHooks []StartHook
+	closed     bool
+	closing    bool
+	closeHooks []CloseHook
+
+	sectionStats map[int]*statsCounters
+	tombstones   map[keyWrap]time.Time
+	bin          *recycleBin
+	history      *history
+	audit        *auditLog
+	feed         *changeFeed
+	wal          *writeAheadLog
+	dispatcher   *callbackDispatcher
+	barriers     []*barrier
+	clock        *clockBackend
+	scan         *shardIndex
+
+	computeMtx sync.Mutex
+	computing  map[keyWrap]*computeCall
+
+	nsMtx          sync.Mutex
+	namespaces     map[string]int
+	namespaceNames map[int]string
 }
 
 type keyWrap struct {
@@ -35,6 +85,91 @@ type element struct {
 	expires time.Time
 	expired bool
 	cbs     []callback
+	created time.Time
+	updated time.Time
+	meta    interface{}
+	gen     uint64
+	grace   time.Duration
+	refs    int
+
+	// writes is the number of times the element has
+	// been set since window started, used by the
+	// write rate limit.
+	writes int
+	window time.Time
+
+	// sliding is the duration the expiry is reset
+	// to on each read, if set by a TTL rule.
+	sliding time.Duration
+
+	rearm RearmFunc
+
+	// cost is the cost of the element as returned
+	// by the CostFunc of the map.
+	cost int64
+
+	// rev is incremented each time the element is
+	// stored. It is not reset when the element is
+	// reused, so an element and its rev identify a
+	// single write of a value.
+	rev uint64
+
+	// marked is set if the element has
+	// been flagged using MarkStale.
+	marked bool
+
+	// uses counts the reads of the element for the
+	// eviction policy, if the map has a maximum size.
+	// It is updated atomically.
+	uses uint32
+
+	// done is set atomically when the element has
+	// been expired to guarantee that its callbacks
+	// are executed at most once.
+	done uint32
+}
+
+// isExpiredAt returns true if the element has
+// an expiry and its grace period ended before t.
+func (v *element) isExpiredAt(t time.Time) bool {
+	return v.expired && t.After(v.deadline())
+}
+
+// isStaleAt returns true if the element has an
+// expiry which is before t, even if it is still
+// in its grace period.
+func (v *element) isStaleAt(t time.Time) bool {
+	return v.expired && t.After(v.expires)
+}
+
+// deadline returns the time when the element is
+// removed from the map, which is its expiry plus
+// its grace period.
+func (v *element) deadline() time.Time {
+	return v.expires.Add(v.grace)
+}
+
+// setExpiry sets the expiry of the element to d
+// after now, or removes it if d is NoExpiration.
+func (v *element) setExpiry(now time.Time, d time.Duration) {
+	if d == NoExpiration {
+		v.expired = false
+		v.expires = time.Time{}
+		return
+	}
+	v.expired = true
+	v.expires = now.Add(d)
+}
+
+// setExpiryOf sets the expiry of the element like
+// setExpiry, or to the deadline of so if it has one.
+func (v *element) setExpiryOf(now time.Time, d time.Duration, so setOptions) {
+	if !so.deadline.IsZero() {
+		v.expired = true
+		v.expires = so.deadline
+		return
+	}
+	v.setExpiry(now, d)
 }
 
 // New creates and returns a new instance of TimedMap.
@@ -54,8 +189,25 @@ type element struct {
 // can also be used to re-define the specification of
 // the cleanup loop when already running if you want to.
 func New(cleanupTickTime time.Duration, tickerChan ...<-chan time.Time) *TimedMap {
+	tm := newTimedMap(options{})
+
+	if len(tickerChan) > 0 {
+		tm.StartCleanerExternal(tickerChan[0])
+	} else if cleanupTickTime > 0 {
+		tm.StartCleanerInternal(cleanupTickTime)
+	}
+
+	return tm
+}
+
+// newTimedMap creates a new instance of TimedMap
+// configured with o without starting the cleanup
+// loop.
+func newTimedMap(o options) *TimedMap {
+	o.resolveCost()
 	tm := &TimedMap{
-		container:       make(map[keyWrap]*element),
+		opts:            o,
+		container:       o.backend,
 		cleanerStopChan: make(chan bool),
 		elementPool: &sync.Pool{
 			New: func() interface{} {
@@ -64,10 +216,40 @@ func New(cleanupTickTime time.Duration, tickerChan ...<-chan time.Time) *TimedMa
 		},
 	}
 
-	if len(tickerChan) > 0 {
-		tm.StartCleanerExternal(tickerChan[0])
-	} else if cleanupTickTime > 0 {
-		tm.StartCleanerInternal(cleanupTickTime)
+	if tm.container == nil {
+		tm.container = NewMapBackend()
+	}
+	if o.preciseExpiry {
+		tm.container = newPreciseBackend(tm, tm.container, o.timerWindow, o.maxTimers)
+	}
+	if o.maxSize > 0 || o.maxCost > 0 {
+		tm.clock = newClockBackend(tm.container)
+		tm.container = tm.clock
+	}
+
+	if o.bloomExpectedKeys > 0 {
+		tm.bloom = newBloomFilter(o.bloomExpectedKeys, o.bloomFalsePositiveRate)
+	}
+	if o.auditSize > 0 || o.auditWriter != nil {
+		tm.audit = &auditLog{
+			records: make([]AuditRecord, o.auditSize),
+			w:       o.auditWriter,
+		}
+	}
+	if o.feedSize > 0 {
+		tm.feed = newChangeFeed(o.feedSize)
+	}
+	if o.binSize > 0 {
+		tm.bin = newRecycleBin(o.binSize, o.binRetention)
+	}
+	if o.historySize > 0 {
+		tm.history = newHistory(o.historySize, o.historyRetention)
+	}
+	if o.callbackWorkers > 0 {
+		tm.dispatcher = newCallbackDispatcher(o.callbackWorkers, nil)
+	}
+	if o.walPath != "" {
+		tm.openWAL(o.walPath)
 	}
 
 	return tm
@@ -92,31 +274,55 @@ func (tm *TimedMap) Ident() int {
 
 // Set appends a key-value pair to the map or sets the value of
 // a key. expiresAfter sets the expire time after the key-value pair
-// will automatically be removed from the map.
+// will automatically be removed from the map. Pass NoExpiration
+// to store a key-value pair which never expires, or
+// DefaultExpiration to apply the rules set by WithTTLRules.
 func (tm *TimedMap) Set(key, value interface{}, expiresAfter time.Duration, cb ...callback) {
 	tm.set(key, 0, value, expiresAfter, cb...)
 }
 
+// SetGet sets the value of a key like Set and returns the
+// previous value of the key. replaced is false if the key
+// was not present in the map or the value was expired.
+func (tm *TimedMap) SetGet(key, value interface{}, expiresAfter time.Duration) (prev interface{}, replaced bool) {
+	k := tm.wrapKey(key, 0)
+	prev, replaced, _ = tm.swap(k, value, expiresAfter, setOptions{})
+	if replaced {
+		prev = tm.transformRead(k, prev)
+	}
+	return
+}
+
 // GetValue returns an interface of the value of a key in the
 // map. The returned value is nil if there is no value to the
 // passed key or if the value was expired.
 func (tm *TimedMap) GetValue(key interface{}) interface{} {
-	v := tm.get(key, 0)
+	k := tm.wrapKey(key, 0)
+	v := tm.lookup(k)
 	if v == nil {
 		return nil
 	}
-	return v.value
+	return tm.readValue(k, v.value)
+}
+
+// Get returns the value of a key in the map. ok is false
+// if there is no value to the passed key or if the value
+// was expired, so stored nil values can be distinguished
+// from missing ones.
+func (tm *TimedMap) Get(key interface{}) (value interface{}, ok bool) {
+	k := tm.wrapKey(key, 0)
+	v := tm.lookup(k)
+	if v == nil {
+		return nil, false
+	}
+	return tm.readValue(k, v.value), true
 }
 
 // GetExpires returns the expire time of a key-value pair.
 // If the key-value pair does not exist in the map or
 // was expired, this will return an error object.
 func (tm *TimedMap) GetExpires(key interface{}) (time.Time, error) {
-	v := tm.get(key, 0)
-	if v == nil {
-		return time.Time{}, ErrKeyNotFound
-	}
-	return v.expires, nil
+	return tm.getExpires(key, 0)
 }
 
 // SetExpire is deprecated.
@@ -126,12 +332,26 @@ func (tm *TimedMap) SetExpire(key interface{}, d time.Duration) error {
 }
 
 // SetExpires sets the expire time for a key-value
-// pair to the passed duration. If there is no value
+// pair to the passed duration from now, or removes it
+// when NoExpiration is passed. If there is no value
 // to the key passed , this will return an error.
 func (tm *TimedMap) SetExpires(key interface{}, d time.Duration) error {
 	return tm.setExpires(key, 0, d)
 }
 
+// SetExpireAt sets the expire time for a key-value
+// pair to the wall clock time at. If there is no value
+// to the key passed, this will return an error.
+//
+// All other expire times are measured on the monotonic
+// clock, so they are not affected by changes of the
+// system clock. Expire times set using SetExpireAt are
+// compared to the wall clock instead, which is useful
+// for absolute deadlines like the expiry of a token.
+func (tm *TimedMap) SetExpireAt(key interface{}, at time.Time) error {
+	return tm.setExpireAt(key, 0, at)
+}
+
 // Contains returns true, if the key exists in the map.
 // false will be returned, if there is no value to the
 // key or if the key-value pair was expired.
@@ -151,21 +371,45 @@ func (tm *TimedMap) Refresh(key interface{}, d time.Duration) error {
 	return tm.refresh(key, 0, d)
 }
 
+// TryRefresh extends the expire time for a key-value pair
+// about the passed duration like Refresh and returns true.
+// If there is no value to the key passed, false is returned.
+func (tm *TimedMap) TryRefresh(key interface{}, d time.Duration) bool {
+	return tm.refresh(key, 0, d) == nil
+}
+
 // Flush deletes all key-value pairs of the map.
 func (tm *TimedMap) Flush() {
 	tm.mtx.Lock()
 	defer tm.mtx.Unlock()
 
-	for k, v := range tm.container {
+	tm.flush()
+}
+
+// flush removes all key-value pairs of the map.
+// The write lock of the map must be held.
+func (tm *TimedMap) flush() {
+	tm.container.each(func(k keyWrap, v *element) bool {
+		tm.discharge(k, v, false)
 		tm.elementPool.Put(v)
-		delete(tm.container, k)
+		return true
+	})
+	tm.container.clear()
+	tm.tombstones = nil
+	tm.publishFlush(0, true)
+
+	if tm.bloom != nil {
+		tm.bloom.reset()
 	}
 }
 
 // Size returns the current number of key-value pairs
 // existent in the map.
 func (tm *TimedMap) Size() int {
-	return len(tm.container)
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	return tm.container.len()
 }
 
 // StartCleanerInternal starts the cleanup loop controlled
@@ -174,10 +418,14 @@ func (tm *TimedMap) Size() int {
 // If the cleanup loop is already running, it will be
 // stopped and restarted using the new specification.
 func (tm *TimedMap) StartCleanerInternal(interval time.Duration) {
+	if tm.IsClosed() {
+		return
+	}
 	if tm.cleanerRunning {
 		tm.StopCleaner()
 	}
 	tm.cleanerTicker = time.NewTicker(interval)
+	tm.cleanerRunning = true
 	go tm.cleanupLoop(tm.cleanerTicker.C)
 }
 
@@ -189,9 +437,13 @@ func (tm *TimedMap) StartCleanerInternal(interval time.Duration) {
 // If the cleanup loop is already running, it will be
 // stopped and restarted using the new specification.
 func (tm *TimedMap) StartCleanerExternal(initiator <-chan time.Time) {
+	if tm.IsClosed() {
+		return
+	}
 	if tm.cleanerRunning {
 		tm.StopCleaner()
 	}
+	tm.cleanerRunning = true
 	go tm.cleanupLoop(initiator)
 }
 
@@ -207,124 +459,386 @@ func (tm *TimedMap) StopCleaner() {
 	if tm.cleanerTicker != nil {
 		tm.cleanerTicker.Stop()
 	}
+	tm.cleanerRunning = false
 }
 
 // Snapshot returns a new map which represents the
 // current key-value state of the internal container.
+// Key-value pairs which have expired but have not been
+// cleaned up yet are not included, so the snapshot is
+// independent of the cleanup loop.
 func (tm *TimedMap) Snapshot() map[interface{}]interface{} {
 	return tm.getSnapshot(0)
 }
 
+// wrapKey returns the internal key of the given key
+// in the given section after applying the key
+// normalizer of the map.
+func (tm *TimedMap) wrapKey(key interface{}, sec int) keyWrap {
+	if tm.opts.keyNormalizer != nil {
+		key = tm.opts.keyNormalizer(key)
+	}
+	return keyWrap{
+		sec: sec,
+		key: key,
+	}
+}
+
 // cleanupLoop holds the loop executing the cleanup
 // when initiated by tc.
+//
+// Ticks which were sent while a cleanup cycle was
+// running are dropped instead of starting further
+// cycles back to back, and counted as skipped ticks.
 func (tm *TimedMap) cleanupLoop(tc <-chan time.Time) {
-	tm.cleanerRunning = true
-	defer func() {
-		tm.cleanerRunning = false
-	}()
-
 	for {
 		select {
 		case <-tc:
-			tm.cleanUp()
+			tm.observedCleanUp()
+			tm.dropPendingTicks(tc)
 		case <-tm.cleanerStopChan:
 			return
 		}
 	}
 }
 
+// dropPendingTicks receives all ticks which are
+// pending on tc without blocking and counts them
+// as skipped, notifying the CleanerObserver.
+func (tm *TimedMap) dropPendingTicks(tc <-chan time.Time) {
+	for {
+		select {
+		case _, ok := <-tc:
+			if !ok {
+				return
+			}
+			atomic.AddUint64(&tm.stats.skippedTicks, 1)
+			if obs := tm.cleanerObserver(); obs != nil {
+				obs.OnSkippedTick()
+			}
+		default:
+			return
+		}
+	}
+}
+
 // expireElement removes the specified key-value element
 // from the map and executes all defined callback functions
-func (tm *TimedMap) expireElement(key interface{}, sec int, v *element) {
-	for _, cb := range v.cbs {
-		cb(v.value)
+func (tm *TimedMap) expireElement(k keyWrap, v *element) {
+	if !atomic.CompareAndSwapUint32(&v.done, 0, 1) {
+		return
 	}
 
-	k := keyWrap{
-		sec: sec,
-		key: key,
+	current := v.gen == tm.currentGeneration()
+	if current && v.rearm != nil && tm.rearmElement(k, v) {
+		return
 	}
 
-	tm.elementPool.Put(v)
-	delete(tm.container, k)
+	// the element is removed even if a callback panics,
+	// which is recovered when a CleanerObserver is set
+	defer func() {
+		tm.discharge(k, v, current)
+		tm.elementPool.Put(v)
+		tm.container.del(k)
+		if tm.bloom != nil {
+			tm.bloom.remove(k)
+		}
+	}()
+
+	if current {
+		tm.runCallbacks(k, v.cbs, tm.transformRead(k, v.value))
+		tm.recycle(k, v, time.Now())
+		tm.publish(ChangeExpire, k, v)
+	}
 }
 
 // cleanUp iterates trhough the map and expires all key-value
-// pairs which expire time after the current time
-func (tm *TimedMap) cleanUp() {
+// pairs which expire time after the current time. It returns
+// the number of expired pairs and the number of pairs stored
+// before the cycle.
+func (tm *TimedMap) cleanUp() (expired, size int) {
 	now := time.Now()
 
 	tm.mtx.Lock()
 	defer tm.mtx.Unlock()
 
-	for k, v := range tm.container {
-		if v.expired && now.After(v.expires) {
-			tm.expireElement(k.key, k.sec, v)
-		}
+	strategy := tm.opts.cleanupStrategy
+	if strategy == nil {
+		strategy = IndexedCleanup()
 	}
+	size = tm.container.len()
+	s := &sweeper{tm: tm, now: now}
+	strategy.Sweep(now, s)
+
+	tm.sweepGenerations()
+	tm.sweepTombstones(now)
+	if tm.bin != nil {
+		tm.bin.sweep(now)
+	}
+	if tm.history != nil {
+		tm.history.sweep(now)
+	}
+	tm.checkBarriers(now)
+	return s.expired, size
 }
 
 // set sets the value for a key and section with the
 // given expiration parameters
-func (tm *TimedMap) set(key interface{}, sec int, val interface{}, expiresAfter time.Duration, cb ...callback) {
-	// re-use element when existent on this key
-	if v := tm.getRaw(key, sec); v != nil {
-		v.value = val
-		v.cbs = cb
-		if expiresAfter > 0 {
-			v.expired = true
-			v.expires = time.Now().Add(expiresAfter)
+func (tm *TimedMap) set(key interface{}, sec int, val interface{}, expiresAfter time.Duration, cb ...callback) error {
+	return tm.setWith(key, sec, val, expiresAfter, setOptions{cbs: cb})
+}
+
+// setWith sets the value for a key and section with
+// the given expiration parameters and set options.
+func (tm *TimedMap) setWith(key interface{}, sec int, val interface{}, expiresAfter time.Duration, so setOptions) error {
+	_, _, err := tm.swap(tm.wrapKey(key, sec), val, expiresAfter, so)
+	return err
+}
+
+// swap sets the value for a key and section like setWith
+// and returns the previous value, if the key was present
+// and has not expired.
+func (tm *TimedMap) swap(k keyWrap, val interface{}, expiresAfter time.Duration, so setOptions) (prev interface{}, replaced bool, err error) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	return tm.swapLocked(k, val, expiresAfter, so)
+}
+
+// swapLocked sets the value of k like swap. The
+// write lock of the map must be held.
+func (tm *TimedMap) swapLocked(k keyWrap, val interface{}, expiresAfter time.Duration, so setOptions) (prev interface{}, replaced bool, err error) {
+	return tm.swapLockedAt(k, time.Now(), val, expiresAfter, so)
+}
+
+// swapLockedAt sets the value of k like swapLocked,
+// as if it was set at now. The write lock of the map
+// must be held.
+func (tm *TimedMap) swapLockedAt(k keyWrap, now time.Time, val interface{}, expiresAfter time.Duration, so setOptions) (prev interface{}, replaced bool, err error) {
+	if tm.closed {
+		err = ErrClosed
+		return
+	}
+
+	if err = tm.checkTombstone(k, now, so); err != nil {
+		return
+	}
+	if err = tm.opts.checkSize(k.key, val); err != nil {
+		return
+	}
+	if tm.opts.validator != nil {
+		if err = tm.opts.validator(k.key, val); err != nil {
+			err = &ValidationError{Key: k.key, Err: err}
+			return
 		}
+	}
+
+	if err = tm.checkWriteRate(k, now); err != nil {
 		return
 	}
 
-	k := keyWrap{
-		sec: sec,
-		key: key,
+	expiresAfter, sliding := tm.applyRules(k.key, expiresAfter)
+	if sliding > 0 {
+		so.sliding = sliding
 	}
 
-	tm.mtx.Lock()
-	defer tm.mtx.Unlock()
+	expiresAfter, expireNow, err := tm.resolveTTL(expiresAfter)
+	if err != nil {
+		return
+	}
+
+	prev, replaced = tm.store(k, now, tm.transformWrite(k.key, val), expiresAfter, so)
+	if tm.audit != nil {
+		var old string
+		if replaced {
+			old = summarize(prev)
+		}
+		tm.record(AuditSet, k, so.actor, old, summarize(val))
+	}
+	if expireNow {
+		if v, ok := tm.container.get(k); ok && v.refs == 0 {
+			tm.expireElement(k, v)
+		}
+	}
+	return
+}
+
+// store sets the value of k at now and returns the
+// previous value, if the key was present and has not
+// expired. The write lock of the map must be held.
+func (tm *TimedMap) store(k keyWrap, now time.Time, val interface{}, expiresAfter time.Duration, so setOptions) (prev interface{}, replaced bool) {
+	// re-use element when existent on this key
+	if v, ok := tm.container.get(k); ok {
+		if tm.isExpired(v, now) {
+			v.created = now
+			v.writes, v.window = 1, now
+		} else {
+			prev, replaced = v.value, true
+			tm.addRevision(k, v, now)
+		}
+		v.value = val
+		v.marked = false
+		v.cbs = so.cbs
+		v.meta = so.meta
+		v.updated = now
+		v.gen = tm.currentGeneration()
+		v.grace = tm.graceOf(so)
+		v.sliding = so.sliding
+		v.rearm = so.rearm
+		v.setExpiryOf(now, expiresAfter, so)
+		v.rev++
+		tm.charge(k, v)
+		tm.markAccess(v)
+		tm.container.touch(k, v)
+		tm.publish(ChangeSet, k, v)
+		if tm.opts.maxCost > 0 {
+			// the new value may cost more
+			tm.evictOverflow(&k)
+		}
+		return
+	}
 
 	v := tm.elementPool.Get().(*element)
+	v.done = 0
+	v.uses = 0
+	v.marked = false
+	v.refs = 0
+	v.cost = 0
+	v.writes, v.window = 1, now
 	v.value = val
-	if expiresAfter > 0 {
-		v.expired = true
-		v.expires = time.Now().Add(expiresAfter)
+	v.grace = tm.graceOf(so)
+	v.sliding = so.sliding
+	v.rearm = so.rearm
+	v.setExpiryOf(now, expiresAfter, so)
+	v.cbs = so.cbs
+	v.meta = so.meta
+	v.created = now
+	v.updated = now
+	v.gen = tm.currentGeneration()
+	v.rev++
+	tm.charge(k, v)
+	tm.container.put(k, v)
+	if tm.bloom != nil {
+		tm.bloom.add(k)
 	}
-	v.cbs = cb
-	tm.container[k] = v
+	tm.publish(ChangeSet, k, v)
+	if tm.clock != nil {
+		tm.evictOverflow(&k)
+	}
+	return
 }
 
 // get returns an element object by key and section
 // if the value has not already expired
 func (tm *TimedMap) get(key interface{}, sec int) *element {
-	v := tm.getRaw(key, sec)
+	return tm.lookup(tm.wrapKey(key, sec))
+}
 
-	if v == nil {
+// lookup returns the element of k like get.
+func (tm *TimedMap) lookup(k keyWrap) *element {
+	if tm.bloom != nil && !tm.bloom.mayContain(k) {
+		return nil
+	}
+
+	tm.mtx.RLock()
+	if tm.closed {
+		tm.mtx.RUnlock()
 		return nil
 	}
+	v, ok := tm.container.get(k)
+	expired := ok && tm.isExpired(v, time.Now())
+	if ok && !expired {
+		tm.markAccess(v)
+	}
+	tm.mtx.RUnlock()
 
-	if v.expired && time.Now().After(v.expires) {
-		tm.mtx.Lock()
-		defer tm.mtx.Unlock()
-		tm.expireElement(key, sec, v)
+	if !ok {
 		return nil
 	}
 
+	if expired {
+		tm.expireIfCurrent(k, v)
+		return nil
+	}
+
+	if v.sliding > 0 {
+		tm.slide(k, v)
+	}
+
 	return v
 }
 
+// expireIfCurrent expires v if it is still the element
+// stored for k and has expired. Between releasing the read
+// lock and acquiring the write lock, the cleanup loop, a
+// timer, Remove or Set may have removed or replaced v.
+func (tm *TimedMap) expireIfCurrent(k keyWrap, v *element) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if cur, ok := tm.container.get(k); ok && cur == v && tm.isExpired(v, time.Now()) {
+		tm.expireElement(k, v)
+	}
+}
+
+// view calls fn with the live element of k while
+// holding the read lock of the map and returns true.
+// If there is no such element, fn is not called and
+// false is returned.
+func (tm *TimedMap) view(k keyWrap, fn func(v *element)) bool {
+	if tm.bloom != nil && !tm.bloom.mayContain(k) {
+		return false
+	}
+
+	tm.mtx.RLock()
+	if tm.closed {
+		tm.mtx.RUnlock()
+		return false
+	}
+	v, ok := tm.container.get(k)
+	if ok && !tm.isExpired(v, time.Now()) {
+		fn(v)
+		tm.markAccess(v)
+		tm.mtx.RUnlock()
+		return true
+	}
+	tm.mtx.RUnlock()
+
+	if ok {
+		tm.expireIfCurrent(k, v)
+	}
+
+	return false
+}
+
+// getExpires returns the expire time of the given
+// key in the given section.
+func (tm *TimedMap) getExpires(key interface{}, sec int) (time.Time, error) {
+	if tm.IsClosed() {
+		return time.Time{}, ErrClosed
+	}
+	v := tm.get(key, sec)
+	if v == nil {
+		return time.Time{}, ErrKeyNotFound
+	}
+	return v.expires, nil
+}
+
 // getRaw returns the raw element object by key,
 // not depending on expiration time
 func (tm *TimedMap) getRaw(key interface{}, sec int) *element {
-	k := keyWrap{
-		sec: sec,
-		key: key,
+	k := tm.wrapKey(key, sec)
+
+	if tm.bloom != nil && !tm.bloom.mayContain(k) {
+		return nil
 	}
 
 	tm.mtx.RLock()
-	v, ok := tm.container[k]
+	if tm.closed {
+		tm.mtx.RUnlock()
+		return nil
+	}
+	v, ok := tm.container.get(k)
 	tm.mtx.RUnlock()
 
 	if !ok {
@@ -336,53 +850,139 @@ func (tm *TimedMap) getRaw(key interface{}, sec int) *element {
 
 // remove removes an element from the map by giveb
 // key and section
-func (tm *TimedMap) remove(key interface{}, sec int) {
-	k := keyWrap{
-		sec: sec,
-		key: key,
-	}
+func (tm *TimedMap) remove(key interface{}, sec int) error {
+	return tm.removeAs(key, sec, "")
+}
+
+// removeAs removes an element from the map like
+// remove and records actor in the audit log.
+func (tm *TimedMap) removeAs(key interface{}, sec int, actor string) error {
+	k := tm.wrapKey(key, sec)
 
 	tm.mtx.Lock()
 	defer tm.mtx.Unlock()
 
-	v, ok := tm.container[k]
+	if tm.closed {
+		return ErrClosed
+	}
+
+	tm.removeLocked(k, actor)
+	return nil
+}
+
+// removeLocked removes the element of k from the map
+// and returns its value and true if it has not expired.
+// The write lock of the map must be held.
+func (tm *TimedMap) removeLocked(k keyWrap, actor string) (value interface{}, live bool) {
+	v, ok := tm.container.get(k)
 	if !ok {
-		return
+		return nil, false
 	}
 
+	if now := time.Now(); !tm.isExpired(v, now) {
+		value, live = v.value, true
+		tm.addRevision(k, v, now)
+		tm.addTombstone(k, now)
+		tm.recycle(k, v, now)
+		if tm.audit != nil {
+			tm.record(AuditRemove, k, actor, summarize(v.value), "")
+		}
+	}
+	tm.publish(ChangeRemove, k, v)
+	tm.discharge(k, v, false)
 	tm.elementPool.Put(v)
-	delete(tm.container, k)
+	tm.container.del(k)
+	if tm.bloom != nil {
+		tm.bloom.remove(k)
+	}
+	return
 }
 
 // refresh extends the lifetime of the given key in the
-// given section by the duration d.
+// given section by the duration d. Pairs which never
+// expire are not changed unless d is NoExpiration.
 func (tm *TimedMap) refresh(key interface{}, sec int, d time.Duration) error {
-	v := tm.get(key, sec)
-	if v == nil {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, time.Now()) {
 		return ErrKeyNotFound
 	}
-	if d > 0 {
-		v.expired = true
+	old := tm.expirySummary(v)
+	if d == NoExpiration {
+		v.setExpiry(time.Time{}, NoExpiration)
+	} else if v.expired {
 		v.expires = v.expires.Add(d)
-	} else {
-		v.expired = false
 	}
+	tm.container.touch(k, v)
+	tm.expiryChanged(k, v, old)
 	return nil
 }
 
 // setExpires sets the lifetime of the given key in the
 // given section to the duration d.
 func (tm *TimedMap) setExpires(key interface{}, sec int, d time.Duration) error {
-	v := tm.get(key, sec)
-	if v == nil {
+	return tm.setExpiresIf(key, sec, d, expiryAlways)
+}
+
+// setExpiresIf sets the lifetime of the given key in the
+// given section to the duration d if the new expiry
+// satisfies the condition c.
+func (tm *TimedMap) setExpiresIf(key interface{}, sec int, d time.Duration, c expiryCond) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, time.Now()) {
 		return ErrKeyNotFound
 	}
-	if d > 0 {
-		v.expired = true
-		v.expires = v.expires.Add(d)
-	} else {
-		v.expired = false
+	now := time.Now()
+	if !c.holds(v, now, d) {
+		return nil
 	}
+	old := tm.expirySummary(v)
+	v.setExpiry(now, d)
+	tm.container.touch(k, v)
+	tm.expiryChanged(k, v, old)
+	return nil
+}
+
+// setExpireAt sets the expire time of the given key
+// in the given section to the wall clock time at.
+func (tm *TimedMap) setExpireAt(key interface{}, sec int, at time.Time) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	v, ok := tm.container.get(k)
+	if !ok || tm.isExpired(v, time.Now()) {
+		return ErrKeyNotFound
+	}
+	// stripping the monotonic clock reading makes
+	// all comparisons use the wall clock
+	old := tm.expirySummary(v)
+	v.expired = true
+	v.expires = at.Round(0)
+	tm.container.touch(k, v)
+	tm.expiryChanged(k, v, old)
 	return nil
 }
 
@@ -392,11 +992,62 @@ func (tm *TimedMap) getSnapshot(sec int) (m map[interface{}]interface{}) {
 	tm.mtx.RLock()
 	defer tm.mtx.RUnlock()
 
-	for k, v := range tm.container {
+	now := time.Now()
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if k.sec == sec && !tm.isExpired(v, now) {
+			m[k.key] = tm.readValue(k, v.value)
+		}
+		return true
+	})
+
+	return
+}
+
+// flushSection removes all key-value pairs
+// of the given section.
+func (tm *TimedMap) flushSection(sec int) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	var keys []keyWrap
+	tm.container.each(func(k keyWrap, _ *element) bool {
 		if k.sec == sec {
-			m[k.key] = v.value
+			keys = append(keys, k)
+		}
+		return true
+	})
+
+	tm.publishFlush(sec, false)
+
+	for k := range tm.tombstones {
+		if k.sec == sec {
+			delete(tm.tombstones, k)
 		}
 	}
 
+	for _, k := range keys {
+		v, _ := tm.container.get(k)
+		tm.discharge(k, v, false)
+		tm.elementPool.Put(v)
+		tm.container.del(k)
+		if tm.bloom != nil {
+			tm.bloom.remove(k)
+		}
+	}
+}
+
+// size returns the number of key-value
+// pairs of the given section.
+func (tm *TimedMap) size(sec int) (i int) {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	tm.container.each(func(k keyWrap, _ *element) bool {
+		if k.sec == sec {
+			i++
+		}
+		return true
+	})
+
 	return
 }
@@ -17,7 +17,7 @@ func TestNew(t *testing.T) {
 	tm := New(dCleanupTick)
 
 	assert.NotNil(t, tm)
-	assert.EqualValues(t, 0, len(tm.container))
+	assert.EqualValues(t, 0, tm.container.len())
 	time.Sleep(10 * time.Millisecond)
 	assert.True(t, tm.cleanerRunning)
 }
@@ -28,9 +28,9 @@ func TestFlush(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		tm.set(i, 0, 1, time.Hour)
 	}
-	assert.EqualValues(t, 10, len(tm.container))
+	assert.EqualValues(t, 10, tm.container.len())
 	tm.Flush()
-	assert.EqualValues(t, 0, len(tm.container))
+	assert.EqualValues(t, 0, tm.container.len())
 }
 
 func TestIdent(t *testing.T) {
@@ -56,6 +56,46 @@ func TestSet(t *testing.T) {
 	assert.Nil(t, tm.get(key, 0))
 }
 
+func TestSetGet(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	prev, replaced := tm.SetGet("a", 1, 20*time.Millisecond)
+	assert.False(t, replaced)
+	assert.Nil(t, prev)
+
+	prev, replaced = tm.SetGet("a", 2, 20*time.Millisecond)
+	assert.True(t, replaced)
+	assert.EqualValues(t, 1, prev)
+	assert.EqualValues(t, 2, tm.GetValue("a"))
+
+	time.Sleep(50 * time.Millisecond)
+	prev, replaced = tm.SetGet("a", 3, time.Hour)
+	assert.False(t, replaced)
+	assert.Nil(t, prev)
+}
+
+func TestGet(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	tm.Set("nil", nil, time.Hour)
+	tm.Set("exp", 1, 10*time.Millisecond)
+
+	v, ok := tm.Get("nil")
+	assert.True(t, ok)
+	assert.Nil(t, v)
+
+	v, ok = tm.Get("exp")
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, v)
+
+	_, ok = tm.Get("missing")
+	assert.False(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok = tm.Get("exp")
+	assert.False(t, ok)
+}
+
 func TestGetValue(t *testing.T) {
 	const key = "tKeyGetVal"
 	const val = "tValGetVal"
@@ -158,6 +198,24 @@ func TestRefresh(t *testing.T) {
 	assert.Nil(t, tm.get(key, 0))
 }
 
+func TestTryRefresh(t *testing.T) {
+	const key = "tKeyRef"
+
+	tm := New(dCleanupTick)
+
+	assert.False(t, tm.TryRefresh("keyNotExists", time.Hour))
+
+	tm.Set(key, 1, 12*time.Millisecond)
+	assert.True(t, tm.TryRefresh(key, 50*time.Millisecond))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NotNil(t, tm.get(key, 0))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, tm.get(key, 0))
+	assert.False(t, tm.TryRefresh(key, time.Hour))
+}
+
 func TestSize(t *testing.T) {
 	tm := New(dCleanupTick)
 
@@ -290,6 +348,10 @@ func TestSnapshot(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		assert.EqualValues(t, i, m[i])
 	}
+
+	tm.set(10, 0, 10, -time.Millisecond)
+	assert.Len(t, tm.Snapshot(), 10)
+	assert.Equal(t, 11, tm.Size())
 }
 
 func TestConcurrentReadWrite(t *testing.T) {
@@ -413,3 +475,70 @@ func (cb *CB) Cb(v interface{}) {
 	cb.TestData().Set("v", v)
 	cb.Called()
 }
+
+func TestNoExpiration(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	tm.Set(1, 1, NoExpiration)
+	tm.Set(2, 2, 0)
+	tm.Set(3, 3, -time.Second)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, tm.Contains(1))
+	assert.False(t, tm.Contains(2))
+	assert.False(t, tm.Contains(3))
+
+	exp, err := tm.GetExpires(1)
+	assert.Nil(t, err)
+	assert.True(t, exp.IsZero())
+
+	assert.Nil(t, tm.Refresh(1, time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, tm.Contains(1))
+
+	tm.Set(1, 1, 10*time.Millisecond)
+	tm.Set(1, 1, NoExpiration)
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, tm.Contains(1))
+
+	tm.Flush()
+	assert.False(t, tm.Contains(1))
+}
+
+func TestSetExpireAt(t *testing.T) {
+	for name, nb := range testBackends {
+		tm := NewWithOptions(dCleanupTick, WithBackend(nb()))
+
+		assert.ErrorIs(t, tm.SetExpireAt("a", time.Now()), ErrKeyNotFound, name)
+
+		tm.Set("a", 1, time.Hour)
+		tm.Section(1).Set("a", 1, time.Hour)
+		at := time.Unix(time.Now().Unix(), 0).Add(2 * time.Second)
+		assert.Nil(t, tm.SetExpireAt("a", at), name)
+		assert.Nil(t, tm.Section(1).SetExpireAt("a", time.Now().Add(20*time.Millisecond)), name)
+
+		exp, err := tm.GetExpires("a")
+		assert.Nil(t, err, name)
+		assert.True(t, exp.Equal(at), name)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.True(t, tm.Contains("a"), name)
+		assert.False(t, tm.Section(1).Contains("a"), name)
+	}
+}
+
+func TestMonotonicExpiry(t *testing.T) {
+	tm := New(dCleanupTick)
+	tm.Set("a", 1, time.Hour)
+
+	exp, err := tm.GetExpires("a")
+	assert.Nil(t, err)
+	// Round(0) strips the monotonic clock reading,
+	// so only times carrying one differ from it
+	assert.NotEqual(t, exp, exp.Round(0))
+
+	tm.SetExpireAt("a", exp)
+	exp, err = tm.GetExpires("a")
+	assert.Nil(t, err)
+	assert.Equal(t, exp, exp.Round(0))
+}
@@ -0,0 +1,247 @@
+// Package timedmapbench drives configurable mixes of
+// reads, writes and removals against a timedmap and
+// reports throughput, allocations and latency
+// percentiles, so that backends and options can be
+// compared on the target hardware.
+//
+// For example, to compare the map and heap backends:
+//
+//	w := timedmapbench.Workload{Keys: 100000, TTL: time.Second}
+//	for _, b := range []timedmap.Backend{timedmap.NewMapBackend(), timedmap.NewHeapBackend()} {
+//		tm := timedmap.NewWithOptions(100*time.Millisecond, timedmap.WithBackend(b))
+//		fmt.Println(timedmapbench.Run(tm, w))
+//		tm.Close()
+//	}
+package timedmapbench
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// maxSamples is the maximum number of latency
+// samples kept per worker.
+const maxSamples = 1 << 16
+
+// Workload describes the operations run against
+// a map. Zero fields are set to their defaults.
+type Workload struct {
+	// Keys is the number of distinct keys
+	// accessed. The default is 1000.
+	Keys int
+
+	// Reads, Writes and Removes are the relative
+	// weights of the operation types. The default
+	// is 90 reads to 10 writes.
+	Reads   int
+	Writes  int
+	Removes int
+
+	// TTL is the duration after which written values
+	// expire. Short TTLs put load on the cleanup of
+	// the map. The default is one second.
+	TTL time.Duration
+
+	// Workers is the number of goroutines issuing
+	// operations. The default is GOMAXPROCS.
+	Workers int
+
+	// Duration is the time the workload runs for.
+	// The default is one second. It is ignored if
+	// Ops is set.
+	Duration time.Duration
+
+	// Ops is the total number of operations to
+	// run, if it is above 0.
+	Ops int
+}
+
+// Result contains the measurements of a run.
+type Result struct {
+	Ops     uint64
+	Elapsed time.Duration
+
+	// Throughput is the number of
+	// operations per second.
+	Throughput float64
+
+	// AllocsPerOp and BytesPerOp are the average
+	// number and size of heap allocations of an
+	// operation.
+	AllocsPerOp float64
+	BytesPerOp  float64
+
+	// Hits and Misses are the numbers of reads
+	// which found or did not find a value.
+	Hits   uint64
+	Misses uint64
+
+	// P50, P90 and P99 are percentiles and Max is
+	// the maximum of the latency of the operations.
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// String returns the result formatted in one line.
+func (r Result) String() string {
+	return fmt.Sprintf("%d ops in %s (%.0f ops/s), %.1f allocs/op, %.1f B/op, "+
+		"hits %d, misses %d, p50 %s, p90 %s, p99 %s, max %s",
+		r.Ops, r.Elapsed, r.Throughput, r.AllocsPerOp, r.BytesPerOp,
+		r.Hits, r.Misses, r.P50, r.P90, r.P99, r.Max)
+}
+
+// worker is the state of a goroutine
+// issuing operations.
+type worker struct {
+	rnd     *rand.Rand
+	samples []time.Duration
+	seen    int
+	hits    uint64
+	misses  uint64
+	ops     uint64
+}
+
+// Run runs the workload w against cache and
+// returns the measurements.
+func Run(cache timedmap.Section, w Workload) Result {
+	w = w.withDefaults()
+
+	workers := make([]*worker, w.Workers)
+	for i := range workers {
+		workers[i] = &worker{
+			rnd:     rand.New(rand.NewSource(int64(i) + 1)),
+			samples: make([]time.Duration, 0, maxSamples),
+		}
+	}
+
+	remaining := int64(w.Ops)
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	mallocs, bytes := ms.Mallocs, ms.TotalAlloc
+
+	start := time.Now()
+	deadline := start.Add(w.Duration)
+
+	var wg sync.WaitGroup
+	wg.Add(len(workers))
+	for _, wk := range workers {
+		go func(wk *worker) {
+			defer wg.Done()
+			for {
+				if w.Ops > 0 {
+					if atomic.AddInt64(&remaining, -1) < 0 {
+						return
+					}
+				} else if !time.Now().Before(deadline) {
+					return
+				}
+				wk.op(cache, &w)
+			}
+		}(wk)
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&ms)
+
+	r := Result{Elapsed: elapsed}
+	var samples []time.Duration
+	for _, wk := range workers {
+		r.Ops += wk.ops
+		r.Hits += wk.hits
+		r.Misses += wk.misses
+		samples = append(samples, wk.samples...)
+	}
+	if r.Ops == 0 {
+		return r
+	}
+
+	r.Throughput = float64(r.Ops) / elapsed.Seconds()
+	r.AllocsPerOp = float64(ms.Mallocs-mallocs) / float64(r.Ops)
+	r.BytesPerOp = float64(ms.TotalAlloc-bytes) / float64(r.Ops)
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	r.P50 = percentile(samples, 0.5)
+	r.P90 = percentile(samples, 0.9)
+	r.P99 = percentile(samples, 0.99)
+	r.Max = samples[len(samples)-1]
+
+	return r
+}
+
+// withDefaults returns w with the
+// defaults of zero fields set.
+func (w Workload) withDefaults() Workload {
+	if w.Keys <= 0 {
+		w.Keys = 1000
+	}
+	if w.Reads <= 0 && w.Writes <= 0 && w.Removes <= 0 {
+		w.Reads, w.Writes = 90, 10
+	}
+	if w.TTL == 0 {
+		w.TTL = time.Second
+	}
+	if w.Workers <= 0 {
+		w.Workers = runtime.GOMAXPROCS(0)
+	}
+	if w.Duration <= 0 {
+		w.Duration = time.Second
+	}
+	return w
+}
+
+// op issues a single random operation of
+// the workload w against cache.
+func (wk *worker) op(cache timedmap.Section, w *Workload) {
+	key := wk.rnd.Intn(w.Keys)
+	n := wk.rnd.Intn(w.Reads + w.Writes + w.Removes)
+
+	start := time.Now()
+	switch {
+	case n < w.Reads:
+		if _, ok := cache.Get(key); ok {
+			wk.hits++
+		} else {
+			wk.misses++
+		}
+	case n < w.Reads+w.Writes:
+		cache.Set(key, key, w.TTL)
+	default:
+		cache.Remove(key)
+	}
+	wk.sample(time.Since(start))
+	wk.ops++
+}
+
+// sample records the latency d, replacing a random
+// sample once maxSamples have been recorded, so that
+// the kept samples are a uniform sample of all.
+func (wk *worker) sample(d time.Duration) {
+	wk.seen++
+	if len(wk.samples) < maxSamples {
+		wk.samples = append(wk.samples, d)
+		return
+	}
+	if i := wk.rnd.Intn(wk.seen); i < maxSamples {
+		wk.samples[i] = d
+	}
+}
+
+// percentile returns the p-th percentile
+// of the sorted samples.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	i := int(float64(len(samples)-1) * p)
+	return samples[i]
+}
@@ -0,0 +1,49 @@
+package timedmapbench
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunOps(t *testing.T) {
+	tm := timedmap.New(10 * time.Millisecond)
+	defer tm.Close()
+
+	r := Run(tm, Workload{
+		Keys:    100,
+		Reads:   50,
+		Writes:  40,
+		Removes: 10,
+		TTL:     5 * time.Millisecond,
+		Workers: 4,
+		Ops:     10000,
+	})
+
+	assert.EqualValues(t, 10000, r.Ops)
+	assert.NotZero(t, r.Hits+r.Misses)
+	assert.True(t, r.Throughput > 0)
+	assert.True(t, r.P50 <= r.P90 && r.P90 <= r.P99 && r.P99 <= r.Max)
+	assert.Contains(t, r.String(), "10000 ops")
+}
+
+func TestRunDuration(t *testing.T) {
+	tm := timedmap.NewWithOptions(0, timedmap.WithBackend(timedmap.NewHeapBackend()))
+	defer tm.Close()
+
+	r := Run(tm, Workload{Duration: 20 * time.Millisecond, Workers: 2})
+	assert.NotZero(t, r.Ops)
+	assert.True(t, r.Elapsed >= 20*time.Millisecond)
+}
+
+func TestSample(t *testing.T) {
+	wk := &worker{rnd: rand.New(rand.NewSource(1))}
+	for i := 0; i < 2*maxSamples; i++ {
+		wk.sample(time.Duration(i))
+	}
+	assert.Len(t, wk.samples, maxSamples)
+	assert.Equal(t, 2*maxSamples, wk.seen)
+}
@@ -0,0 +1,132 @@
+// Package timedmaptest provides utilities for testing
+// code which uses a timedmap, like injecting the
+// latency of cache misses and forcing expirations.
+package timedmaptest
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// Map wraps a cache section and delays reads and
+// loader calls, so that cache-miss and stampede code
+// paths can be exercised in tests.
+//
+// The latencies can be changed at any time, also
+// while the map is used concurrently.
+type Map struct {
+	cache timedmap.Section
+
+	mtx           sync.RWMutex
+	getLatency    time.Duration
+	loaderLatency time.Duration
+
+	loads uint64
+}
+
+// New creates a new Map wrapping the
+// given cache section.
+func New(cache timedmap.Section) *Map {
+	return &Map{
+		cache: cache,
+	}
+}
+
+// Section returns the wrapped cache section,
+// which can be used without injected latencies.
+func (m *Map) Section() timedmap.Section {
+	return m.cache
+}
+
+// SetGetLatency sets the duration each read
+// of the map is delayed by.
+func (m *Map) SetGetLatency(d time.Duration) {
+	m.mtx.Lock()
+	m.getLatency = d
+	m.mtx.Unlock()
+}
+
+// SetLoaderLatency sets the duration each call of a
+// loader passed to GetOrCompute is delayed by, which
+// simulates the penalty of a cache miss.
+func (m *Map) SetLoaderLatency(d time.Duration) {
+	m.mtx.Lock()
+	m.loaderLatency = d
+	m.mtx.Unlock()
+}
+
+// Set sets the value of a key like Section.Set.
+func (m *Map) Set(key, value interface{}, expiresAfter time.Duration) {
+	m.cache.Set(key, value, expiresAfter)
+}
+
+// Get returns the value of a key like Section.Get
+// after the configured read latency.
+func (m *Map) Get(key interface{}) (value interface{}, ok bool) {
+	getLatency, _ := m.latencies()
+	sleep(getLatency)
+	return m.cache.Get(key)
+}
+
+// GetValue returns the value of a key like
+// Section.GetValue after the configured read latency.
+func (m *Map) GetValue(key interface{}) interface{} {
+	v, _ := m.Get(key)
+	return v
+}
+
+// GetOrCompute returns the value of a key like
+// Section.GetOrCompute after the configured read
+// latency. Calls of fn are delayed by the configured
+// loader latency and counted.
+func (m *Map) GetOrCompute(key interface{}, fn timedmap.ComputeFunc) (interface{}, error) {
+	getLatency, loaderLatency := m.latencies()
+	sleep(getLatency)
+
+	return m.cache.GetOrCompute(key, func() (interface{}, time.Duration, error) {
+		atomic.AddUint64(&m.loads, 1)
+		sleep(loaderLatency)
+		return fn()
+	})
+}
+
+// Loads returns the number of loader calls
+// made by GetOrCompute.
+func (m *Map) Loads() uint64 {
+	return atomic.LoadUint64(&m.loads)
+}
+
+// Expire expires the passed keys immediately, so
+// that their next read is a miss. Their callbacks
+// are executed on the next read or cleanup cycle.
+func (m *Map) Expire(keys ...interface{}) {
+	for _, key := range keys {
+		m.cache.SetExpires(key, 0)
+	}
+}
+
+// ExpireAll expires all keys of the
+// section immediately like Expire.
+func (m *Map) ExpireAll() {
+	for key := range m.cache.Snapshot() {
+		m.cache.SetExpires(key, 0)
+	}
+}
+
+// latencies returns the currently
+// configured latencies.
+func (m *Map) latencies() (get, loader time.Duration) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.getLatency, m.loaderLatency
+}
+
+// sleep sleeps for d, if d is above zero.
+func sleep(d time.Duration) {
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
@@ -0,0 +1,78 @@
+package timedmaptest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	m := New(timedmap.New(time.Minute))
+	m.Set(1, 1, time.Hour)
+
+	m.SetGetLatency(20 * time.Millisecond)
+	start := time.Now()
+	assert.Equal(t, 1, m.GetValue(1))
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	m.SetGetLatency(0)
+	m.SetLoaderLatency(20 * time.Millisecond)
+	start = time.Now()
+	v, err := m.GetOrCompute(2, func() (interface{}, time.Duration, error) {
+		return 2, time.Hour, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	assert.EqualValues(t, 1, m.Loads())
+}
+
+func TestStampede(t *testing.T) {
+	m := New(timedmap.New(time.Minute))
+	m.SetLoaderLatency(20 * time.Millisecond)
+
+	load := func() (interface{}, time.Duration, error) {
+		return 1, time.Hour, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := m.GetOrCompute(1, load)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, m.Loads())
+
+	m.Expire(1)
+	_, ok := m.Get(1)
+	assert.False(t, ok)
+	_, err := m.GetOrCompute(1, load)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, m.Loads())
+}
+
+func TestExpireAll(t *testing.T) {
+	tm := timedmap.New(time.Minute)
+	m := New(tm.Section(1))
+
+	var expired int
+	m.Section().Set(1, 1, time.Hour, func(interface{}) {
+		expired++
+	})
+	m.Set(2, 2, timedmap.NoExpiration)
+	tm.Set(1, 1, time.Hour)
+
+	m.ExpireAll()
+	assert.False(t, m.Section().Contains(1))
+	assert.False(t, m.Section().Contains(2))
+	assert.Equal(t, 1, expired)
+	assert.True(t, tm.Contains(1))
+}
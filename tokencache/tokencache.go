@@ -0,0 +1,226 @@
+// Package tokencache provides a cache for expiring
+// credentials like bearer tokens, which are renewed
+// in the background shortly before they expire.
+package tokencache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// DefaultRenewTimeout is the time after which
+// a renewal is cancelled.
+const DefaultRenewTimeout = 30 * time.Second
+
+// RenewFunc obtains a new token for the given key
+// and returns it together with its expiry time.
+type RenewFunc func(ctx context.Context, key interface{}) (token interface{}, expires time.Time, err error)
+
+// Cache stores tokens until their expiry and renews
+// them renewBefore their expiry in the background.
+//
+// Concurrent requests for a token which is not
+// present in the cache result in a single call
+// of the RenewFunc.
+type Cache struct {
+	// RenewTimeout is the time after which a renewal
+	// is cancelled. Renewals are shared by concurrent
+	// callers of Get, so they are not cancelled with the
+	// context of a caller, which only limits how long
+	// that caller waits. If 0, DefaultRenewTimeout is
+	// used.
+	RenewTimeout time.Duration
+
+	// OnRenewError is called when a background
+	// renewal failed, if specified.
+	OnRenewError func(key interface{}, err error)
+
+	cache       timedmap.Section
+	renew       RenewFunc
+	renewBefore time.Duration
+
+	mtx     sync.Mutex
+	calls   map[interface{}]*call
+	timers  map[interface{}]*renewal
+	stopped bool
+}
+
+// renewal is a scheduled background renewal.
+type renewal struct {
+	t *time.Timer
+}
+
+// call is an in-flight or completed renewal.
+type call struct {
+	done  chan struct{}
+	token interface{}
+	err   error
+
+	// forgotten is set by Forget, so that the
+	// obtained token is not stored.
+	forgotten bool
+}
+
+// New creates a new Cache storing tokens in the
+// given cache section, which are obtained by renew
+// and renewed renewBefore their expiry.
+func New(cache timedmap.Section, renew RenewFunc, renewBefore time.Duration) *Cache {
+	return &Cache{
+		cache:       cache,
+		renew:       renew,
+		renewBefore: renewBefore,
+		calls:       make(map[interface{}]*call),
+		timers:      make(map[interface{}]*renewal),
+	}
+}
+
+// Get returns the current token for key. If there is
+// no valid token in the cache, a new one is obtained
+// using the RenewFunc. Concurrent callers wait for
+// the same renewal.
+func (c *Cache) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	if v := c.cache.GetValue(key); v != nil {
+		return v, nil
+	}
+
+	cl := c.doRenew(key, nil)
+	select {
+	case <-cl.done:
+		return cl.token, cl.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Forget removes the token for key from the cache
+// and stops its background renewal. A token obtained
+// by a renewal in flight is still returned to its
+// callers, but not stored.
+func (c *Cache) Forget(key interface{}) {
+	c.mtx.Lock()
+	if r, ok := c.timers[key]; ok {
+		r.t.Stop()
+		delete(c.timers, key)
+	}
+	if cl, ok := c.calls[key]; ok {
+		cl.forgotten = true
+	}
+	c.mtx.Unlock()
+
+	c.cache.Remove(key)
+}
+
+// Stop stops all background renewals, including
+// those of renewals in flight. Tokens which are not
+// present in the cache are still obtained by Get.
+func (c *Cache) Stop() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.stopped = true
+	for k, r := range c.timers {
+		r.t.Stop()
+		delete(c.timers, k)
+	}
+}
+
+// doRenew starts a renewal of the token for key if
+// there is none in flight and returns the call. The
+// renewal is only limited by RenewTimeout, as it is
+// shared by all callers waiting for it.
+//
+// If r is not nil, the renewal is started by the
+// scheduled renewal r and nil is returned if it has
+// been stopped since.
+func (c *Cache) doRenew(key interface{}, r *renewal) *call {
+	c.mtx.Lock()
+	if r != nil && c.timers[key] != r {
+		c.mtx.Unlock()
+		return nil
+	}
+	if cl, ok := c.calls[key]; ok {
+		c.mtx.Unlock()
+		return cl
+	}
+	cl := &call{done: make(chan struct{})}
+	c.calls[key] = cl
+	c.mtx.Unlock()
+
+	go func() {
+		timeout := c.RenewTimeout
+		if timeout == 0 {
+			timeout = DefaultRenewTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		cl.token, cl.err = c.obtain(ctx, key, cl)
+
+		c.mtx.Lock()
+		if c.calls[key] == cl {
+			delete(c.calls, key)
+		}
+		c.mtx.Unlock()
+
+		close(cl.done)
+	}()
+
+	return cl
+}
+
+// obtain calls the RenewFunc for key, stores the
+// result and schedules the next renewal, unless cl
+// has been forgotten in the meantime.
+func (c *Cache) obtain(ctx context.Context, key interface{}, cl *call) (interface{}, error) {
+	token, expires, err := c.renew(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Until(expires)
+	if ttl <= 0 {
+		return token, nil
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if cl.forgotten {
+		return token, nil
+	}
+	c.cache.Set(key, token, ttl)
+	c.schedule(key, ttl-c.renewBefore)
+
+	return token, nil
+}
+
+// schedule sets up the background renewal of the
+// token for key after d, unless the cache has been
+// stopped. The lock of c must be held.
+func (c *Cache) schedule(key interface{}, d time.Duration) {
+	if c.stopped {
+		return
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	if r, ok := c.timers[key]; ok {
+		r.t.Stop()
+	}
+	r := new(renewal)
+	r.t = time.AfterFunc(d, func() {
+		cl := c.doRenew(key, r)
+		if cl == nil {
+			return
+		}
+		<-cl.done
+		if cl.err != nil && c.OnRenewError != nil {
+			c.OnRenewError(key, cl.err)
+		}
+	})
+	c.timers[key] = r
+}
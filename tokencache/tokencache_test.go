@@ -0,0 +1,182 @@
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSingleRenewal(t *testing.T) {
+	var calls int32
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "token", time.Now().Add(time.Hour), nil
+	}, time.Minute)
+	defer c.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := c.Get(context.Background(), "svc")
+			assert.Nil(t, err)
+			assert.Equal(t, "token", tok)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestGetRenewsBeforeExpiry(t *testing.T) {
+	var calls int32
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return n, time.Now().Add(40 * time.Millisecond), nil
+	}, 20*time.Millisecond)
+	defer c.Stop()
+
+	tok, err := c.Get(context.Background(), "svc")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, tok)
+
+	time.Sleep(30 * time.Millisecond)
+	tok, err = c.Get(context.Background(), "svc")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, tok)
+}
+
+func TestGetError(t *testing.T) {
+	errRenew := errors.New("renew failed")
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		return nil, time.Time{}, errRenew
+	}, time.Minute)
+
+	_, err := c.Get(context.Background(), "svc")
+	assert.ErrorIs(t, err, errRenew)
+}
+
+func TestForget(t *testing.T) {
+	var calls int32
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		atomic.AddInt32(&calls, 1)
+		return "token", time.Now().Add(20 * time.Millisecond), nil
+	}, 10*time.Millisecond)
+
+	c.Get(context.Background(), "svc")
+	c.Forget("svc")
+
+	time.Sleep(30 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestGetRenewTimeout(t *testing.T) {
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		<-ctx.Done()
+		return nil, time.Time{}, ctx.Err()
+	}, time.Minute)
+	c.RenewTimeout = 10 * time.Millisecond
+
+	_, err := c.Get(context.Background(), "svc")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestGetFirstCallerCancels(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		close(started)
+		select {
+		case <-unblock:
+		case <-ctx.Done():
+			return nil, time.Time{}, ctx.Err()
+		}
+		return "token", time.Now().Add(time.Hour), nil
+	}, time.Minute)
+	defer c.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := c.Get(ctx, "svc")
+		first <- err
+	}()
+	<-started
+
+	second := make(chan interface{})
+	go func() {
+		tok, err := c.Get(context.Background(), "svc")
+		assert.NoError(t, err)
+		second <- tok
+	}()
+
+	// cancelling the first caller only
+	// stops it from waiting
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(unblock)
+	assert.Equal(t, "token", <-second)
+}
+
+func TestForgetDuringRenewal(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	tm := timedmap.New(time.Minute)
+	c := New(tm, func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-unblock
+		}
+		return "token", time.Now().Add(20 * time.Millisecond), nil
+	}, 10*time.Millisecond)
+	defer c.Stop()
+
+	done := make(chan interface{})
+	go func() {
+		tok, err := c.Get(context.Background(), "svc")
+		assert.NoError(t, err)
+		done <- tok
+	}()
+	<-started
+
+	c.Forget("svc")
+	close(unblock)
+	assert.Equal(t, "token", <-done)
+	assert.False(t, tm.Contains("svc"))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestStopDuringRenewal(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	c := New(timedmap.New(time.Minute), func(ctx context.Context, key interface{}) (interface{}, time.Time, error) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			close(started)
+			<-unblock
+		}
+		return "token", time.Now().Add(20 * time.Millisecond), nil
+	}, 10*time.Millisecond)
+
+	_, err := c.Get(context.Background(), "svc")
+	assert.NoError(t, err)
+
+	// the background renewal is blocked
+	<-started
+	c.Stop()
+	close(unblock)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,43 @@
+package timedmap
+
+import "time"
+
+// addTombstone leaves a tombstone for k at now if
+// tombstones are enabled. The write lock of the map
+// must be held.
+func (tm *TimedMap) addTombstone(k keyWrap, now time.Time) {
+	if tm.opts.tombstone <= 0 {
+		return
+	}
+	if tm.tombstones == nil {
+		tm.tombstones = make(map[keyWrap]time.Time)
+	}
+	tm.tombstones[k] = now.Add(tm.opts.tombstone)
+}
+
+// checkTombstone returns ErrRecentlyDeleted if k has a
+// tombstone at now, unless so resurrects the key, in
+// which case the tombstone is removed. The write lock
+// of the map must be held.
+func (tm *TimedMap) checkTombstone(k keyWrap, now time.Time, so setOptions) error {
+	until, ok := tm.tombstones[k]
+	if !ok {
+		return nil
+	}
+	if so.resurrect || !now.Before(until) {
+		delete(tm.tombstones, k)
+		return nil
+	}
+	return ErrRecentlyDeleted
+}
+
+// sweepTombstones removes all tombstones which have
+// expired at now. The write lock of the map must
+// be held.
+func (tm *TimedMap) sweepTombstones(now time.Time) {
+	for k, until := range tm.tombstones {
+		if !now.Before(until) {
+			delete(tm.tombstones, k)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTombstones(t *testing.T) {
+	tm := NewWithOptions(0, WithTombstones(20*time.Millisecond))
+
+	tm.Set(1, 1, time.Hour)
+	tm.Remove(1)
+
+	assert.ErrorIs(t, tm.SetWithOptions(1, 2, time.Hour), ErrRecentlyDeleted)
+	tm.Set(1, 2, time.Hour)
+	assert.False(t, tm.Contains(1))
+
+	// other sections and keys are not affected
+	assert.NoError(t, tm.Section(1).SetWithOptions(1, 2, time.Hour))
+	assert.NoError(t, tm.SetWithOptions(2, 2, time.Hour))
+
+	time.Sleep(30 * time.Millisecond)
+	tm.cleanUp()
+	assert.Empty(t, tm.tombstones)
+	assert.NoError(t, tm.SetWithOptions(1, 3, time.Hour))
+	assert.Equal(t, 3, tm.GetValue(1))
+}
+
+func TestTombstonesResurrect(t *testing.T) {
+	tm := NewWithOptions(0, WithTombstones(time.Hour))
+
+	tm.Set(1, 1, time.Hour)
+	tm.Remove(1)
+
+	assert.NoError(t, tm.SetWithOptions(1, 2, time.Hour, WithResurrect()))
+	assert.Equal(t, 2, tm.GetValue(1))
+	assert.Empty(t, tm.tombstones)
+}
+
+func TestTombstonesOnlyForLivePairs(t *testing.T) {
+	tm := NewWithOptions(0, WithTombstones(time.Hour))
+
+	tm.Remove(1)
+	tm.Set(2, 2, -time.Second)
+	tm.Remove(2)
+	assert.Empty(t, tm.tombstones)
+
+	assert.NoError(t, tm.SetWithOptions(1, 1, time.Hour))
+	assert.NoError(t, tm.SetWithOptions(2, 2, time.Hour))
+}
+
+func TestTombstonesFlush(t *testing.T) {
+	tm := NewWithOptions(0, WithTombstones(time.Hour))
+
+	tm.Set(1, 1, time.Hour)
+	tm.Section(1).Set(1, 1, time.Hour)
+	tm.Remove(1)
+	tm.Section(1).Remove(1)
+
+	tm.Section(1).Flush()
+	assert.NoError(t, tm.Section(1).SetWithOptions(1, 1, time.Hour))
+	assert.ErrorIs(t, tm.SetWithOptions(1, 1, time.Hour), ErrRecentlyDeleted)
+
+	tm.Flush()
+	assert.NoError(t, tm.SetWithOptions(1, 1, time.Hour))
+}
+
+func TestTombstonesReconfigure(t *testing.T) {
+	tm := NewWithOptions(0)
+
+	assert.NoError(t, tm.Reconfigure(WithTombstones(time.Hour)))
+	tm.Set(1, 1, time.Hour)
+	tm.Remove(1)
+	assert.ErrorIs(t, tm.SetWithOptions(1, 1, time.Hour), ErrRecentlyDeleted)
+}
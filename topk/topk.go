@@ -0,0 +1,130 @@
+// Package topk tracks the most frequently incremented
+// keys within an expiring window using the space-saving
+// algorithm, backed by a timedmap.
+package topk
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jonsen/timedmap"
+)
+
+// Item is a tracked key with its estimated count.
+// The real count is in the range of
+// [Count - Error, Count].
+type Item struct {
+	Key   interface{}
+	Count int64
+	Error int64
+}
+
+// TopK tracks the k most incremented keys. Counters of
+// keys which were not incremented within window expire,
+// which lets the tracked keys follow the current load.
+type TopK struct {
+	mtx   sync.Mutex
+	cache timedmap.Section
+
+	k      int
+	window time.Duration
+}
+
+// counter is the state of a tracked key.
+type counter struct {
+	count int64
+	err   int64
+	last  time.Time
+}
+
+// New creates a new TopK tracking up to k keys in
+// the given cache section. Counters expire when their
+// key is not incremented for the duration of window.
+func New(cache timedmap.Section, k int, window time.Duration) *TopK {
+	return &TopK{
+		cache:  cache,
+		k:      k,
+		window: window,
+	}
+}
+
+// Incr increments the counter of key by one.
+func (t *TopK) Incr(key interface{}) {
+	t.IncrBy(key, 1)
+}
+
+// IncrBy increments the counter of key by n.
+//
+// When key is not tracked yet and k keys are already
+// tracked, the key with the lowest count is replaced
+// and its count is taken over as error.
+func (t *TopK) IncrBy(key interface{}, n int64) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	now := time.Now()
+
+	c, ok := t.cache.GetValue(key).(*counter)
+	if !ok {
+		c = new(counter)
+		// Size also counts expired counters which have
+		// not been cleaned up yet, which do not take a
+		// slot.
+		if len(t.cache.Snapshot()) >= t.k {
+			if minKey, min, ok := t.min(now); ok {
+				t.cache.Remove(minKey)
+				c.count = min.count
+				c.err = min.count
+			}
+		}
+	}
+
+	c.count += n
+	c.last = now
+	t.cache.Set(key, c, t.window)
+}
+
+// Top returns all tracked keys sorted descending
+// by their estimated count.
+func (t *TopK) Top() []Item {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	now := time.Now()
+	items := make([]Item, 0, t.k)
+	for k, v := range t.cache.Snapshot() {
+		if c, ok := v.(*counter); ok && t.live(c, now) {
+			items = append(items, Item{Key: k, Count: c.count, Error: c.err})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Count > items[j].Count
+	})
+
+	return items
+}
+
+// min returns the tracked key with the lowest count.
+// Expired counters are removed and reported as
+// zero count.
+func (t *TopK) min(now time.Time) (minKey interface{}, min *counter, ok bool) {
+	for k, v := range t.cache.Snapshot() {
+		c, isCounter := v.(*counter)
+		if !isCounter || !t.live(c, now) {
+			t.cache.Remove(k)
+			return k, new(counter), true
+		}
+		if !ok || c.count < min.count {
+			minKey, min, ok = k, c, true
+		}
+	}
+	return
+}
+
+// live returns true if c has been incremented
+// within the window.
+func (t *TopK) live(c *counter, now time.Time) bool {
+	return now.Sub(c.last) <= t.window
+}
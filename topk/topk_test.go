@@ -0,0 +1,52 @@
+package topk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonsen/timedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTop(t *testing.T) {
+	tk := New(timedmap.New(time.Minute), 3, time.Minute)
+
+	tk.IncrBy("a", 10)
+	tk.IncrBy("b", 5)
+	tk.IncrBy("c", 1)
+
+	top := tk.Top()
+	assert.Len(t, top, 3)
+	assert.Equal(t, Item{Key: "a", Count: 10}, top[0])
+	assert.Equal(t, Item{Key: "b", Count: 5}, top[1])
+	assert.Equal(t, Item{Key: "c", Count: 1}, top[2])
+}
+
+func TestTopReplacesMin(t *testing.T) {
+	tk := New(timedmap.New(time.Minute), 2, time.Minute)
+
+	tk.IncrBy("a", 10)
+	tk.IncrBy("b", 2)
+	tk.Incr("c")
+
+	top := tk.Top()
+	assert.Len(t, top, 2)
+	assert.Equal(t, Item{Key: "a", Count: 10}, top[0])
+	assert.Equal(t, Item{Key: "c", Count: 3, Error: 2}, top[1])
+}
+
+func TestTopDecay(t *testing.T) {
+	tk := New(timedmap.New(time.Minute), 2, 20*time.Millisecond)
+
+	tk.IncrBy("a", 100)
+	time.Sleep(30 * time.Millisecond)
+	tk.Incr("b")
+	tk.Incr("c")
+
+	top := tk.Top()
+	assert.Len(t, top, 2)
+	for _, it := range top {
+		assert.NotEqual(t, "a", it.Key)
+		assert.EqualValues(t, 1, it.Count)
+	}
+}
@@ -0,0 +1,33 @@
+package timedmap
+
+// TransformFunc transforms the value of a key,
+// for example to encode or decode it.
+type TransformFunc func(key, value interface{}) interface{}
+
+// transformWrite returns value of key transformed by
+// the function passed to WithWriteTransform, or value
+// itself if none was passed.
+func (tm *TimedMap) transformWrite(key, value interface{}) interface{} {
+	if tm.opts.writeTransform == nil {
+		return value
+	}
+	return tm.opts.writeTransform(key, value)
+}
+
+// transformRead returns the stored value of k
+// transformed by the function passed to
+// WithReadTransform, or value itself if none was
+// passed.
+func (tm *TimedMap) transformRead(k keyWrap, value interface{}) interface{} {
+	if tm.opts.readTransform == nil {
+		return value
+	}
+	return tm.opts.readTransform(k.key, value)
+}
+
+// readValue returns the stored value of k as it is
+// returned to callers, transformed like transformRead
+// and copied like copyValue.
+func (tm *TimedMap) readValue(k keyWrap, value interface{}) interface{} {
+	return tm.copyValue(tm.transformRead(k, value))
+}
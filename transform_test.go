@@ -0,0 +1,50 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	var stored interface{}
+	tm := NewWithOptions(0,
+		WithWriteTransform(func(key, value interface{}) interface{} {
+			return []byte(value.(string))
+		}),
+		WithReadTransform(func(key, value interface{}) interface{} {
+			stored = value
+			return string(value.([]byte))
+		}),
+	)
+
+	tm.Set("a", "hello", time.Hour)
+	assert.Equal(t, "hello", tm.GetValue("a"))
+	assert.Equal(t, []byte("hello"), stored)
+
+	v, ok := tm.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "hello", v)
+
+	e, err := tm.GetEntry("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", e.Value)
+
+	assert.Equal(t, map[interface{}]interface{}{"a": "hello"}, tm.Snapshot())
+
+	prev, replaced := tm.SetGet("a", "world", time.Hour)
+	assert.True(t, replaced)
+	assert.Equal(t, "hello", prev)
+
+	var expired interface{}
+	tm.Section(1).Set("b", "bye", -time.Millisecond, func(v interface{}) {
+		expired = v
+	})
+	tm.cleanUp()
+	assert.Equal(t, "bye", expired)
+
+	v, ok = tm.Pop("a")
+	assert.True(t, ok)
+	assert.Equal(t, "world", v)
+}
@@ -0,0 +1,48 @@
+package timedmap
+
+import "time"
+
+// TTLPolicy defines how durations of 0 or below passed
+// to Set are handled, except NoExpiration and
+// DefaultExpiration.
+type TTLPolicy int
+
+const (
+	// TTLExpireOnCleanup stores the key-value pair as
+	// already expired. It is not returned by reads and
+	// is removed and its callbacks are executed on the
+	// next cleanup cycle. This is the default policy.
+	TTLExpireOnCleanup TTLPolicy = iota
+
+	// TTLReject rejects the key-value pair with
+	// ErrInvalidTTL.
+	TTLReject
+
+	// TTLNeverExpire stores the key-value pair like
+	// NoExpiration was passed.
+	TTLNeverExpire
+
+	// TTLExpireNow expires the key-value pair right
+	// after it has been set, executing its callbacks
+	// before Set returns.
+	TTLExpireNow
+)
+
+// resolveTTL applies the TTL policy of the map to
+// expiresAfter and returns the duration the key should
+// be set with and true if it should be expired right
+// after it has been set.
+func (tm *TimedMap) resolveTTL(expiresAfter time.Duration) (time.Duration, bool, error) {
+	if expiresAfter > 0 || expiresAfter == NoExpiration {
+		return expiresAfter, false, nil
+	}
+	switch tm.opts.ttlPolicy {
+	case TTLReject:
+		return 0, false, ErrInvalidTTL
+	case TTLNeverExpire:
+		return NoExpiration, false, nil
+	case TTLExpireNow:
+		return expiresAfter, true, nil
+	}
+	return expiresAfter, false, nil
+}
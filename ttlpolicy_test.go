@@ -0,0 +1,39 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLPolicy(t *testing.T) {
+	tm := New(0)
+	tm.Set(1, 1, 0)
+	assert.Equal(t, 1, tm.Size())
+	assert.False(t, tm.Contains(1))
+
+	tm = NewWithOptions(0, WithTTLPolicy(TTLReject))
+	assert.ErrorIs(t, tm.SetWithOptions(1, 1, 0), ErrInvalidTTL)
+	assert.ErrorIs(t, tm.SetWithOptions(1, 1, -time.Second), ErrInvalidTTL)
+	assert.Nil(t, tm.SetWithOptions(1, 1, NoExpiration))
+	assert.True(t, tm.Contains(1))
+
+	tm = NewWithOptions(0, WithTTLPolicy(TTLNeverExpire))
+	tm.Set(1, 1, 0)
+	assert.True(t, tm.Contains(1))
+	exp, err := tm.GetExpires(1)
+	assert.Nil(t, err)
+	assert.True(t, exp.IsZero())
+
+	tm = NewWithOptions(0, WithTTLPolicy(TTLExpireNow))
+	var expired interface{}
+	tm.Set(1, 1, 0, func(v interface{}) {
+		expired = v
+	})
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, 0, tm.Size())
+
+	assert.Nil(t, tm.Reconfigure(WithTTLPolicy(TTLReject)))
+	assert.ErrorIs(t, tm.SetWithOptions(1, 1, 0), ErrInvalidTTL)
+}
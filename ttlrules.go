@@ -0,0 +1,106 @@
+package timedmap
+
+import "time"
+
+// DefaultExpiration can be passed as duration to Set
+// to apply the TTL of the first rule configured using
+// WithTTLRules whose pattern matches the key. If no rule
+// matches, the key-value pair never expires.
+const DefaultExpiration time.Duration = -2
+
+// TTLRule maps keys matching a pattern to the
+// duration after which they expire when they are
+// set with DefaultExpiration.
+type TTLRule struct {
+	// Pattern is matched against string keys. '*'
+	// matches any sequence of characters and '?'
+	// matches any single character, so "session:*"
+	// matches all keys with the prefix "session:".
+	Pattern string
+
+	// TTL is the duration after which matching keys
+	// expire. Pass NoExpiration for keys which never
+	// expire.
+	TTL time.Duration
+
+	// Sliding resets the expire time of matching
+	// keys to TTL from now each time they are read.
+	Sliding bool
+}
+
+// ruleFor returns the first rule of the
+// map matching key, if any.
+func (tm *TimedMap) ruleFor(key interface{}) (TTLRule, bool) {
+	s, ok := key.(string)
+	if !ok {
+		return TTLRule{}, false
+	}
+	for _, r := range tm.opts.ttlRules {
+		if matchPattern(r.Pattern, s) {
+			return r, true
+		}
+	}
+	return TTLRule{}, false
+}
+
+// applyRules resolves DefaultExpiration using the
+// rules of the map and returns the expiry and the
+// sliding duration the key should be set with.
+func (tm *TimedMap) applyRules(key interface{}, expiresAfter time.Duration) (time.Duration, time.Duration) {
+	if expiresAfter != DefaultExpiration {
+		return expiresAfter, 0
+	}
+	r, ok := tm.ruleFor(key)
+	if !ok {
+		return NoExpiration, 0
+	}
+	if r.Sliding && r.TTL != NoExpiration {
+		return r.TTL, r.TTL
+	}
+	return r.TTL, 0
+}
+
+// slide resets the expire time of the element v of k
+// to its sliding duration from now, if it is still
+// the live element of k.
+func (tm *TimedMap) slide(k keyWrap, v *element) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	now := time.Now()
+	if cur, ok := tm.container.get(k); ok && cur == v && !tm.isExpired(v, now) {
+		old := tm.expirySummary(v)
+		v.setExpiry(now, v.sliding)
+		tm.container.touch(k, v)
+		tm.expiryChanged(k, v, old)
+	}
+}
+
+// matchPattern returns true if s matches the pattern
+// p, where '*' matches any sequence of characters and
+// '?' matches any single character.
+func matchPattern(p, s string) bool {
+	// position to continue at after the last '*'
+	star, next := -1, 0
+
+	i, j := 0, 0
+	for j < len(s) {
+		switch {
+		case i < len(p) && (p[i] == '?' || p[i] == s[j]):
+			i++
+			j++
+		case i < len(p) && p[i] == '*':
+			star, next = i, j
+			i++
+		case star >= 0:
+			next++
+			i, j = star+1, next
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
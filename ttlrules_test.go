@@ -0,0 +1,109 @@
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLRules(t *testing.T) {
+	tm := NewWithOptions(0, WithTTLRules(
+		TTLRule{Pattern: "session:*", TTL: 30 * time.Minute},
+		TTLRule{Pattern: "cfg:*", TTL: NoExpiration},
+		TTLRule{Pattern: "*", TTL: time.Minute},
+	))
+
+	tm.Set("session:1", 1, DefaultExpiration)
+	tm.Set("cfg:a", 1, DefaultExpiration)
+	tm.Set("other", 1, DefaultExpiration)
+	tm.Set("session:2", 1, time.Hour)
+	tm.Set(1, 1, DefaultExpiration)
+
+	expiresIn := func(key interface{}) time.Duration {
+		exp, err := tm.GetExpires(key)
+		assert.NoError(t, err)
+		if exp.IsZero() {
+			return NoExpiration
+		}
+		return time.Until(exp).Round(time.Minute)
+	}
+
+	assert.Equal(t, 30*time.Minute, expiresIn("session:1"))
+	assert.Equal(t, NoExpiration, expiresIn("cfg:a"))
+	assert.Equal(t, time.Minute, expiresIn("other"))
+	assert.Equal(t, time.Hour, expiresIn("session:2"))
+	assert.Equal(t, NoExpiration, expiresIn(1))
+}
+
+func TestTTLRulesSliding(t *testing.T) {
+	tm := NewWithOptions(0, WithTTLRules(
+		TTLRule{Pattern: "session:*", TTL: time.Hour, Sliding: true},
+	))
+
+	tm.Set("session:1", 1, DefaultExpiration)
+	tm.Set("session:2", 1, time.Hour)
+	exp1, _ := tm.GetExpires("session:1")
+	exp2, _ := tm.GetExpires("session:2")
+
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, tm.Contains("session:1"))
+	assert.True(t, tm.Contains("session:2"))
+
+	// explicit durations are not sliding
+	next1, _ := tm.GetExpires("session:1")
+	next2, _ := tm.GetExpires("session:2")
+	assert.True(t, next1.After(exp1))
+	assert.Equal(t, exp2, next2)
+}
+
+func TestTTLRulesReconfigure(t *testing.T) {
+	tm := NewWithOptions(0)
+
+	assert.NoError(t, tm.Reconfigure(WithTTLRules(TTLRule{Pattern: "a", TTL: -time.Second})))
+	tm.Set("a", 1, DefaultExpiration)
+	assert.False(t, tm.Contains("a"))
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		p, s string
+		ok   bool
+	}{
+		{"", "", true},
+		{"*", "", true},
+		{"*", "abc", true},
+		{"a*", "abc", true},
+		{"a*", "bac", false},
+		{"*c", "abc", true},
+		{"a*c", "abbbc", true},
+		{"a*c", "abcd", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"a*b*c", "axbyc", true},
+		{"a*b*c", "axbyd", false},
+		{"user/*", "user/1/x", true},
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.ok, matchPattern(c.p, c.s), "%q %q", c.p, c.s)
+	}
+}
+
+func TestTTLRulesSlidingChanges(t *testing.T) {
+	tm := NewWithOptions(0, WithChangeFeed(10), WithTTLRules(
+		TTLRule{Pattern: "session:*", TTL: time.Hour, Sliding: true},
+	))
+
+	tm.Set("session:1", 1, DefaultExpiration)
+	assert.True(t, tm.Contains("session:1"))
+
+	changes, err := tm.Changes(0)
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, ChangeExpiry, changes[1].Op)
+		assert.Equal(t, "session:1", changes[1].Key)
+		assert.True(t, changes[1].Expires.After(changes[0].Expires))
+	}
+}
@@ -0,0 +1,159 @@
+//go:build go1.18
+// +build go1.18
+
+package timedmap
+
+import "time"
+
+// TypedSection is a strongly typed view of a section
+// of a TimedMap, created using SectionOf. Multiple typed
+// sections can share one map and its cleanup loop.
+type TypedSection[K comparable, V any] struct {
+	s Section
+}
+
+// SectionOf returns a view of the section sec of tm
+// which only accepts keys of type K and values of
+// type V.
+//
+// Key-value pairs set through other views of the same
+// section which have different types are treated as
+// not existent.
+func SectionOf[K comparable, V any](tm *TimedMap, sec int) *TypedSection[K, V] {
+	return &TypedSection[K, V]{
+		s: tm.Section(sec),
+	}
+}
+
+// Ident returns the identifier of the section.
+func (t *TypedSection[K, V]) Ident() int {
+	return t.s.Ident()
+}
+
+// Set sets the value of a key like Section.Set. The
+// callbacks are executed with the value when the
+// key-value pair expires.
+func (t *TypedSection[K, V]) Set(key K, value V, expiresAfter time.Duration, cb ...func(value V)) {
+	if len(cb) == 0 {
+		t.s.Set(key, value, expiresAfter)
+		return
+	}
+	t.s.Set(key, value, expiresAfter, func(v interface{}) {
+		tv, _ := typedValue[V](v)
+		for _, c := range cb {
+			c(tv)
+		}
+	})
+}
+
+// Get returns the value of a key. ok is false if there
+// is no value of type V to the passed key or if the
+// value was expired.
+func (t *TypedSection[K, V]) Get(key K) (value V, ok bool) {
+	v, ok := t.s.Get(key)
+	if !ok {
+		return
+	}
+	return typedValue[V](v)
+}
+
+// GetValue returns the value of a key, or the zero
+// value of V if there is no value of type V to the
+// passed key or if the value was expired.
+func (t *TypedSection[K, V]) GetValue(key K) V {
+	v, _ := t.Get(key)
+	return v
+}
+
+// GetElseSet returns the value of a key or, if there is
+// no value to the key, stores and returns the value
+// returned by fn like Section.GetElseSet.
+func (t *TypedSection[K, V]) GetElseSet(key K, ttl time.Duration, fn func() V) V {
+	v, _ := typedValue[V](t.s.GetElseSet(key, ttl, func() interface{} {
+		return fn()
+	}))
+	return v
+}
+
+// GetOrSet returns the value of a key and true or, if
+// there is no value to the key, stores value and returns
+// it and false like Section.GetOrSet.
+func (t *TypedSection[K, V]) GetOrSet(key K, value V, ttl time.Duration) (actual V, loaded bool) {
+	v, loaded := t.s.GetOrSet(key, value, ttl)
+	actual, _ = typedValue[V](v)
+	return
+}
+
+// Contains returns true if there is a value of
+// type V to the key which has not expired.
+func (t *TypedSection[K, V]) Contains(key K) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// GetExpires returns the expire time of a key-value
+// pair like Section.GetExpires.
+func (t *TypedSection[K, V]) GetExpires(key K) (time.Time, error) {
+	if !t.Contains(key) {
+		return time.Time{}, ErrKeyNotFound
+	}
+	return t.s.GetExpires(key)
+}
+
+// SetExpires sets the expire time of a key-value
+// pair like Section.SetExpires.
+func (t *TypedSection[K, V]) SetExpires(key K, d time.Duration) error {
+	return t.s.SetExpires(key, d)
+}
+
+// Refresh extends the expire time of a key-value
+// pair like Section.Refresh.
+func (t *TypedSection[K, V]) Refresh(key K, d time.Duration) error {
+	return t.s.Refresh(key, d)
+}
+
+// Remove deletes a key-value pair.
+func (t *TypedSection[K, V]) Remove(key K) {
+	t.s.Remove(key)
+}
+
+// Flush deletes all key-value pairs of the section.
+func (t *TypedSection[K, V]) Flush() {
+	t.s.Flush()
+}
+
+// Size returns the number of key-value
+// pairs in the section.
+func (t *TypedSection[K, V]) Size() int {
+	return t.s.Size()
+}
+
+// Snapshot returns a new map holding all key-value
+// pairs of the section with keys of type K and values
+// of type V.
+func (t *TypedSection[K, V]) Snapshot() map[K]V {
+	snap := t.s.Snapshot()
+	m := make(map[K]V, len(snap))
+	for k, v := range snap {
+		tk, ok := k.(K)
+		if !ok {
+			continue
+		}
+		if tv, ok := typedValue[V](v); ok {
+			m[tk] = tv
+		}
+	}
+	return m
+}
+
+// typedValue converts the value v of a key-value pair
+// to V. ok is false if v is not of type V. A nil v is
+// of type V if V is an interface type, since storing
+// a nil interface value drops its type.
+func typedValue[V any](v interface{}) (value V, ok bool) {
+	if v == nil {
+		return value, interface{}(value) == nil
+	}
+	value, ok = v.(V)
+	return
+}
@@ -0,0 +1,53 @@
+//go:build go1.18
+// +build go1.18
+
+package timedmap
+
+import "time"
+
+// Typed is a TimedMap which only accepts keys of type K
+// and values of type V, so that values do not need to
+// be type asserted when they are read.
+//
+// It wraps an untyped TimedMap, which can be obtained
+// using Map for the functionality not covered by the
+// typed methods.
+type Typed[K comparable, V any] struct {
+	*TypedSection[K, V]
+	tm *TimedMap
+}
+
+// NewTyped creates and returns a new instance of Typed
+// like NewWithOptions, which cleans up expired key-value
+// pairs each cleanupTickTime.
+func NewTyped[K comparable, V any](cleanupTickTime time.Duration, opts ...Option) *Typed[K, V] {
+	tm := NewWithOptions(cleanupTickTime, opts...)
+	return &Typed[K, V]{
+		TypedSection: SectionOf[K, V](tm, 0),
+		tm:           tm,
+	}
+}
+
+// Map returns the untyped TimedMap wrapped by t.
+func (t *Typed[K, V]) Map() *TimedMap {
+	return t.tm
+}
+
+// Section returns a typed view of the section sec
+// of the map, which shares the map and its cleanup
+// loop with t.
+func (t *Typed[K, V]) Section(sec int) *TypedSection[K, V] {
+	return SectionOf[K, V](t.tm, sec)
+}
+
+// Stats returns a snapshot of the runtime
+// statistics of the map.
+func (t *Typed[K, V]) Stats() Stats {
+	return t.tm.Stats()
+}
+
+// Close stops the cleanup loop of the map
+// and closes it like TimedMap.Close.
+func (t *Typed[K, V]) Close() error {
+	return t.tm.Close()
+}
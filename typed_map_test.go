@@ -0,0 +1,67 @@
+//go:build go1.18
+// +build go1.18
+
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTyped(t *testing.T) {
+	tm := NewTyped[string, int](0)
+
+	tm.Set("a", 1, time.Hour)
+	assert.Equal(t, 1, tm.GetValue("a"))
+	assert.Equal(t, 0, tm.GetValue("b"))
+
+	v, ok := tm.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	assert.Equal(t, 2, tm.GetElseSet("b", time.Hour, func() int {
+		return 2
+	}))
+	assert.Equal(t, 2, tm.GetElseSet("b", time.Hour, func() int {
+		return 3
+	}))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, tm.Snapshot())
+
+	// Values of other types set through the untyped
+	// map are not visible to the typed one.
+	tm.Map().Set("c", "three", time.Hour)
+	assert.False(t, tm.Contains("c"))
+	assert.Equal(t, 3, tm.Size())
+
+	sec := tm.Section(1)
+	sec.Set("a", 10, time.Hour)
+	assert.Equal(t, 10, sec.GetValue("a"))
+	assert.Equal(t, 1, tm.GetValue("a"))
+
+	var expired int
+	tm.Set("d", 4, -time.Millisecond, func(v int) {
+		expired = v
+	})
+	tm.Map().cleanUp()
+	assert.Equal(t, 4, expired)
+	assert.False(t, tm.Contains("d"))
+
+	assert.NoError(t, tm.Close())
+}
+
+func TestNewTypedNilError(t *testing.T) {
+	tm := NewTyped[string, error](0)
+	defer tm.Close()
+
+	tm.Set("a", nil, time.Hour)
+	err, ok := tm.Get("a")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.True(t, tm.Map().Contains("a"))
+	assert.True(t, tm.Contains("a"))
+
+	_, ok = tm.Get("b")
+	assert.False(t, ok)
+}
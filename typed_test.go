@@ -0,0 +1,102 @@
+//go:build go1.18
+// +build go1.18
+
+package timedmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedTestUser struct {
+	Name string
+}
+
+func TestSectionOf(t *testing.T) {
+	tm := New(dCleanupTick)
+
+	users := SectionOf[string, typedTestUser](tm, 1)
+	counts := SectionOf[int, int](tm, 2)
+	assert.Equal(t, 1, users.Ident())
+
+	var expired typedTestUser
+	users.Set("a", typedTestUser{Name: "alice"}, time.Hour)
+	users.Set("b", typedTestUser{Name: "bob"}, 5*time.Millisecond, func(u typedTestUser) {
+		expired = u
+	})
+	counts.Set(1, 42, time.Hour)
+
+	u, ok := users.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", u.Name)
+
+	n, ok := counts.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, 42, n)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, users.Contains("b"))
+	assert.Equal(t, "bob", expired.Name)
+
+	assert.Equal(t, map[string]typedTestUser{"a": {Name: "alice"}}, users.Snapshot())
+	assert.Equal(t, 1, users.Size())
+
+	users.Remove("a")
+	assert.False(t, users.Contains("a"))
+	assert.Equal(t, 1, counts.Size())
+}
+
+func TestSectionOfMismatchingTypes(t *testing.T) {
+	tm := New(0)
+
+	tm.Section(1).Set("a", 1, time.Hour)
+	tm.Section(1).Set(1, "b", time.Hour)
+
+	s := SectionOf[string, string](tm, 1)
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+	_, err := s.GetExpires("a")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Empty(t, s.Snapshot())
+
+	s.Set("c", "c", time.Hour)
+	assert.NoError(t, s.SetExpires("c", NoExpiration))
+	assert.NoError(t, s.Refresh("c", time.Hour))
+	exp, err := s.GetExpires("c")
+	assert.NoError(t, err)
+	assert.True(t, exp.IsZero())
+
+	s.Flush()
+	assert.Equal(t, 0, s.Size())
+}
+
+func TestSectionOfNilInterfaceValue(t *testing.T) {
+	tm := New(0)
+
+	errs := SectionOf[string, error](tm, 1)
+	var expired bool
+	errs.Set("a", nil, 5*time.Millisecond, func(err error) {
+		expired = err == nil
+	})
+
+	err, ok := errs.Get("a")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.True(t, errs.Contains("a"))
+	assert.Equal(t, map[string]error{"a": nil}, errs.Snapshot())
+
+	actual, loaded := errs.GetOrSet("a", assert.AnError, time.Hour)
+	assert.True(t, loaded)
+	assert.Nil(t, actual)
+
+	// nil is no value of a non-interface type
+	tm.Section(2).Set("a", nil, time.Hour)
+	_, ok = SectionOf[string, string](tm, 2).Get("a")
+	assert.False(t, ok)
+
+	time.Sleep(10 * time.Millisecond)
+	tm.Cleanup()
+	assert.True(t, expired)
+}
@@ -0,0 +1,48 @@
+package timedmap
+
+import "time"
+
+// UpdateFunc computes the new value of a key from its
+// old value and returns the duration after which the new
+// value expires. exists is false if there was no live
+// value to the key, in which case old is nil.
+type UpdateFunc func(old interface{}, exists bool) (value interface{}, ttl time.Duration)
+
+// Update sets the value of a key to the value returned
+// by fn, which is called with the current value while
+// holding the lock of the map, so read-modify-write
+// sequences like incrementing counters are safe under
+// concurrency. fn must not access the map.
+//
+// Like SetWithOptions, Update returns an error if the
+// new value could not be set.
+func (tm *TimedMap) Update(key interface{}, fn UpdateFunc) error {
+	return tm.update(key, 0, fn)
+}
+
+func (s *section) Update(key interface{}, fn UpdateFunc) error {
+	return s.tm.update(key, s.sec, fn)
+}
+
+// update sets the value of the given key in the given
+// section to the value computed by fn.
+func (tm *TimedMap) update(key interface{}, sec int, fn UpdateFunc) error {
+	k := tm.wrapKey(key, sec)
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	var old interface{}
+	v, exists := tm.container.get(k)
+	if exists = exists && !tm.isExpired(v, time.Now()); exists {
+		old = tm.readValue(k, v.value)
+	}
+
+	value, ttl := fn(old, exists)
+	_, _, err := tm.swapLocked(k, value, ttl, setOptions{})
+	return err
+}
@@ -0,0 +1,41 @@
+package timedmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdate(t *testing.T) {
+	tm := New(0)
+
+	incr := func(old interface{}, exists bool) (interface{}, time.Duration) {
+		if !exists {
+			return 1, time.Hour
+		}
+		return old.(int) + 1, time.Hour
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, tm.Update("n", incr))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, tm.GetValue("n"))
+
+	tm.Set("old", 10, -time.Millisecond)
+	assert.NoError(t, tm.Update("old", incr))
+	assert.Equal(t, 1, tm.GetValue("old"))
+
+	assert.NoError(t, tm.Section(1).Update("n", incr))
+	assert.Equal(t, 1, tm.Section(1).GetValue("n"))
+
+	tm.Close()
+	assert.ErrorIs(t, tm.Update("n", incr), ErrClosed)
+}
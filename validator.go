@@ -0,0 +1,9 @@
+package timedmap
+
+// Validator checks the value which should be
+// stored for key and returns an error if the
+// value must be rejected.
+//
+// The validator is executed while the map is locked
+// and must not access the map.
+type Validator func(key, value interface{}) error
@@ -0,0 +1,48 @@
+package timedmap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotInt = errors.New("value is not an int")
+
+func intValidator(key, value interface{}) error {
+	if _, ok := value.(int); !ok {
+		return errNotInt
+	}
+	return nil
+}
+
+func TestWithValidator(t *testing.T) {
+	tm := NewWithOptions(dCleanupTick, WithValidator(intValidator))
+
+	for _, s := range []Section{tm, tm.Section(1)} {
+		assert.Nil(t, s.SetWithOptions("a", 1, time.Hour))
+
+		err := s.SetWithOptions("a", "1", time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidValue)
+		assert.ErrorIs(t, err, errNotInt)
+
+		var verr *ValidationError
+		assert.True(t, errors.As(err, &verr))
+		assert.Equal(t, "a", verr.Key)
+		assert.EqualValues(t, 1, s.GetValue("a"))
+
+		err = s.SetCtx(context.Background(), "b", nil, time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidValue)
+		assert.False(t, s.Contains("b"))
+
+		s.Set("c", 1.5, time.Hour)
+		assert.False(t, s.Contains("c"))
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Key: "a", Err: errNotInt}
+	assert.Equal(t, "invalid value for key a: value is not an int", err.Error())
+}
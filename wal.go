@@ -0,0 +1,358 @@
+package timedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// walMagic identifies the write-ahead
+// logs written using WithWAL.
+var walMagic = [4]byte{'T', 'M', 'W', 'L'}
+
+const (
+	// walVersion is the version of the
+	// write-ahead log format.
+	walVersion uint16 = 1
+
+	// walSyncInterval is the interval in which
+	// the write-ahead log is synced to disk.
+	walSyncInterval = 5 * time.Millisecond
+)
+
+// walRecord is a change of the map as
+// stored in the write-ahead log.
+type walRecord struct {
+	Op      ChangeOp
+	Section int
+	All     bool
+	Key     interface{}
+	Value   interface{}
+	Meta    interface{}
+	Expires time.Time
+	Grace   time.Duration
+	Sliding time.Duration
+}
+
+// writeAheadLog appends the changes of a map to a file,
+// which is synced to disk in the background.
+type writeAheadLog struct {
+	mtx   sync.Mutex
+	path  string
+	f     *os.File
+	w     *bufio.Writer
+	buf   bytes.Buffer
+	dirty bool
+	err   error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WALError returns the first error which occurred
+// while reading, writing or syncing the write-ahead
+// log configured using WithWAL, if any.
+func (tm *TimedMap) WALError() error {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.wal == nil {
+		return nil
+	}
+	tm.wal.mtx.Lock()
+	defer tm.wal.mtx.Unlock()
+	return tm.wal.err
+}
+
+// CompactWAL rewrites the write-ahead log configured
+// using WithWAL so that it only contains the live
+// key-value pairs of the map, as the log grows with
+// every change until then. The log is also compacted
+// each time it is replayed.
+//
+// As the compacted log holds the whole state of the
+// map, replaying it using Recover discards all pairs
+// of the snapshot it is replayed over.
+func (tm *TimedMap) CompactWAL() error {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+	if tm.wal == nil {
+		return ErrNoWAL
+	}
+
+	return tm.wal.rewrite(tm.walRecords(time.Now()))
+}
+
+// walRecords returns the records setting all live
+// key-value pairs of the map at now. The lock of
+// the map must be held.
+func (tm *TimedMap) walRecords(now time.Time) []walRecord {
+	var records []walRecord
+	tm.container.each(func(k keyWrap, v *element) bool {
+		if !tm.isExpired(v, now) {
+			records = append(records, walRecordOf(ChangeSet, k, v))
+		}
+		return true
+	})
+	return records
+}
+
+// openWAL replays the write-ahead log at path into the
+// map, which must not be used concurrently yet, and
+// opens it for appending the following changes. The
+// pairs are set like using Set, so pairs rejected by
+// the map are dropped from the log.
+func (tm *TimedMap) openWAL(path string) {
+	tm.wal = &writeAheadLog{path: path}
+
+	records, err := readWAL(path)
+	if err != nil {
+		tm.wal.err = err
+		return
+	}
+
+	now := time.Now()
+	tm.restoreChanges(records, now)
+
+	if err = tm.wal.rewrite(tm.walRecords(now)); err != nil {
+		return
+	}
+	tm.wal.stop = make(chan struct{})
+	tm.wal.done = make(chan struct{})
+	go tm.wal.syncLoop()
+}
+
+// logChange appends a change of op for the element v
+// of k to the write-ahead log, if the map has one.
+// The write lock of the map must be held.
+func (tm *TimedMap) logChange(op ChangeOp, k keyWrap, v *element) {
+	if tm.wal == nil {
+		return
+	}
+	tm.wal.append(walRecordOf(op, k, v))
+}
+
+// logFlush appends a flush of the given section, or
+// of all sections if all is true, to the write-ahead
+// log, if the map has one. The write lock of the map
+// must be held.
+func (tm *TimedMap) logFlush(sec int, all bool) {
+	if tm.wal == nil {
+		return
+	}
+	tm.wal.append(walRecord{Op: ChangeFlush, Section: sec, All: all})
+}
+
+// walRecordOf returns the record of a change
+// of op for the element v of k.
+func walRecordOf(op ChangeOp, k keyWrap, v *element) walRecord {
+	rec := walRecord{Op: op, Section: k.sec, Key: k.key}
+	if op == ChangeSet {
+		rec.Value = v.value
+		rec.Meta = v.meta
+		rec.Grace = v.grace
+		rec.Sliding = v.sliding
+	}
+	if (op == ChangeSet || op == ChangeExpiry) && v.expired {
+		rec.Expires = v.expires.Round(0)
+	}
+	return rec
+}
+
+// readWAL reads the records of the write-ahead log at
+// path like readWALFrom. A missing log has no records.
+func readWAL(path string) ([]walRecord, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readWALFrom(f)
+}
+
+// readWALFrom reads the records of the write-ahead log
+// in r. Records after a truncated or corrupted record,
+// which was being written when the process died, are
+// ignored.
+func readWALFrom(r io.Reader) ([]walRecord, error) {
+	sr := &snapshotReader{
+		r:   bufio.NewReader(r),
+		sum: crc32.NewIEEE(),
+	}
+
+	var header struct {
+		Magic   [4]byte
+		Version uint16
+	}
+	var err error
+	if err = sr.read(&header); err != nil {
+		return nil, err
+	}
+	if header.Magic != walMagic || header.Version != walVersion {
+		return nil, sr.fail(0, ErrSnapshotVersion)
+	}
+
+	var records []walRecord
+	for {
+		if _, err = sr.r.Peek(1); err == io.EOF {
+			return records, nil
+		}
+
+		var length uint32
+		if err = sr.read(&length); err != nil {
+			break
+		}
+		var rec walRecord
+		if err = sr.readRecord(length, &rec); err != nil {
+			break
+		}
+		records = append(records, rec)
+		sr.record++
+	}
+
+	if serr, ok := err.(*SnapshotError); ok && serr.Err != ErrSnapshotVersion {
+		return records, nil
+	}
+	return nil, err
+}
+
+// append appends rec to the log. Errors are kept
+// and reported by WALError.
+func (l *writeAheadLog) append(rec walRecord) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.w == nil {
+		return
+	}
+	if err := writeRecord(l.w, &l.buf, rec); err != nil && l.err == nil {
+		l.err = err
+	}
+	l.dirty = true
+}
+
+// rewrite replaces the log with a new log containing
+// records and opens it for appending. The new log starts
+// with a flush of all sections, so that it replaces the
+// pairs of any snapshot it is replayed over.
+func (l *writeAheadLog) rewrite(records []walRecord) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	err := l.rewriteLocked(records)
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+	return err
+}
+
+func (l *writeAheadLog) rewriteLocked(records []walRecord) error {
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = binary.Write(w, binary.BigEndian, walMagic)
+	if err == nil {
+		err = binary.Write(w, binary.BigEndian, walVersion)
+	}
+	if err == nil {
+		err = writeRecord(w, &l.buf, walRecord{Op: ChangeFlush, All: true})
+	}
+	for i := 0; err == nil && i < len(records); i++ {
+		err = writeRecord(w, &l.buf, records[i])
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, l.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if l.f != nil {
+		l.f.Close()
+	}
+	l.f, l.w, l.dirty = f, w, false
+	return nil
+}
+
+// syncLoop flushes and syncs the log
+// every walSyncInterval until closed.
+func (l *writeAheadLog) syncLoop() {
+	defer close(l.done)
+
+	t := time.NewTicker(walSyncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			l.mtx.Lock()
+			l.sync()
+			l.mtx.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// sync flushes and syncs the log if it has
+// been written to. The lock of l must be held.
+func (l *writeAheadLog) sync() {
+	if !l.dirty || l.w == nil {
+		return
+	}
+	l.dirty = false
+
+	err := l.w.Flush()
+	if err == nil {
+		err = l.f.Sync()
+	}
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+}
+
+// close syncs and closes the log and returns
+// the first error which occurred, if any.
+func (l *writeAheadLog) close() error {
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.w != nil {
+		l.sync()
+		if err := l.f.Close(); err != nil && l.err == nil {
+			l.err = err
+		}
+		l.f, l.w = nil, nil
+	}
+	return l.err
+}
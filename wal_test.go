@@ -0,0 +1,173 @@
+package timedmap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempWAL(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "timedmap")
+	assert.NoError(t, err)
+	return filepath.Join(dir, "wal"), func() { os.RemoveAll(dir) }
+}
+
+func TestWAL(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	tm := NewWithOptions(0, WithWAL(path))
+	tm.Set(1, "a", time.Hour)
+	tm.Set(2, "b", time.Hour)
+	tm.Set(3, "c", NoExpiration)
+	tm.Remove(2)
+	assert.NoError(t, tm.SetExpires(3, time.Hour))
+	tm.Section(1).Set(1, "d", time.Hour)
+	tm.Section(2).Set(1, "e", time.Hour)
+	tm.Section(2).Flush()
+	tm.Set(4, "f", 5*time.Millisecond)
+	exp, err := tm.GetExpires(3)
+	assert.NoError(t, err)
+	assert.NoError(t, tm.Close())
+
+	time.Sleep(10 * time.Millisecond)
+
+	tm = NewWithOptions(0, WithWAL(path))
+	defer tm.Close()
+	assert.NoError(t, tm.WALError())
+	assert.Equal(t, "a", tm.GetValue(1))
+	assert.False(t, tm.Contains(2))
+	assert.Equal(t, "c", tm.GetValue(3))
+	assert.False(t, tm.Contains(4))
+	assert.Equal(t, "d", tm.Section(1).GetValue(1))
+	assert.False(t, tm.Section(2).Contains(1))
+
+	rexp, err := tm.GetExpires(3)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, exp, rexp, time.Millisecond)
+
+	// changes are synced without closing the map,
+	// so a copy of the log reflects them
+	tm.Set(5, "g", time.Hour)
+	time.Sleep(10 * walSyncInterval)
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path+".copy", data, 0644))
+
+	crashed := NewWithOptions(0, WithWAL(path+".copy"))
+	assert.Equal(t, "g", crashed.GetValue(5))
+	assert.NoError(t, crashed.Close())
+}
+
+func TestWALTruncated(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	tm := NewWithOptions(0, WithWAL(path))
+	tm.Set(1, "a", time.Hour)
+	tm.Set(2, "b", time.Hour)
+	assert.NoError(t, tm.Close())
+
+	// a record which was being written during
+	// a crash is dropped on replay
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data[:len(data)-5], 0644))
+
+	tm = NewWithOptions(0, WithWAL(path))
+	assert.NoError(t, tm.WALError())
+	assert.Equal(t, "a", tm.GetValue(1))
+	assert.False(t, tm.Contains(2))
+	tm.Set(3, "c", time.Hour)
+	assert.NoError(t, tm.Close())
+
+	tm = NewWithOptions(0, WithWAL(path))
+	defer tm.Close()
+	assert.Equal(t, "a", tm.GetValue(1))
+	assert.Equal(t, "c", tm.GetValue(3))
+}
+
+func TestWALInvalid(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("not a log"), 0644))
+
+	tm := NewWithOptions(0, WithWAL(path))
+	assert.ErrorIs(t, tm.WALError(), ErrSnapshotVersion)
+	tm.Set(1, "a", time.Hour)
+	assert.Equal(t, "a", tm.GetValue(1))
+	assert.ErrorIs(t, tm.Close(), ErrSnapshotVersion)
+
+	// the file is not overwritten
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "not a log", string(data))
+
+	assert.ErrorIs(t, New(0).CompactWAL(), ErrNoWAL)
+}
+
+func TestCompactWAL(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	tm := NewWithOptions(0, WithWAL(path))
+	defer tm.Close()
+	for i := 0; i < 100; i++ {
+		tm.Set(i%10, i, time.Hour)
+	}
+	time.Sleep(10 * walSyncInterval)
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tm.CompactWAL())
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	tm.Set(10, 10, time.Hour)
+	time.Sleep(10 * walSyncInterval)
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path+".copy", data, 0644))
+
+	restored := NewWithOptions(0, WithWAL(path+".copy"))
+	defer restored.Close()
+	assert.Equal(t, 11, restored.Size())
+	assert.Equal(t, 99, restored.GetValue(9))
+}
+
+func TestWALReplayChecks(t *testing.T) {
+	path, cleanup := tempWAL(t)
+	defer cleanup()
+
+	tm := NewWithOptions(0, WithWAL(path))
+	tm.Set(1, 1, time.Hour)
+	tm.Set(2, "b", time.Hour)
+	tm.SetWithOptions(3, 3, 5*time.Millisecond, WithGrace(time.Hour))
+	exp, err := tm.GetExpires(3)
+	assert.NoError(t, err)
+	assert.NoError(t, tm.Close())
+	time.Sleep(10 * time.Millisecond)
+
+	// pairs are validated like set using Set and
+	// rejected ones are dropped from the log
+	tm = NewWithOptions(0, WithWAL(path), WithValidator(intValidator))
+	assert.Equal(t, 1, tm.GetValue(1))
+	assert.False(t, tm.Contains(2))
+
+	// pairs in their grace period keep their expire time
+	rexp, err := tm.GetExpires(3)
+	assert.NoError(t, err)
+	assert.Equal(t, exp.Round(0), rexp.Round(0))
+	assert.NoError(t, tm.Close())
+
+	tm = NewWithOptions(0, WithWAL(path))
+	defer tm.Close()
+	assert.False(t, tm.Contains(2))
+	assert.Equal(t, 2, tm.Size())
+}
@@ -0,0 +1,66 @@
+package timedmap
+
+import "time"
+
+// WeakMap is an experimental map holding pointer values
+// weakly, so that they can be reclaimed by the garbage
+// collector before they expire if nothing else references
+// them. Reclaimed values are treated like expired ones.
+//
+// Values which are not pointers are held like in a
+// TimedMap. When the package is built with a Go version
+// without weak pointer support (before Go 1.24), all
+// values are held strongly.
+type WeakMap struct {
+	tm *TimedMap
+}
+
+// NewWeakMap creates a new WeakMap with the given
+// cleanup tick time like New.
+func NewWeakMap(cleanupTickTime time.Duration, tickerChan ...<-chan time.Time) *WeakMap {
+	return &WeakMap{
+		tm: New(cleanupTickTime, tickerChan...),
+	}
+}
+
+// Set sets the value of key, which expires after
+// expiresAfter unless it is reclaimed before.
+func (m *WeakMap) Set(key, value interface{}, expiresAfter time.Duration) {
+	m.tm.Set(key, makeWeak(value), expiresAfter)
+}
+
+// Get returns the value of key. ok is false if there is
+// no value to the key or if the value has expired or
+// has been reclaimed.
+func (m *WeakMap) Get(key interface{}) (value interface{}, ok bool) {
+	v, ok := m.tm.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if value, ok = resolveWeak(v); !ok {
+		// the value may have been set again since
+		m.tm.RemoveAfterConfirm(key, func(v interface{}) bool {
+			_, ok := resolveWeak(v)
+			return !ok
+		})
+	}
+	return
+}
+
+// Contains returns true if the value of key has
+// neither expired nor been reclaimed.
+func (m *WeakMap) Contains(key interface{}) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Remove removes the value of key.
+func (m *WeakMap) Remove(key interface{}) {
+	m.tm.Remove(key)
+}
+
+// Close stops the cleanup loop of the map and
+// removes all of its values like TimedMap.Close.
+func (m *WeakMap) Close() error {
+	return m.tm.Close()
+}
@@ -0,0 +1,45 @@
+//go:build go1.24
+// +build go1.24
+
+package timedmap
+
+import (
+	"reflect"
+	"unsafe"
+	"weak"
+)
+
+// weakRef is a weak reference to a pointer value
+// together with its type, which is required to
+// restore the value.
+type weakRef struct {
+	typ reflect.Type
+	p   weak.Pointer[byte]
+}
+
+// makeWeak returns a weak reference to value
+// if it is a non-nil pointer, otherwise value.
+func makeWeak(value interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return value
+	}
+	return weakRef{
+		typ: rv.Type(),
+		p:   weak.Make((*byte)(rv.UnsafePointer())),
+	}
+}
+
+// resolveWeak returns the value referenced by v,
+// which is false if it has been reclaimed.
+func resolveWeak(v interface{}) (interface{}, bool) {
+	ref, ok := v.(weakRef)
+	if !ok {
+		return v, true
+	}
+	p := ref.p.Value()
+	if p == nil {
+		return nil, false
+	}
+	return reflect.NewAt(ref.typ.Elem(), unsafe.Pointer(p)).Interface(), true
+}
@@ -0,0 +1,15 @@
+//go:build !go1.24
+// +build !go1.24
+
+package timedmap
+
+// makeWeak returns value, because weak
+// pointers are not supported.
+func makeWeak(value interface{}) interface{} {
+	return value
+}
+
+// resolveWeak returns v.
+func resolveWeak(v interface{}) (interface{}, bool) {
+	return v, true
+}
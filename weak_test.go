@@ -0,0 +1,63 @@
+//go:build go1.24
+// +build go1.24
+
+package timedmap
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type weakTestValue struct {
+	n    int
+	data [64]byte
+}
+
+func TestWeakMap(t *testing.T) {
+	m := NewWeakMap(dCleanupTick)
+	defer m.Close()
+
+	kept := &weakTestValue{n: 1}
+	m.Set(1, kept, time.Hour)
+	m.Set(2, &weakTestValue{n: 2}, time.Hour)
+	m.Set(3, 3, time.Hour)
+
+	v, ok := m.Get(1)
+	assert.True(t, ok)
+	assert.Same(t, kept, v)
+
+	runtime.GC()
+	runtime.GC()
+
+	v, ok = m.Get(1)
+	assert.True(t, ok)
+	assert.Same(t, kept, v)
+	runtime.KeepAlive(kept)
+
+	// reclaimed values are removed on access
+	assert.False(t, m.Contains(2))
+	assert.False(t, m.tm.Contains(2))
+
+	v, ok = m.Get(3)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
+
+func TestWeakMapExpires(t *testing.T) {
+	m := NewWeakMap(dCleanupTick)
+	defer m.Close()
+
+	kept := &weakTestValue{n: 1}
+	m.Set(1, kept, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	assert.False(t, m.Contains(1))
+	runtime.KeepAlive(kept)
+
+	m.Set(2, kept, time.Hour)
+	m.Remove(2)
+	assert.False(t, m.Contains(2))
+}
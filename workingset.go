@@ -0,0 +1,182 @@
+package timedmap
+
+import "time"
+
+// WorkingSet is an isolated copy of a set of key-value
+// pairs checked out of a map using Checkout, which can
+// be changed freely and then committed back to the map
+// at once.
+//
+// A WorkingSet is not safe for concurrent use.
+type WorkingSet struct {
+	tm   *TimedMap
+	sec  int
+	keys map[interface{}]*workingEntry
+}
+
+// workingEntry is the state of a key of a working set.
+type workingEntry struct {
+	// base identifies the pair in the map at the
+	// time of the checkout or the last commit. base
+	// is nil if there was no live pair.
+	base *element
+	rev  uint64
+
+	value  interface{}
+	ttl    time.Duration
+	exists bool
+	dirty  bool
+}
+
+// Checkout copies the key-value pairs of the passed keys
+// into a new working set. Keys which are not present in
+// the map can be checked out as well to add them.
+func (tm *TimedMap) Checkout(keys ...interface{}) (*WorkingSet, error) {
+	return tm.CheckoutOf(0, keys...)
+}
+
+// CheckoutOf copies the key-value pairs of the passed
+// keys in the given section into a new working set
+// like Checkout.
+func (tm *TimedMap) CheckoutOf(sec int, keys ...interface{}) (*WorkingSet, error) {
+	tm.mtx.RLock()
+	defer tm.mtx.RUnlock()
+
+	if tm.closed {
+		return nil, ErrClosed
+	}
+
+	ws := &WorkingSet{
+		tm:   tm,
+		sec:  sec,
+		keys: make(map[interface{}]*workingEntry, len(keys)),
+	}
+	now := time.Now()
+	for _, key := range keys {
+		k := tm.wrapKey(key, sec)
+		e := new(workingEntry)
+		if v, ok := tm.container.get(k); ok && !tm.isExpired(v, now) {
+			e.base, e.rev = v, v.rev
+			e.value, e.exists = tm.readValue(k, v.value), true
+		}
+		ws.keys[k.key] = e
+	}
+	return ws, nil
+}
+
+// Get returns the value of a key in the working set.
+// ok is false if the key is not checked out or has no
+// value.
+func (ws *WorkingSet) Get(key interface{}) (value interface{}, ok bool) {
+	e, checkedOut := ws.keys[ws.tm.wrapKey(key, ws.sec).key]
+	if !checkedOut || !e.exists {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set sets the value of a key in the working set, which
+// expires after ttl once committed. If the key has not
+// been checked out, ErrNotCheckedOut is returned.
+func (ws *WorkingSet) Set(key, value interface{}, ttl time.Duration) error {
+	e, ok := ws.keys[ws.tm.wrapKey(key, ws.sec).key]
+	if !ok {
+		return ErrNotCheckedOut
+	}
+	e.value, e.ttl = value, ttl
+	e.exists, e.dirty = true, true
+	return nil
+}
+
+// Remove deletes a key in the working set. If the key
+// has not been checked out, ErrNotCheckedOut is returned.
+func (ws *WorkingSet) Remove(key interface{}) error {
+	e, ok := ws.keys[ws.tm.wrapKey(key, ws.sec).key]
+	if !ok {
+		return ErrNotCheckedOut
+	}
+	e.value, e.ttl = nil, 0
+	e.exists, e.dirty = false, true
+	return nil
+}
+
+// Commit writes all changes of the working set back to
+// the map at once. If any checked out key has been set,
+// removed or expired in the map since the checkout or
+// the last commit, ErrConflict is returned. All values
+// are checked like SetWithOptions would before any change
+// is written, so either all or none of the changes are
+// applied.
+//
+// After a successful commit, the working set can be
+// changed and committed again.
+func (ws *WorkingSet) Commit() error {
+	tm := ws.tm
+
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+
+	if tm.closed {
+		return ErrClosed
+	}
+
+	now := time.Now()
+	for key, e := range ws.keys {
+		k := keyWrap{sec: ws.sec, key: key}
+		v, ok := tm.container.get(k)
+		live := ok && !tm.isExpired(v, now)
+		if live != (e.base != nil) || live && (v != e.base || v.rev != e.rev) {
+			return ErrConflict
+		}
+		if e.dirty && e.exists {
+			if err := tm.checkWritable(k, now, e.value, e.ttl); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, e := range ws.keys {
+		if !e.dirty {
+			continue
+		}
+		k := keyWrap{sec: ws.sec, key: key}
+		if e.exists {
+			tm.swapLocked(k, e.value, e.ttl, setOptions{})
+		} else {
+			tm.removeLocked(k, "")
+		}
+
+		e.base, e.rev, e.dirty = nil, 0, false
+		if v, ok := tm.container.get(k); ok && !tm.isExpired(v, now) {
+			e.base, e.rev = v, v.rev
+		}
+	}
+	return nil
+}
+
+// checkWritable returns the error which setting the value
+// of k to val at now would fail with, without changing
+// the map. The write lock of the map must be held.
+func (tm *TimedMap) checkWritable(k keyWrap, now time.Time, val interface{}, expiresAfter time.Duration) error {
+	if until, ok := tm.tombstones[k]; ok && now.Before(until) {
+		return ErrRecentlyDeleted
+	}
+	if err := tm.opts.checkSize(k.key, val); err != nil {
+		return err
+	}
+	if tm.opts.validator != nil {
+		if err := tm.opts.validator(k.key, val); err != nil {
+			return &ValidationError{Key: k.key, Err: err}
+		}
+	}
+	if tm.opts.writeLimit > 0 {
+		v, ok := tm.container.get(k)
+		if ok && !tm.isExpired(v, now) && now.Sub(v.window) < tm.opts.writeWindow &&
+			v.writes >= tm.opts.writeLimit {
+			return ErrRateLimited
+		}
+	}
+	expiresAfter, _ = tm.applyRules(k.key, expiresAfter)
+	_, _, err := tm.resolveTTL(expiresAfter)
+	return err
+}
@@ -0,0 +1,91 @@
+package timedmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkingSet(t *testing.T) {
+	tm := New(0)
+	tm.Set("a", 1, time.Hour)
+	tm.Set("b", 2, time.Hour)
+
+	ws, err := tm.Checkout("a", "b", "c")
+	assert.NoError(t, err)
+
+	v, ok := ws.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	_, ok = ws.Get("c")
+	assert.False(t, ok)
+
+	assert.NoError(t, ws.Set("a", 10, time.Hour))
+	assert.NoError(t, ws.Remove("b"))
+	assert.NoError(t, ws.Set("c", 30, time.Hour))
+	assert.ErrorIs(t, ws.Set("d", 40, time.Hour), ErrNotCheckedOut)
+
+	// changes are isolated until committed
+	assert.Equal(t, 1, tm.GetValue("a"))
+	assert.False(t, tm.Contains("c"))
+
+	assert.NoError(t, ws.Commit())
+	assert.Equal(t, 10, tm.GetValue("a"))
+	assert.False(t, tm.Contains("b"))
+	assert.Equal(t, 30, tm.GetValue("c"))
+
+	// the working set can be committed again
+	assert.NoError(t, ws.Set("a", 11, time.Hour))
+	assert.NoError(t, ws.Commit())
+	assert.Equal(t, 11, tm.GetValue("a"))
+}
+
+func TestWorkingSetConflict(t *testing.T) {
+	tm := New(0)
+	tm.Set("a", 1, time.Hour)
+
+	ws, err := tm.Checkout("a", "b")
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Set("a", 2, time.Hour))
+
+	tm.Set("a", 1, time.Hour)
+	assert.ErrorIs(t, ws.Commit(), ErrConflict)
+	assert.Equal(t, 1, tm.GetValue("a"))
+
+	ws, err = tm.Checkout("a", "b")
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Set("a", 2, time.Hour))
+	tm.Section(1).Set("b", 1, time.Hour)
+	assert.NoError(t, ws.Commit())
+
+	ws, err = tm.CheckoutOf(1, "b")
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Set("b", 2, time.Hour))
+	tm.Section(1).Remove("b")
+	assert.ErrorIs(t, ws.Commit(), ErrConflict)
+}
+
+func TestWorkingSetAllOrNothing(t *testing.T) {
+	tm := NewWithOptions(0, WithValidator(func(key, value interface{}) error {
+		if value == "bad" {
+			return errors.New("bad value")
+		}
+		return nil
+	}))
+	tm.Set("a", "good", time.Hour)
+
+	ws, err := tm.Checkout("a", "b")
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Set("a", "better", time.Hour))
+	assert.NoError(t, ws.Set("b", "bad", time.Hour))
+
+	assert.ErrorIs(t, ws.Commit(), ErrInvalidValue)
+	assert.Equal(t, "good", tm.GetValue("a"))
+	assert.False(t, tm.Contains("b"))
+
+	tm.Close()
+	_, err = tm.Checkout("a")
+	assert.ErrorIs(t, err, ErrClosed)
+}